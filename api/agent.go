@@ -4,18 +4,15 @@
 package api
 
 import (
-	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/blang/semver"
 	client "github.com/mattermost/mattermost-load-test-ng/api/client/agent"
@@ -24,18 +21,22 @@ import (
 	"github.com/mattermost/mattermost-load-test-ng/loadtest"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/control"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/browsercontroller"
-	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/clustercontroller"
-	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/gencontroller"
-	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/noopcontroller"
-	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/simplecontroller"
-	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/simulcontroller"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/store/memstore"
+	ltuser "github.com/mattermost/mattermost-load-test-ng/loadtest/user"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/user/userentity"
 	"github.com/mattermost/mattermost-load-test-ng/performance"
 
 	"github.com/gorilla/mux"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/shared/mlog"
+
+	// The built-in controllers register themselves on import.
+	_ "github.com/mattermost/mattermost-load-test-ng/loadtest/control/botcontroller"
+	_ "github.com/mattermost/mattermost-load-test-ng/loadtest/control/clustercontroller"
+	_ "github.com/mattermost/mattermost-load-test-ng/loadtest/control/gencontroller"
+	_ "github.com/mattermost/mattermost-load-test-ng/loadtest/control/noopcontroller"
+	_ "github.com/mattermost/mattermost-load-test-ng/loadtest/control/simplecontroller"
+	_ "github.com/mattermost/mattermost-load-test-ng/loadtest/control/simulcontroller"
 )
 
 func writeAgentResponse(w http.ResponseWriter, status int, resp *client.AgentResponse) {
@@ -52,9 +53,13 @@ func getAmount(r *http.Request) (int, error) {
 
 func (a *api) createLoadAgentHandler(w http.ResponseWriter, r *http.Request) {
 	var data struct {
-		LoadTestConfig         loadtest.Config
-		SimpleControllerConfig *simplecontroller.Config `json:",omitempty"`
-		SimulControllerConfig  *simulcontroller.Config  `json:",omitempty"`
+		LoadTestConfig loadtest.Config
+		// The config of the UserController, decoded according to its type.
+		ControllerConfig json.RawMessage `json:",omitempty"`
+		// Deprecated: kept for compatibility with older clients, which sent
+		// the config of the UserController in a field specific to its type.
+		SimpleControllerConfig json.RawMessage `json:",omitempty"`
+		SimulControllerConfig  json.RawMessage `json:",omitempty"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
 		writeAgentResponse(w, http.StatusBadRequest, &client.AgentResponse{
@@ -70,29 +75,34 @@ func (a *api) createLoadAgentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	isBAInstance, err := isBrowserAgentInstance()
+	if err != nil {
+		mlog.Warn("failed to detect agent_type. Going ahead assuming it's a server agent", mlog.Err(err))
+	}
+
+	// Browser agents run their own controller regardless of the type, so
+	// the config is only read by server agents.
 	var ucConfig interface{}
-	var err error
-	switch ltConfig.UserControllerConfiguration.Type {
-	case loadtest.UserControllerSimple:
-		if data.SimpleControllerConfig == nil {
-			mlog.Warn("could not read controller config from the request")
-			ucConfig, err = simplecontroller.ReadConfig("")
-			break
+	if !isBAInstance {
+		rawConfig := data.ControllerConfig
+		if len(rawConfig) == 0 {
+			rawConfig = data.SimpleControllerConfig
+		}
+		if len(rawConfig) == 0 {
+			rawConfig = data.SimulControllerConfig
 		}
-		ucConfig = data.SimpleControllerConfig
-	case loadtest.UserControllerSimulative:
-		if data.SimulControllerConfig == nil {
+
+		var err error
+		ucConfig, err = control.DecodeControllerConfig(string(ltConfig.UserControllerConfiguration.Type), rawConfig)
+		if err != nil {
+			writeAgentResponse(w, http.StatusBadRequest, &client.AgentResponse{
+				Error: fmt.Sprintf("could not read controller configuration: %s", err),
+			})
+			return
+		}
+		if len(rawConfig) == 0 && ucConfig != nil {
 			mlog.Warn("could not read controller config from the request")
-			ucConfig, err = simulcontroller.ReadConfig("")
-			break
 		}
-		ucConfig = data.SimulControllerConfig
-	}
-	if err != nil {
-		writeAgentResponse(w, http.StatusBadRequest, &client.AgentResponse{
-			Error: fmt.Sprintf("could not read controller configuration: %s", err),
-		})
-		return
 	}
 	if ucConfig != nil {
 		if err := defaults.Validate(ucConfig); err != nil {
@@ -117,11 +127,6 @@ func (a *api) createLoadAgentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	isBAInstance, err := isBrowserAgentInstance()
-	if err != nil {
-		mlog.Warn("failed to detect agent_type. Going ahead assuming it's a server agent", mlog.Err(err))
-	}
-
 	// Read and validate the browsercontroller.json that was uploaded by
 	// Terraform to confirm it landed correctly and contains valid values
 	// before proceeding with browser agent creation if it's a browser agent instance.
@@ -160,6 +165,25 @@ func (a *api) createLoadAgentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if listenAddr := ltConfig.WebhooksConfiguration.ReceiverListenAddress; listenAddr != "" && !isBAInstance {
+		var whMetrics *performance.WebhookMetrics
+		if a.metrics != nil {
+			whMetrics = a.metrics.WebhookMetrics()
+		}
+		receiver, err := loadtest.NewWebhookReceiver(listenAddr, whMetrics)
+		if err == nil {
+			err = lt.SetWebhookReceiver(receiver)
+		}
+		if err != nil {
+			writeAgentResponse(w, http.StatusBadRequest, &client.AgentResponse{
+				Id:      agentId,
+				Message: "load-test agent creation failed",
+				Error:   fmt.Sprintf("could not create webhook receiver: %s", err),
+			})
+			return
+		}
+	}
+
 	// Store the loadTest's LoadTester instance in the API's resource map using the agentId as the key.
 	// This allows the LoadTester to be retrieved later by other API endpoints of /loadtest
 	if ok := a.setResource(agentId, lt); !ok {
@@ -343,9 +367,9 @@ func (a *api) agentInjectActionHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func getServerVersion(serverURL string) (string, error) {
+func getServerVersion(httpClient *http.Client, serverURL string) (string, error) {
 	var version string
-	resp, err := http.Get(serverURL)
+	resp, err := httpClient.Get(serverURL)
 	if err != nil {
 		return version, fmt.Errorf("failed to get server version: %w", err)
 	}
@@ -372,26 +396,44 @@ func NewControllerWrapper(config *loadtest.Config, controllerConfig interface{},
 		maxHTTPconns = loadtest.MaxHTTPConns(config.UsersConfiguration.MaxActiveBrowserUsers)
 	}
 
-	// http.Transport to be shared amongst all clients.
-	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   1 * time.Second,
-			KeepAlive: 30 * time.Second,
-			DualStack: true,
-		}).DialContext,
-		MaxConnsPerHost:       maxHTTPconns,
-		MaxIdleConns:          maxHTTPconns,
-		MaxIdleConnsPerHost:   maxHTTPconns,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   1 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
+	connConfig := &config.ConnectionConfiguration
+	transportConfig := &connConfig.Transport
+	tlsConfig, err := transportConfig.TLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error reading TLS config: %w", err)
+	}
+
+	// http.Transport values to be shared amongst all clients, one for each
+	// source address, unless each user has its own pool of connections.
+	var sharedTransports map[string]*http.Transport
+	if transportConfig.ConnectionPool == loadtest.ConnectionPoolShared {
+		sharedTransports = make(map[string]*http.Transport)
+		for i := 0; i < max(len(connConfig.SourceAddresses), 1); i++ {
+			sourceAddr := connConfig.SourceAddress(i)
+			sharedTransports[sourceAddr] = transportConfig.NewTransport(tlsConfig, sourceAddr, maxHTTPconns)
+		}
+	}
+	getTransport := func(sourceAddr string) *http.Transport {
+		if transport, ok := sharedTransports[sourceAddr]; ok {
+			return transport
+		}
+		return transportConfig.NewTransport(tlsConfig, sourceAddr, 0)
+	}
+
+	// Setup of the requests made on behalf of the system admin.
+	adminSetup := userentity.Setup{
+		Transport:           getTransport(connConfig.SourceAddress(0)),
+		ClientTimeout:       transportConfig.RequestTimeout(),
+		TLSConfig:           tlsConfig,
+		SourceAddress:       connConfig.SourceAddress(0),
+		HonorRateLimits:     transportConfig.HonorRateLimits,
+		MaxRateLimitBackoff: transportConfig.MaxRateLimitBackoff(),
 	}
 
-	var err error
 	serverVersionStr := config.UserControllerConfiguration.ServerVersion
 	if serverVersionStr == "" {
-		serverVersionStr, err = getServerVersion(config.ConnectionConfiguration.ServerURL)
+		httpClient := &http.Client{Transport: adminSetup.Transport, Timeout: adminSetup.ClientTimeout}
+		serverVersionStr, err = getServerVersion(httpClient, config.ConnectionConfiguration.ServerURL)
 		if err != nil {
 			mlog.Error("Failed to get server version", mlog.Err(err))
 		}
@@ -410,60 +452,89 @@ func NewControllerWrapper(config *loadtest.Config, controllerConfig interface{},
 		return nil, fmt.Errorf("unable to parse server version %q: %w", serverVersionStr, err)
 	}
 
-	creds, err := getUserCredentials(config.UsersConfiguration.UsersFilePath, config)
+	credsProvider, err := loadtest.NewCredentialsProvider(&config.UsersConfiguration, namePrefix)
 	if err != nil {
-		return nil, fmt.Errorf("error getting user credentials from file: %w", err)
+		return nil, fmt.Errorf("error getting user credentials: %w", err)
 	}
 
+	storeLimits := config.UsersConfiguration.StoreLimits
+
 	modAdmins := 0
 	if config.UsersConfiguration.PercentOfUsersAreAdmin > 0 {
 		modAdmins = int(1 / config.UsersConfiguration.PercentOfUsersAreAdmin)
 	}
 
-	err = createCustomEmoji(config)
+	err = createCustomEmoji(adminSetup, config)
 	if err != nil {
 		return nil, fmt.Errorf("error creating custom emoji from config: %w", err)
 	}
 	mlog.Info("Custom emoji created")
 
+	// Browser agents run their own controller regardless of the type.
+	controllerType := string(config.UserControllerConfiguration.Type)
+	if !isBrowserAgentInstance && !control.IsControllerRegistered(controllerType) {
+		return nil, fmt.Errorf("%w: %q", control.ErrUnknownController, controllerType)
+	}
+
+	// Each population runs its own type of controller with its own config.
+	populations := config.UserControllerConfiguration.Populations
+	populationConfigs := make([]interface{}, len(populations))
+	for i, p := range populations {
+		populationConfigs[i], err = readPopulationConfig(p)
+		if err != nil {
+			return nil, fmt.Errorf("error reading config of population %q: %w", p.GetName(), err)
+		}
+	}
+
 	return func(id int, status chan<- control.UserStatus) (control.UserController, error) {
-		id += userOffset
+		ucType, ucConfig := controllerType, controllerConfig
+		if len(populations) > 0 {
+			// The population is picked before applying the offset, so that
+			// it matches the one the load-test accounts the user for.
+			p := config.UserControllerConfiguration.PickPopulation(id)
+			ucType, ucConfig = string(populations[p].Type), populationConfigs[p]
+		}
 
-		username := fmt.Sprintf("%s-%d", namePrefix, id)
-		email := fmt.Sprintf("%s-%d@example.com", namePrefix, id)
-		password := "testPass123$"
-		authenticationType := userentity.AuthenticationTypeMattermost
+		id += userOffset
 
+		// If UsersFilePath was set, and we haven't yet consumed all of the
+		// credentials provided there, the user gets the next available ones in
+		// that file. Otherwise they're generated.
+		creds, _ := credsProvider.Credentials(id)
 		if modAdmins > 0 && id%modAdmins == 0 {
-			username = ""
-			email = config.ConnectionConfiguration.AdminEmail
-			password = config.ConnectionConfiguration.AdminPassword
-		} else if len(creds) > 0 && id < len(creds) {
-			// If UsersFilePath was set, and we haven't yet consumed all of the credentials
-			// provided there, ovewrite this user's credentials with the next available
-			// user in that file
-			username = creds[id].username
-			email = creds[id].email
-			password = creds[id].password
-			authenticationType = creds[id].authService
+			creds = loadtest.Credentials{
+				Email:              config.ConnectionConfiguration.AdminEmail,
+				Password:           config.ConnectionConfiguration.AdminPassword,
+				AuthenticationType: userentity.AuthenticationTypeMattermost,
+			}
 		}
 
+		// Users are spread across the configured source addresses and
+		// targets, if any.
+		sourceAddr := connConfig.SourceAddress(id)
+		target := connConfig.Target(id)
+
 		ueConfig := userentity.Config{
-			ServerURL:          config.ConnectionConfiguration.ServerURL,
-			WebSocketURL:       config.ConnectionConfiguration.WebSocketURL,
-			AuthenticationType: authenticationType,
-			Username:           username,
-			Email:              email,
-			Password:           password,
+			ServerURL:          target.ServerURL,
+			WebSocketURL:       target.WebSocketURL,
+			AuthenticationType: creds.AuthenticationType,
+			Username:           creds.Username,
+			Email:              creds.Email,
+			Password:           creds.Password,
+			Token:              creds.Token,
 		}
 
 		store, err := memstore.New(&memstore.Config{
-			MaxStoredPosts:          250,
-			MaxStoredUsers:          500,
-			MaxStoredChannelMembers: 500,
-			MaxStoredStatuses:       500,
-			MaxStoredThreads:        250,
-			MaxStoredReactions:      10,
+			MaxStoredPosts:          storeLimits.MaxStoredPosts,
+			MaxStoredUsers:          storeLimits.MaxStoredUsers,
+			MaxStoredChannelMembers: storeLimits.MaxStoredChannelMembers,
+			MaxStoredStatuses:       storeLimits.MaxStoredStatuses,
+			MaxStoredThreads:        storeLimits.MaxStoredThreads,
+			MaxStoredReactions:      storeLimits.MaxStoredReactions,
+			MaxStoredChannels:       storeLimits.MaxStoredChannels,
+			MaxStoredDrafts:         storeLimits.MaxStoredDrafts,
+			MaxStoredBookmarks:      storeLimits.MaxStoredBookmarks,
+			MaxStoredScheduledPosts: storeLimits.MaxStoredScheduledPosts,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("error creating memory store: %w", err)
@@ -474,9 +545,13 @@ func NewControllerWrapper(config *loadtest.Config, controllerConfig interface{},
 		}
 
 		ueSetup := userentity.Setup{
-			Store:         store,
-			Transport:     transport,
-			ClientTimeout: 5 * time.Second,
+			Store:               store,
+			Transport:           getTransport(sourceAddr),
+			ClientTimeout:       transportConfig.RequestTimeout(),
+			TLSConfig:           tlsConfig,
+			SourceAddress:       sourceAddr,
+			HonorRateLimits:     transportConfig.HonorRateLimits,
+			MaxRateLimitBackoff: transportConfig.MaxRateLimitBackoff(),
 		}
 		if metrics != nil {
 			ueSetup.Metrics = metrics.UserEntityMetrics()
@@ -486,118 +561,71 @@ func NewControllerWrapper(config *loadtest.Config, controllerConfig interface{},
 		// We detect early on if the current instance is going to run browser agents
 		// this is because of design decision to run only browser agents in the instance
 		if isBrowserAgentInstance {
-			return browsercontroller.New(id, ue, config.ConnectionConfiguration.ServerURL, status)
+			return browsercontroller.New(id, ue, target.ServerURL, status)
 		}
 
-		switch config.UserControllerConfiguration.Type {
-		case loadtest.UserControllerSimple:
-			return simplecontroller.New(id, ue, controllerConfig.(*simplecontroller.Config), status)
-		case loadtest.UserControllerSimulative:
-			return simulcontroller.New(id, ue, controllerConfig.(*simulcontroller.Config), status)
-		case loadtest.UserControllerGenerative:
-			adminStore, err := memstore.New(nil)
-			if err != nil {
-				return nil, err
-			}
-			sysadmin := createSysAdmin(adminStore, config)
-			if err := sysadmin.Login(); err != nil {
-				return nil, err
-			}
-			return gencontroller.New(id, ue, sysadmin, controllerConfig.(*gencontroller.Config), status, config.UsersConfiguration.InitialActiveUsers)
-		case loadtest.UserControllerNoop:
-			return noopcontroller.New(id, ue, status)
-		case loadtest.UserControllerCluster:
-			// For cluster controller, we only use the sysadmin
-			// because we are just testing system console APIs.
-			ueConfig.Username = ""
-			ueConfig.Email = config.ConnectionConfiguration.AdminEmail
-			ueConfig.Password = config.ConnectionConfiguration.AdminPassword
-
-			admin := userentity.New(ueSetup, ueConfig)
-			return clustercontroller.New(id, admin, status)
-		default:
-			panic("controller type must be valid")
+		var webhookCallbackURL string
+		if config.WebhooksConfiguration.ReceiverListenAddress != "" {
+			webhookCallbackURL = config.WebhooksConfiguration.ReceiverURL
 		}
-	}, nil
-}
 
-type user struct {
-	email       string
-	username    string
-	password    string
-	authService string
-}
-
-func getUserCredentials(usersFilePath string, _ *loadtest.Config) ([]user, error) {
-	var users []user
-	if usersFilePath == "" {
-		return users, nil
-	}
-	f, err := os.Open(usersFilePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open %q: %w", usersFilePath, err)
-	}
-	defer f.Close()
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Text()
-		// Emails and passwords are separated by space.
-		split := strings.Split(line, " ")
-		if len(split) < 2 {
-			return nil, fmt.Errorf("user credential %q does not have space in between", line)
+		var actionMetrics *performance.ActionMetrics
+		if metrics != nil {
+			actionMetrics = metrics.ActionMetrics()
 		}
-		email := split[0]
-		password := split[1]
-		authService := userentity.AuthenticationTypeMattermost
-
-		// Check if the user has a custom authentication type. Custom authentication types are
-		// specified by prepending the email with the authentication type followed by a colon.
-		// Example: "openid:email1@xample.com"
-		if strings.Contains(email, ":") {
-			split := strings.Split(email, ":")
-			if len(split) != 2 {
-				return nil, fmt.Errorf("invalid custom authentication found in %q", email)
-			}
-			authService = split[0]
-			switch authService {
-			case userentity.AuthenticationTypeOpenID:
-			case userentity.AuthenticationTypeSAML:
-			case userentity.AuthenticationTypeMattermost:
-			default:
-				return nil, fmt.Errorf("invalid custom authentication type %q", authService)
-			}
 
-			email = strings.Replace(email, authService+":", "", 1)
-		}
+		return control.NewController(ucType, control.ControllerParams{
+			Id:     id,
+			User:   ue,
+			Status: status,
+			NewSysAdmin: func() (ltuser.User, error) {
+				adminStore, err := memstore.New(nil)
+				if err != nil {
+					return nil, fmt.Errorf("error creating memory store: %w", err)
+				}
+				if err := adminStore.SetServerVersion(serverVersion); err != nil {
+					return nil, fmt.Errorf("error setting server version: %w", err)
+				}
+				sysadminSetup := ueSetup
+				sysadminSetup.Store = adminStore
+				return createSysAdmin(sysadminSetup, config), nil
+			},
+			InitialActiveUsers: config.UsersConfiguration.InitialActiveUsers,
+			ActionMetrics:      actionMetrics,
+			WebhookCallbackURL: webhookCallbackURL,
+		}, ucConfig)
+	}, nil
+}
 
-		emailParts := strings.Split(email, "@")
-		if len(emailParts) != 2 {
-			return nil, fmt.Errorf("invalid email %q", email)
+// readPopulationConfig returns the config of the controller of the given
+// population, that is the default config of its type with the overrides of
+// the population applied.
+func readPopulationConfig(p loadtest.ControllerPopulation) (interface{}, error) {
+	var data []byte
+	if len(p.ControllerConfig) > 0 {
+		var err error
+		data, err = json.Marshal(p.ControllerConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode controller config: %w", err)
 		}
+	}
 
-		// Quick and dirty hack to extract username from email.
-		// This is not terribly important to be correct.
-		username := emailParts[0]
-		username = strings.Replace(username, "+", "-", -1)
-
-		users = append(users, user{
-			email:       email,
-			username:    username,
-			password:    password,
-			authService: authService,
-		})
+	ucConfig, err := control.DecodeControllerConfig(string(p.Type), data)
+	if err != nil {
+		return nil, err
+	}
+	if ucConfig == nil {
+		return nil, nil
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read from %s: %w", f.Name(), err)
+
+	if err := defaults.Validate(ucConfig); err != nil {
+		return nil, fmt.Errorf("could not validate controller config: %w", err)
 	}
 
-	return users, nil
+	return ucConfig, nil
 }
 
-func createSysAdmin(store *memstore.MemStore, config *loadtest.Config) *userentity.UserEntity {
-	adminUeSetup := userentity.Setup{
-		Store: store,
-	}
+func createSysAdmin(adminUeSetup userentity.Setup, config *loadtest.Config) *userentity.UserEntity {
 	adminUeConfig := userentity.Config{
 		ServerURL:    config.ConnectionConfiguration.ServerURL,
 		WebSocketURL: config.ConnectionConfiguration.WebSocketURL,
@@ -608,12 +636,13 @@ func createSysAdmin(store *memstore.MemStore, config *loadtest.Config) *userenti
 	return userentity.New(adminUeSetup, adminUeConfig)
 }
 
-func createCustomEmoji(config *loadtest.Config) error {
+func createCustomEmoji(adminSetup userentity.Setup, config *loadtest.Config) error {
 	adminStore, err := memstore.New(nil)
 	if err != nil {
 		return err
 	}
-	sysadmin := createSysAdmin(adminStore, config)
+	adminSetup.Store = adminStore
+	sysadmin := createSysAdmin(adminSetup, config)
 	if err := sysadmin.Login(); err != nil {
 		return fmt.Errorf("error login as sysadmin: %w", err)
 	}
@@ -9,7 +9,6 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"testing"
 
@@ -19,7 +18,6 @@ import (
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/control"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/simplecontroller"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/simulcontroller"
-	"github.com/mattermost/mattermost-load-test-ng/loadtest/user/userentity"
 	"github.com/mattermost/mattermost-load-test-ng/logger"
 
 	"github.com/gavv/httpexpect"
@@ -188,6 +186,16 @@ func TestAgentAPI(t *testing.T) {
 		e.POST(ltId + "/stop").Expect().Status(http.StatusOK)
 		e.DELETE(ltId).Expect().Status(http.StatusOK)
 	})
+
+	t.Run("start agent with an unknown controller type", func(t *testing.T) {
+		ltConfig.UserControllerConfiguration.Type = "unknown"
+		rd := requestData{
+			LoadTestConfig: ltConfig,
+		}
+		e.POST("/create").WithQuery("id", "lt0").WithJSON(rd).
+			Expect().Status(http.StatusBadRequest).
+			JSON().Object().ContainsKey("error")
+	})
 }
 
 func TestAgentAPIConcurrency(t *testing.T) {
@@ -245,75 +253,6 @@ func TestAgentAPIConcurrency(t *testing.T) {
 	wg.Wait()
 }
 
-func TestGetUserCredentials(t *testing.T) {
-	for _, tc := range []struct {
-		name          string
-		fileContents  []string
-		expectedCreds []user
-		expectErr     bool
-	}{
-		{
-			name: "get simple credentials",
-			expectedCreds: []user{{
-				email:       "email1@sample.mattermost.com",
-				password:    "password",
-				username:    "email1",
-				authService: userentity.AuthenticationTypeMattermost,
-			}, {
-				email:       "email2@sample.mattermost.com",
-				password:    "password",
-				username:    "email2",
-				authService: userentity.AuthenticationTypeMattermost,
-			}},
-			fileContents: []string{
-				"email1@sample.mattermost.com password",
-				"email2@sample.mattermost.com password",
-			},
-		}, {
-			name: "get credentials with custom auth provider",
-			expectedCreds: []user{{
-				email:       "email1@sample.mattermost.com",
-				password:    "password",
-				username:    "email1",
-				authService: userentity.AuthenticationTypeOpenID,
-			}},
-			fileContents: []string{
-				"openid:email1@sample.mattermost.com password",
-			},
-		}, {
-			name:      "incorrect auth provider",
-			expectErr: true,
-			fileContents: []string{
-				"incorrect:email@sample.mattermost.com password",
-			},
-		}, {
-			name:      "incorrect number of fields",
-			expectErr: true,
-			fileContents: []string{
-				"bogus",
-			},
-		},
-	} {
-		t.Run(tc.name, func(t *testing.T) {
-			tmpFile, err := os.CreateTemp("", "credentials")
-			require.NoError(t, err)
-			defer os.Remove(tmpFile.Name())
-
-			_, err = tmpFile.Write([]byte(strings.Join(tc.fileContents, "\n")))
-			require.NoError(t, err)
-
-			creds, err := getUserCredentials(tmpFile.Name(), nil)
-
-			if tc.expectErr {
-				require.Error(t, err)
-			} else {
-				require.NoError(t, err)
-				require.Equal(t, tc.expectedCreds, creds)
-			}
-		})
-	}
-}
-
 func TestIsBrowserAgentInstance(t *testing.T) {
 	t.Run("returns true when agent_type.txt contains browser_agent", func(t *testing.T) {
 		setupAgentType(t, deployment.AgentTypeBrowser)
@@ -451,3 +390,77 @@ func TestBrowserAgentConfigValidation(t *testing.T) {
 			JSON().Object().ContainsKey("error")
 	})
 }
+
+func TestReadPopulationConfig(t *testing.T) {
+	t.Run("applies overrides", func(t *testing.T) {
+		cfg, err := readPopulationConfig(loadtest.ControllerPopulation{
+			Type:       loadtest.UserControllerSimulative,
+			Percentage: 1,
+			ControllerConfig: map[string]any{
+				"AvgIdleTimeMs":  5000,
+				"PercentReplies": 0.5,
+			},
+		})
+		require.NoError(t, err)
+		simulConfig, ok := cfg.(*simulcontroller.Config)
+		require.True(t, ok)
+		require.Equal(t, 5000, simulConfig.AvgIdleTimeMs)
+		require.Equal(t, 0.5, simulConfig.PercentReplies)
+
+		defaultConfig, err := simulcontroller.ReadConfig("")
+		require.NoError(t, err)
+		require.Equal(t, defaultConfig.MinIdleTimeMs, simulConfig.MinIdleTimeMs)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := readPopulationConfig(loadtest.ControllerPopulation{
+			Type:             loadtest.UserControllerSimulative,
+			Percentage:       1,
+			ControllerConfig: map[string]any{"Unknown": true},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		_, err := readPopulationConfig(loadtest.ControllerPopulation{
+			Type:             loadtest.UserControllerSimulative,
+			Percentage:       1,
+			ControllerConfig: map[string]any{"PercentReplies": 2},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("no config", func(t *testing.T) {
+		cfg, err := readPopulationConfig(loadtest.ControllerPopulation{
+			Type:       loadtest.UserControllerNoop,
+			Percentage: 1,
+		})
+		require.NoError(t, err)
+		require.Nil(t, cfg)
+
+		_, err = readPopulationConfig(loadtest.ControllerPopulation{
+			Type:             loadtest.UserControllerNoop,
+			Percentage:       1,
+			ControllerConfig: map[string]any{"AvgIdleTimeMs": 5000},
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestBuiltinControllersRegistered(t *testing.T) {
+	for _, ucType := range []string{
+		string(loadtest.UserControllerSimple),
+		loadtest.UserControllerSimulative,
+		loadtest.UserControllerNoop,
+		loadtest.UserControllerGenerative,
+		loadtest.UserControllerCluster,
+	} {
+		require.True(t, control.IsControllerRegistered(ucType), ucType)
+	}
+
+	_, err := readPopulationConfig(loadtest.ControllerPopulation{
+		Type:       "unknown",
+		Percentage: 1,
+	})
+	require.ErrorIs(t, err, control.ErrUnknownController)
+}
@@ -12,8 +12,7 @@ import (
 	"strconv"
 
 	"github.com/mattermost/mattermost-load-test-ng/loadtest"
-	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/simplecontroller"
-	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/simulcontroller"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/control"
 )
 
 var (
@@ -106,39 +105,24 @@ func (a *Agent) Create(ltConfig *loadtest.Config, ucConfig interface{}) (loadtes
 		return status, errors.New("client: ltConfig should not be nil")
 	}
 
+	ucType := string(ltConfig.UserControllerConfiguration.Type)
+	if ucType == "" {
+		return status, errors.New("client: UserController type is not set")
+	}
+	if err := control.CheckControllerConfig(ucType, ucConfig); errors.Is(err, control.ErrNilControllerConfig) {
+		return status, errors.New("client: ucConfig should not be nil")
+	} else if errors.Is(err, control.ErrWrongControllerConfigType) {
+		return status, errors.New("client: ucConfig has the wrong type")
+	} else if err != nil {
+		return status, fmt.Errorf("client: %w", err)
+	}
+
 	data := struct {
-		LoadTestConfig         *loadtest.Config
-		SimpleControllerConfig *simplecontroller.Config `json:",omitempty"`
-		SimulControllerConfig  *simulcontroller.Config  `json:",omitempty"`
+		LoadTestConfig   *loadtest.Config
+		ControllerConfig interface{} `json:",omitempty"`
 	}{
-		LoadTestConfig: ltConfig,
-	}
-
-	switch ltConfig.UserControllerConfiguration.Type {
-	case loadtest.UserControllerSimple:
-		if ucConfig == nil {
-			return status, errors.New("client: ucConfig should not be nil")
-		}
-
-		var scc *simplecontroller.Config
-		scc, ok := ucConfig.(*simplecontroller.Config)
-		if !ok {
-			return status, errors.New("client: ucConfig has the wrong type")
-		}
-		data.SimpleControllerConfig = scc
-	case loadtest.UserControllerSimulative:
-		if ucConfig == nil {
-			return status, errors.New("client: ucConfig should not be nil")
-		}
-
-		scc, ok := ucConfig.(*simulcontroller.Config)
-		if !ok {
-			return status, errors.New("client: ucConfig has the wrong type")
-		}
-		data.SimulControllerConfig = scc
-	case loadtest.UserControllerNoop:
-	default:
-		return status, errors.New("client: UserController type is not set")
+		LoadTestConfig:   ltConfig,
+		ControllerConfig: ucConfig,
 	}
 
 	configData, err := json.Marshal(data)
@@ -10,9 +10,7 @@ import (
 	"github.com/mattermost/mattermost-load-test-ng/api"
 	"github.com/mattermost/mattermost-load-test-ng/defaults"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest"
-	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/gencontroller"
-	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/simplecontroller"
-	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/simulcontroller"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/control"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/store/memstore"
 	"github.com/mattermost/mattermost-load-test-ng/logger"
 
@@ -64,15 +62,7 @@ func RunLoadTestCmdF(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	var ucConfig interface{}
-	switch controllerType {
-	case loadtest.UserControllerSimple:
-		ucConfig, err = simplecontroller.ReadConfig(ucConfigPath)
-	case loadtest.UserControllerSimulative:
-		ucConfig, err = simulcontroller.ReadConfig(ucConfigPath)
-	case loadtest.UserControllerGenerative:
-		ucConfig, err = gencontroller.ReadConfig(ucConfigPath)
-	}
+	ucConfig, err := control.ReadControllerConfig(string(controllerType), ucConfigPath)
 	if err != nil {
 		return fmt.Errorf("failed to read controller configuration: %w", err)
 	}
@@ -117,6 +107,16 @@ func RunLoadTestCmdF(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("error while initializing loadtest: %w", err)
 	}
 
+	if listenAddr := config.WebhooksConfiguration.ReceiverListenAddress; listenAddr != "" {
+		receiver, err := loadtest.NewWebhookReceiver(listenAddr, nil)
+		if err != nil {
+			return fmt.Errorf("error while creating webhook receiver: %w", err)
+		}
+		if err := lt.SetWebhookReceiver(receiver); err != nil {
+			return fmt.Errorf("error while setting webhook receiver: %w", err)
+		}
+	}
+
 	if controllerType == loadtest.UserControllerGenerative {
 		return runGenLoadtest(lt, config.UsersConfiguration.InitialActiveUsers)
 	}
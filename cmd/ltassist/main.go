@@ -13,6 +13,7 @@ import (
 	"github.com/mattermost/mattermost-load-test-ng/defaults"
 	"github.com/mattermost/mattermost-load-test-ng/deployment"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/botcontroller"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/simplecontroller"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/simulcontroller"
 
@@ -21,6 +22,7 @@ import (
 
 var docs = map[string]string{
 	"agent":            "./docs/config/config.md",
+	"botcontroller":    "./docs/config/botcontroller.md",
 	"coordinator":      "./docs/config/coordinator.md",
 	"deployer":         "./docs/config/deployer.md",
 	"simplecontroller": "./docs/config/simplecontroller.md",
@@ -157,6 +159,8 @@ func getDefaultConfig(configType string) (interface{}, error) {
 		cfg = &coordinator.Config{}
 	case "deployer":
 		cfg = &deployment.Config{}
+	case "botcontroller":
+		cfg = &botcontroller.Config{}
 	case "simplecontroller":
 		cfg = &simplecontroller.Config{}
 	case "simulcontroller":
@@ -39,6 +39,9 @@ func createStruct(defaultValue interface{}, docPath string, dryRun bool) (reflec
 				return reflect.Zero(t), err
 			}
 			str.Field(i).Set(newSlice)
+		case reflect.Map:
+			// Maps are left to their default value.
+			str.Field(i).Set(defaultField)
 		case reflect.Bool, reflect.Int, reflect.Float64, reflect.String:
 			dvs := valueToString(defaultField)
 			newField, err := createField(t.Field(i), docPath, dvs, dryRun)
@@ -57,6 +60,10 @@ func createStruct(defaultValue interface{}, docPath string, dryRun bool) (reflec
 // does not support for some types (e.g. []int, []string etc.)
 func createSlice(defaultValue interface{}, docPath string, dryRun bool) (reflect.Value, error) {
 	t := reflect.ValueOf(defaultValue).Type().Elem()
+	// Slices of other types than structs are left to their default value.
+	if t.Kind() != reflect.Struct {
+		return reflect.ValueOf(defaultValue), nil
+	}
 	if dryRun {
 		_, err := createStruct(reflect.New(t).Interface(), docPath, dryRun)
 		return reflect.ValueOf(defaultValue), err
@@ -9,8 +9,9 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/mattermost/mattermost-load-test-ng/comparison"
@@ -67,7 +68,7 @@ func writeResults(results []comparison.Result, writer io.Writer) error {
 	var content string
 
 	for i, res := range results {
-		if len(res.LoadTests) < 2 || res.LoadTests[0].Failed || res.LoadTests[1].Failed {
+		if res.HasFailures() {
 			content += "==================================================\n"
 			content += fmt.Sprintf("Deployment %d: No results generated\n", i)
 			content += "==================================================\n\n"
@@ -77,11 +78,28 @@ func writeResults(results []comparison.Result, writer io.Writer) error {
 		content += "=================================================="
 		content += "Comparison result:"
 		content += fmt.Sprintf("Report: %s\n", getReportFilename(i, res))
-		content += fmt.Sprintf("Grafana Dashboard: %s\n", res.DashboardURL)
+		for j, url := range res.DashboardURLs {
+			label := ""
+			if len(res.DashboardURLs) > 1 && j+1 < len(res.LoadTests) {
+				label = fmt.Sprintf(" (%s vs %s)", res.LoadTests[0].Label, res.LoadTests[j+1].Label)
+			}
+			content += fmt.Sprintf("Grafana Dashboard%s: %s\n", label, url)
+		}
 		for _, ltRes := range res.LoadTests {
 			content += fmt.Sprintf("%s:\n", ltRes.Label)
 			content += fmt.Sprintf("  Type: %s\n", ltRes.Config.Type)
 			content += fmt.Sprintf("  DB Engine: %s\n", ltRes.Config.DBEngine)
+			if ltRes.Build.ConfigPatchFile != "" {
+				content += fmt.Sprintf("  Config Patch: %s\n", ltRes.Build.ConfigPatchFile)
+			}
+			if len(ltRes.Build.Plugins) > 0 {
+				ids := make([]string, 0, len(ltRes.Build.Plugins))
+				for id := range ltRes.Build.Plugins {
+					ids = append(ids, id)
+				}
+				sort.Strings(ids)
+				content += fmt.Sprintf("  Plugins: %s\n", strings.Join(ids, ", "))
+			}
 			if ltRes.Config.Type == comparison.LoadTestTypeBounded {
 				content += fmt.Sprintf("  Duration: %s\n", ltRes.Config.Duration)
 				content += fmt.Sprintf("  Users: %d\n", ltRes.Config.NumUsers)
@@ -89,6 +107,15 @@ func writeResults(results []comparison.Result, writer io.Writer) error {
 				content += fmt.Sprintf("  Supported Users: %d\n", ltRes.Status.SupportedUsers)
 			}
 			content += fmt.Sprintf("  Errors: %d\n", ltRes.Status.NumErrors)
+			if len(ltRes.Repetitions) > 1 {
+				content += fmt.Sprintf("  Repetitions: %d\n", len(ltRes.Repetitions))
+				if ltRes.Config.Type == comparison.LoadTestTypeUnbounded {
+					content += fmt.Sprintf("    Supported Users: %v (mean: %.1f, variance: %.1f)\n",
+						ltRes.SupportedUsers.Values, ltRes.SupportedUsers.Mean, ltRes.SupportedUsers.Variance)
+				}
+				content += fmt.Sprintf("    Errors: %v (mean: %.1f, variance: %.1f)\n",
+					ltRes.NumErrors.Values, ltRes.NumErrors.Mean, ltRes.NumErrors.Variance)
+			}
 		}
 		content += "==================================================\n\n"
 	}
@@ -98,18 +125,31 @@ func writeResults(results []comparison.Result, writer io.Writer) error {
 
 func writeReports(results []comparison.Result, outPath string) error {
 	for i, res := range results {
-		if res.Report == "" {
-			continue
+		if res.Report != "" {
+			filePath := filepath.Join(outPath, getReportFilename(i, res))
+			if err := os.WriteFile(filePath, []byte(res.Report), 0660); err != nil {
+				return err
+			}
 		}
-		filePath := filepath.Join(outPath, getReportFilename(i, res))
-		if err := os.WriteFile(filePath, []byte(res.Report), 0660); err != nil {
-			return err
+		if res.HTMLReport != "" {
+			filePath := filepath.Join(outPath, strings.TrimSuffix(getReportFilename(i, res), ".md")+".html")
+			if err := os.WriteFile(filePath, []byte(res.HTMLReport), 0660); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
 func RunComparisonCmdF(cmd *cobra.Command, args []string) error {
+	return runComparison(cmd, false)
+}
+
+func ResumeComparisonCmdF(cmd *cobra.Command, args []string) error {
+	return runComparison(cmd, true)
+}
+
+func runComparison(cmd *cobra.Command, resume bool) error {
 	deployerConfig, err := getConfig(cmd)
 	if err != nil {
 		return err
@@ -121,12 +161,6 @@ func RunComparisonCmdF(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to read comparison config: %w", err)
 	}
 
-	if cfg.Output.GenerateGraphs {
-		if _, err := exec.LookPath("gnuplot"); err != nil {
-			return fmt.Errorf("gnuplot is not installed. The comparison command with generate graph option requires it to be installed: %w", err)
-		}
-	}
-
 	outputPath, _ := cmd.Flags().GetString("output-dir")
 	if outputPath != "" {
 		cfg.Output.GraphsPath = outputPath
@@ -149,11 +183,20 @@ func RunComparisonCmdF(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to initialize comparison object: %w", err)
 	}
 
-	output, err := cmp.Run()
+	var output comparison.Output
+	if resume {
+		output, err = cmp.Resume()
+	} else {
+		output, err = cmp.Run()
+	}
 	if err != nil {
 		return fmt.Errorf("failed to run comparisons: %w", err)
 	}
 
+	if err := writeVerdict(output, outputPath); err != nil {
+		return fmt.Errorf("failed to write verdict: %w", err)
+	}
+
 	if format, _ := cmd.Flags().GetString("format"); format == "json" {
 		f, err := os.Create(filepath.Join(outputPath, "comparison.json"))
 		if err != nil {
@@ -191,8 +234,35 @@ func RunComparisonCmdF(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if !output.Verdict.Passed {
+		return fmt.Errorf("comparison failed: %d regression gate(s) violated", len(output.Verdict.Violations))
+	}
+
 	return nil
+}
+
+// writeVerdict writes the verdict of the comparison as JSON and JUnit XML
+// to the given directory.
+func writeVerdict(output comparison.Output, outPath string) error {
+	verdictFile, err := os.Create(filepath.Join(outPath, "verdict.json"))
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer verdictFile.Close()
+
+	enc := json.NewEncoder(verdictFile)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(output.Verdict); err != nil {
+		return fmt.Errorf("failed to encode verdict: %w", err)
+	}
+
+	junitFile, err := os.Create(filepath.Join(outPath, "junit.xml"))
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer junitFile.Close()
 
+	return output.WriteJUnit(junitFile)
 }
 
 func CollectComparisonCmdF(cmd *cobra.Command, args []string) error {
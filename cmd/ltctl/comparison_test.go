@@ -16,9 +16,9 @@ func TestPrintResults(t *testing.T) {
 	// Prepare test data
 	results := []comparison.Result{
 		{
-			Report:       "Sample Report",
-			DashboardURL: "http://example.com/dashboard",
-			LoadTests: [2]comparison.LoadTestResult{
+			Report:        "Sample Report",
+			DashboardURLs: []string{"http://example.com/dashboard"},
+			LoadTests: []comparison.LoadTestResult{
 				{
 					Label: "Test1",
 					Config: comparison.LoadTestConfig{
@@ -4,8 +4,11 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"os"
 	"time"
 
 	"github.com/mattermost/mattermost-load-test-ng/coordinator"
@@ -184,6 +187,14 @@ func printCoordinatorStatus(status coordinator.Status, errInfo map[string]int64,
 	}
 	if status.State == coordinator.Done {
 		fmt.Println("Supported users:", status.SupportedUsers)
+		if curve := status.CapacityCurve; curve != nil {
+			if ci := curve.SupportedUsers; ci != nil {
+				fmt.Printf("Supported users %.0f%% CI: %.1f - %.1f\n", ci.Level*100, ci.Lower, ci.Upper)
+			}
+			for _, k := range curve.Knees {
+				fmt.Printf("Knee point for %q: %d users (value: %.4f)\n", k.Query, k.ActiveUsers, k.Value)
+			}
+		}
 	}
 	fmt.Println("==================================================")
 }
@@ -227,6 +238,24 @@ func RunLoadTestStatusCmdF(cmd *cobra.Command, args []string) error {
 
 	printCoordinatorStatus(status, errInfo, usersCount)
 
+	curveFile, err := cmd.Flags().GetString("capacity-curve")
+	if err != nil {
+		return err
+	}
+	if curveFile != "" {
+		if status.CapacityCurve == nil {
+			return errors.New("the capacity curve is not available until the load-test is done")
+		}
+		data, err := json.MarshalIndent(status.CapacityCurve, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode capacity curve: %w", err)
+		}
+		if err := os.WriteFile(curveFile, data, 0644); err != nil {
+			return fmt.Errorf("failed to write capacity curve: %w", err)
+		}
+		fmt.Println("Capacity curve written to", curveFile)
+	}
+
 	return nil
 }
 
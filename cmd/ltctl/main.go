@@ -49,6 +49,32 @@ func RunCreateCmdF(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func RunPlanCmdF(cmd *cobra.Command, args []string) error {
+	config, err := getConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	dir, err := cmd.Flags().GetString("output-dir")
+	if err != nil {
+		return err
+	}
+
+	plan, err := terraform.NewPlan(config)
+	if err != nil {
+		return fmt.Errorf("failed to generate plan: %w", err)
+	}
+
+	if err := plan.Write(dir); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+
+	fmt.Print(plan.Summary())
+	fmt.Printf("Plan written to %s\n", dir)
+
+	return nil
+}
+
 func destroyAllButMetrics(config deployment.Config) error {
 	// Override all created resources so we destroy everything but the metrics
 	// instance.
@@ -336,6 +362,16 @@ func main() {
 		},
 	}
 
+	planCmd := &cobra.Command{
+		Use:     "plan",
+		Short:   "Render the artifacts of a load-test deployment without creating it",
+		Long:    "Validate the deployer config and render the artifacts generated when creating a deployment (Terraform variables, nginx config, Mattermost config, agent and coordinator configs) to a directory. No AWS credentials are needed and nothing is provisioned. Addresses of instances are replaced by placeholders.",
+		Example: "ltctl deployment plan --output-dir=plan",
+		RunE:    RunPlanCmdF,
+	}
+	planCmd.Flags().StringP("output-dir", "o", "plan", "Path to the directory to write the rendered artifacts to.")
+	deploymentCommands = append(deploymentCommands, planCmd)
+
 	destroyCmd := &cobra.Command{
 		Use:     "destroy",
 		Short:   "Destroy the current load-test deployment",
@@ -369,6 +405,13 @@ func main() {
 	}
 	ltStartCmd.Flags().Bool("sync", false, "Changes the command to not return until the test has finished, and then stops the DB after that")
 
+	ltStatusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Shows the status of the current load-test",
+		RunE:  RunLoadTestStatusCmdF,
+	}
+	ltStatusCmd.Flags().String("capacity-curve", "", "Path to the output file to write the capacity curve to, in JSON format. The curve is only available once the load-test is done.")
+
 	loadtestComands := []*cobra.Command{
 		ltStartCmd,
 		{
@@ -376,11 +419,7 @@ func main() {
 			Short: "Stop the coordinator in the current load-test deployment",
 			RunE:  RunLoadTestStopCmdF,
 		},
-		{
-			Use:   "status",
-			Short: "Shows the status of the current load-test",
-			RunE:  RunLoadTestStatusCmdF,
-		},
+		ltStatusCmd,
 		{
 			Use:   "inject actionId",
 			Short: "Injects the action into the current load-test",
@@ -504,19 +543,36 @@ func main() {
 	genReport.Flags().StringP("output", "o", "ltreport.out", "Path to the output file to write the report to.")
 	genReport.Flags().StringP("label", "l", "", "A friendly name for the report.")
 	genReport.Flags().StringP("prometheus-url", "p", "", "The URL of the Prometheus server. If this is not passed, the value is taken from terraform.tfstate.")
+	genReport.Flags().Bool("bundle", false, "If set to true, a bundle (a gzipped tarball) is written instead of a plain JSON report. Along with the report, it contains the raw metrics it was generated from, the load-test config files, information about the deployment and, if available, the status of the coordinator and the version of the server.")
+	genReport.Flags().String("metrics-file", "", "Path to a file holding recorded metrics, either a bundle or a file written by `ltctl report export`, to generate the report from instead of Prometheus. When a bundle is passed, the start and end times can be omitted to use those of the bundled report.")
+	genReport.Flags().String("capacity-curve", "", "Path to a capacity curve JSON file, as written by `ltctl loadtest status --capacity-curve`, to include in the report.")
+
+	exportReport := &cobra.Command{
+		Use:     "export",
+		Short:   "Export the metrics needed to generate a report from a start time to end time.",
+		Long:    "Export the metrics needed to generate a report from a start time to end time, so that the report can be generated offline later on through `ltctl report generate --metrics-file`.",
+		Example: "ltctl report export --output=metrics.json \"2020-06-17 04:37:05\" \"2020-06-17 04:42:00\"",
+		RunE:    RunExportReportCmdF,
+	}
+	exportReport.Flags().StringP("output", "o", "metrics.json", "Path to the output file to write the metrics to.")
+	exportReport.Flags().StringP("prometheus-url", "p", "", "The URL of the Prometheus server. If this is not passed, the value is taken from terraform.tfstate.")
 
 	compareReport := &cobra.Command{
 		Use:     "compare",
 		Short:   "Compare one or more reports",
 		Long:    "Compare one or more reports. The first report is considered to be the base",
-		Example: "ltctl report compare report1.out report2.out",
+		Example: "ltctl report compare report1.out report2.out\nltctl report compare --baseline base1.out --baseline base2.out new1.out new2.out",
 		RunE:    RunCompareReportCmdF,
 	}
 	compareReport.Flags().StringP("output", "o", "", "Path to the output file to write the comparison to. If this is not set, the report is displayed to stdout.")
-	compareReport.Flags().Bool("graph", false, "If set to true, it also generates graphs comparing different metrics from the load tests. This needs gnuplot to be present in the system.")
+	compareReport.Flags().Bool("graph", false, "If set to true, it also generates graphs comparing different metrics from the load tests, along with a single image combining all of them.")
+	compareReport.Flags().String("graph-format", "png", "The image format of the generated graphs [png, svg].")
 	compareReport.Flags().Bool("dashboard", false, "If set to true, it also generates a comparative Grafana dashboard between the load tests.")
+	compareReport.Flags().StringSlice("baseline", nil, "Path to a report of a base run. It can be repeated to compare multiple runs per side, in which case all the other reports are considered to be runs of the new load-test and only statistically significant differences are highlighted.")
+	compareReport.Flags().Float64("alpha", 0.05, "The significance level used when comparing multiple runs per side.")
+	compareReport.Flags().StringP("format", "f", "markdown", "The format of the comparison [markdown, html]. The html format generates a self-contained page with sortable tables and charts.")
 
-	reportCmds := []*cobra.Command{genReport, compareReport}
+	reportCmds := []*cobra.Command{genReport, exportReport, compareReport}
 	reportCmd.AddCommand(reportCmds...)
 	rootCmd.AddCommand(reportCmd)
 
@@ -536,6 +592,16 @@ func main() {
 	runComparisonCmd.Flags().StringP("output-dir", "d", "", "path to output directory")
 	runComparisonCmd.Flags().StringP("format", "f", "plain", "output format [plain, json]")
 
+	resumeComparisonCmd := &cobra.Command{
+		Use:   "resume",
+		Short: "Resume a partially completed load-test comparison",
+		Long:  "Resume a partially completed load-test comparison, reusing the existing deployments and skipping the load-tests that already completed.",
+		RunE:  ResumeComparisonCmdF,
+	}
+	resumeComparisonCmd.Flags().Bool("archive", false, "create zip archive")
+	resumeComparisonCmd.Flags().StringP("output-dir", "d", "", "path to output directory")
+	resumeComparisonCmd.Flags().StringP("format", "f", "plain", "output format [plain, json]")
+
 	collectComparisonCmd := &cobra.Command{
 		Use:   "collect",
 		Short: "Collect logs and configurations from all deployments",
@@ -550,7 +616,7 @@ func main() {
 	}
 	destroyComparisonCmd.Flags().Bool("do-not-destroy-metrics-instance", false, "Destroy everything but the metrics instance, so you can still analyze already stored data.")
 
-	comparisonCmd.AddCommand(runComparisonCmd, destroyComparisonCmd, collectComparisonCmd)
+	comparisonCmd.AddCommand(runComparisonCmd, resumeComparisonCmd, destroyComparisonCmd, collectComparisonCmd)
 	rootCmd.AddCommand(comparisonCmd)
 
 	if err := rootCmd.Execute(); err != nil {
@@ -4,111 +4,218 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"regexp"
 	"time"
 
+	"github.com/mattermost/mattermost-load-test-ng/comparison"
 	"github.com/mattermost/mattermost-load-test-ng/coordinator/performance/prometheus"
+	"github.com/mattermost/mattermost-load-test-ng/deployment"
 	"github.com/mattermost/mattermost-load-test-ng/deployment/terraform"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/report"
 
+	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/spf13/cobra"
 )
 
-func RunGenerateReportCmdF(cmd *cobra.Command, args []string) error {
-	err := cobra.MinimumNArgs(2)(cmd, args)
-	if err != nil {
-		return err
-	}
-
-	config, err := getConfig(cmd)
-	if err != nil {
-		return err
+// parseTimeRange parses the start and end times passed as the first two
+// arguments of a command.
+func parseTimeRange(cmd *cobra.Command, args []string) (time.Time, time.Time, error) {
+	if err := cobra.MinimumNArgs(2)(cmd, args); err != nil {
+		return time.Time{}, time.Time{}, err
 	}
 
 	const layout = "2006-01-02 15:04:05"
 	startTime, err := time.Parse(layout, args[0])
 	if err != nil {
-		return fmt.Errorf("start-time in incorrect format: %w", err)
+		return time.Time{}, time.Time{}, fmt.Errorf("start-time in incorrect format: %w", err)
 	}
 
 	endTime, err := time.Parse(layout, args[1])
 	if err != nil {
-		return fmt.Errorf("end-time in incorrect format: %w", err)
+		return time.Time{}, time.Time{}, fmt.Errorf("end-time in incorrect format: %w", err)
 	}
 
-	file, err := cmd.Flags().GetString("output")
+	if endTime.Before(startTime) {
+		return time.Time{}, time.Time{}, errors.New("end-time is before start-time")
+	}
+
+	return startTime, endTime, nil
+}
+
+// confirmOverwrite asks for confirmation if the given file already exists.
+func confirmOverwrite(file string) bool {
+	if _, err := os.Stat(file); err != nil {
+		return true
+	}
+	fmt.Printf("File %s exists. Overwrite ? (Y/n) ", file)
+	var confirm string
+	fmt.Scanln(&confirm)
+	return regexp.MustCompile(`(?i)^(y|yes)?$`).MatchString(confirm)
+}
+
+// getMetricsSource returns the metrics source configured for reports. If no
+// Prometheus URL is passed, the deployment's Prometheus server is used, in
+// which case the deployment is returned as well.
+func getMetricsSource(cmd *cobra.Command, config deployment.Config) (prometheus.MetricsSource, *terraform.Terraform, *terraform.Output, error) {
+	promURL, err := cmd.Flags().GetString("prometheus-url")
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
-	label, err := cmd.Flags().GetString("label")
+	var t *terraform.Terraform
+	var output *terraform.Output
+	if promURL == "" && config.Report.MetricsSource.Type != prometheus.SourceTypeFile {
+		fmt.Println("Flag --prometheus-url is not set. Defaulting to the deployment's Prometheus server...")
+		t, err = terraform.New("", config)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to create terraform engine: %w", err)
+		}
+		output, err = t.Output()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("could not parse output: %w", err)
+		}
+
+		if !output.HasMetrics() {
+			return nil, nil, nil, fmt.Errorf("no active deployment found, use the `--prometheus-url` flag if you have a local or manually deployed Prometheus server running")
+		}
+
+		promURL = "http://" + output.MetricsServer.GetConnectionIP() + ":9090"
+	}
+
+	source, err := prometheus.NewSource(config.Report.MetricsSource, promURL)
 	if err != nil {
-		return err
+		return nil, nil, nil, fmt.Errorf("failed to create metrics source: %w", err)
 	}
 
-	if endTime.Before(startTime) {
-		return errors.New("end-time is before start-time")
+	return source, t, output, nil
+}
+
+func RunGenerateReportCmdF(cmd *cobra.Command, args []string) error {
+	config, err := getConfig(cmd)
+	if err != nil {
+		return err
 	}
 
-	if _, err := os.Stat(file); err == nil {
-		fmt.Printf("File %s exists. Overwrite ? (Y/n) ", file)
-		var confirm string
-		fmt.Scanln(&confirm)
-		if !regexp.MustCompile(`(?i)^(y|yes)?$`).MatchString(confirm) {
-			return nil
-		}
+	file, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
 	}
 
-	f, err := os.Create(file)
+	label, err := cmd.Flags().GetString("label")
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	promURL, err := cmd.Flags().GetString("prometheus-url")
+	metricsFile, err := cmd.Flags().GetString("metrics-file")
 	if err != nil {
 		return err
 	}
 
-	if promURL == "" {
-		fmt.Println("Flag --prometheus-url is not set. Defaulting to the deployment's Prometheus server...")
-		config, err := getConfig(cmd)
+	// Metrics are read from the given file instead of the metrics source
+	// when generating reports offline.
+	var source prometheus.MetricsSource
+	var offlineBundle *report.Bundle
+	if metricsFile != "" {
+		source, offlineBundle, err = report.NewOfflineSource(metricsFile)
 		if err != nil {
 			return err
 		}
+	}
 
-		t, err := terraform.New("", config)
-		if err != nil {
-			return fmt.Errorf("failed to create terraform engine: %w", err)
+	var startTime, endTime time.Time
+	if offlineBundle != nil && len(args) == 0 {
+		// The report is generated again for the same time range.
+		startTime, endTime = offlineBundle.Report.StartTime, offlineBundle.Report.EndTime
+		if label == "" {
+			label = offlineBundle.Report.Label
 		}
-		output, err := t.Output()
+	} else {
+		startTime, endTime, err = parseTimeRange(cmd, args)
 		if err != nil {
-			return fmt.Errorf("could not parse output: %w", err)
+			return err
 		}
+	}
 
-		if !output.HasMetrics() {
-			return fmt.Errorf("no active deployment found, use the `--prometheus-url` flag if you have a local or manually deployed Prometheus server running")
-		}
+	if !confirmOverwrite(file) {
+		return nil
+	}
 
-		promURL = "http://" + output.MetricsServer.GetConnectionIP() + ":9090"
+	f, err := os.Create(file)
+	if err != nil {
+		return err
 	}
+	defer f.Close()
 
-	helper, err := prometheus.NewHelper(promURL)
+	var t *terraform.Terraform
+	var output *terraform.Output
+	if source == nil {
+		source, t, output, err = getMetricsSource(cmd, config)
+		if err != nil {
+			return err
+		}
+	}
+
+	genBundle, err := cmd.Flags().GetBool("bundle")
 	if err != nil {
-		return fmt.Errorf("failed to create prometheus.Helper: %w", err)
+		return err
+	}
+
+	// Queries are recorded so that the bundle can be used to generate the
+	// report again.
+	var rec *prometheus.RecordingSource
+	if genBundle {
+		rec = prometheus.NewRecordingSource(source)
+		source = rec
 	}
 
-	g := report.New(label, helper, config.Report)
+	g := report.New(label, source, config.Report)
 	data, err := g.Generate(startTime, endTime)
 	if err != nil {
 		return fmt.Errorf("error while generating report: %w", err)
 	}
 
+	curveFile, err := cmd.Flags().GetString("capacity-curve")
+	if err != nil {
+		return err
+	}
+	if curveFile != "" {
+		buf, err := os.ReadFile(curveFile)
+		if err != nil {
+			return fmt.Errorf("failed to read capacity curve: %w", err)
+		}
+		if err := json.Unmarshal(buf, &data.CapacityCurve); err != nil {
+			return fmt.Errorf("failed to parse capacity curve: %w", err)
+		}
+	} else if offlineBundle != nil {
+		data.CapacityCurve = offlineBundle.Report.CapacityCurve
+	}
+
+	if genBundle {
+		b := report.Bundle{
+			Report:  data,
+			Metrics: rec.Recording(),
+		}
+		// When generating a report again from a bundle, the information
+		// about the original run is kept.
+		if offlineBundle != nil {
+			b.Manifest.ServerVersion = offlineBundle.Manifest.ServerVersion
+			b.Configs = offlineBundle.Configs
+			b.DeploymentInfo = offlineBundle.DeploymentInfo
+			b.Status = offlineBundle.Status
+		} else if err := fillBundle(&b, config, t, output); err != nil {
+			return err
+		}
+		if err := report.WriteBundle(f, b); err != nil {
+			return fmt.Errorf("error while writing bundle: %w", err)
+		}
+		return nil
+	}
+
 	enc := json.NewEncoder(f)
 	enc.SetIndent("", "  ")
 	err = enc.Encode(data)
@@ -119,29 +226,148 @@ func RunGenerateReportCmdF(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func RunCompareReportCmdF(cmd *cobra.Command, args []string) error {
-	err := cobra.MinimumNArgs(2)(cmd, args)
+func RunExportReportCmdF(cmd *cobra.Command, args []string) error {
+	config, err := getConfig(cmd)
 	if err != nil {
 		return err
 	}
+
+	startTime, endTime, err := parseTimeRange(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	file, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+
+	if !confirmOverwrite(file) {
+		return nil
+	}
+
+	source, _, _, err := getMetricsSource(cmd, config)
+	if err != nil {
+		return err
+	}
+
+	// The series needed by a report are exactly those returned by the
+	// queries made while generating it, so we just record them.
+	rec := prometheus.NewRecordingSource(source)
+	if _, err := report.New("", rec, config.Report).Generate(startTime, endTime); err != nil {
+		return fmt.Errorf("error while querying metrics: %w", err)
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(rec.Recording()); err != nil {
+		return fmt.Errorf("error while encoding metrics to JSON: %w", err)
+	}
+
+	fmt.Println("Metrics written to", file)
+
+	return nil
+}
+
+// fillBundle adds the config files and the information about the deployment
+// to the given bundle. The status of the coordinator and the version of the
+// server are only added if the deployment is set.
+func fillBundle(b *report.Bundle, config deployment.Config, t *terraform.Terraform, output *terraform.Output) error {
+	var err error
+	b.Configs, err = report.ReadConfigFiles(report.DefaultConfigFiles)
+	if err != nil {
+		return err
+	}
+
+	b.DeploymentInfo, err = json.Marshal(comparison.NewDeploymentInfo(&config))
+	if err != nil {
+		return fmt.Errorf("failed to encode deployment info: %w", err)
+	}
+
+	if t == nil {
+		return nil
+	}
+
+	status, err := t.GetCoordinatorStatus()
+	if err != nil {
+		fmt.Printf("Unable to get the coordinator status, it won't be included in the bundle: %s\n", err)
+	} else if b.Status, err = json.MarshalIndent(status, "", "  "); err != nil {
+		return fmt.Errorf("failed to encode coordinator status: %w", err)
+	}
+
+	if len(output.Instances) > 0 {
+		client := model.NewAPIv4Client("http://" + output.Instances[0].GetConnectionIP() + ":8065")
+		_, resp, err := client.GetPing(context.Background())
+		if err != nil {
+			fmt.Printf("Unable to get the server version, it won't be included in the bundle: %s\n", err)
+		} else {
+			b.Manifest.ServerVersion = resp.ServerVersion
+		}
+	}
+
+	return nil
+}
+
+func loadReports(paths []string) ([]report.Report, error) {
 	var reports []report.Report
-	for _, arg := range args {
-		r, err := report.Load(arg)
+	for _, path := range paths {
+		r, err := report.Load(path)
 		if err != nil {
-			return fmt.Errorf("error loading report %s: %w", arg, err)
+			return nil, fmt.Errorf("error loading report %s: %w", path, err)
 		}
 		reports = append(reports, r)
 	}
+	return reports, nil
+}
+
+func RunCompareReportCmdF(cmd *cobra.Command, args []string) error {
+	baselines, err := cmd.Flags().GetStringSlice("baseline")
+	if err != nil {
+		return err
+	}
+
+	minArgs := 2
+	if len(baselines) > 0 {
+		minArgs = 1
+	}
+	err = cobra.MinimumNArgs(minArgs)(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	reports, err := loadReports(args)
+	if err != nil {
+		return err
+	}
+
+	baseRuns, err := loadReports(baselines)
+	if err != nil {
+		return err
+	}
+
+	alpha, err := cmd.Flags().GetFloat64("alpha")
+	if err != nil {
+		return err
+	}
+	if alpha <= 0 || alpha >= 1 {
+		return fmt.Errorf("alpha should be in the (0,1) range, got %v", alpha)
+	}
 
 	genGraph, err := cmd.Flags().GetBool("graph")
 	if err != nil {
 		return err
 	}
 
-	if genGraph {
-		if _, err := exec.LookPath("gnuplot"); err != nil {
-			return fmt.Errorf("gnuplot is not installed. The --graph option requires it to be installed: %w", err)
-		}
+	graphFormat, err := cmd.Flags().GetString("graph-format")
+	if err != nil {
+		return err
+	}
+	if graphFormat != string(report.GraphFormatPNG) && graphFormat != string(report.GraphFormatSVG) {
+		return fmt.Errorf("invalid graph format %q, it should be either %q or %q", graphFormat, report.GraphFormatPNG, report.GraphFormatSVG)
 	}
 
 	file, err := cmd.Flags().GetString("output")
@@ -163,7 +389,12 @@ func RunCompareReportCmdF(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	if genDashboard {
-		if len(reports) != 2 {
+		// When comparing multiple runs, the dashboard compares the first run of each side.
+		dashboardReports := reports
+		if len(baseRuns) > 0 {
+			dashboardReports = []report.Report{baseRuns[0], reports[0]}
+		}
+		if len(dashboardReports) != 2 {
 			return errors.New("cannot generate dashboard for more than 2 reports")
 		}
 		dashboardFile, err := os.Create("dashboard.json")
@@ -171,11 +402,29 @@ func RunCompareReportCmdF(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to create output file: %w", err)
 		}
 		defer dashboardFile.Close()
-		title := "Comparison - " + reports[1].Label
-		if err := report.GenerateDashboard(title, reports[0], reports[1], dashboardFile); err != nil {
+		title := "Comparison - " + dashboardReports[1].Label
+		if err := report.GenerateDashboard(title, dashboardReports[0], dashboardReports[1], dashboardFile); err != nil {
 			return err
 		}
 	}
 
-	return report.Compare(target, report.CompareOpts{GenGraph: genGraph}, reports...)
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+	if format != string(report.FormatMarkdown) && format != string(report.FormatHTML) {
+		return fmt.Errorf("invalid format %q, it should be either %q or %q", format, report.FormatMarkdown, report.FormatHTML)
+	}
+
+	opts := report.CompareOpts{
+		GenGraph:     genGraph,
+		GraphsFormat: report.GraphFormat(graphFormat),
+		Alpha:        alpha,
+		Format:       report.Format(format),
+	}
+	if len(baseRuns) > 0 {
+		return report.CompareRuns(target, opts, baseRuns, reports)
+	}
+
+	return report.Compare(target, opts, reports...)
 }
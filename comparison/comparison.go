@@ -37,7 +37,7 @@ func New(cfg *Config, deployerCfg *deployment.Config) (*Comparison, error) {
 
 	cmp := &Comparison{
 		config:         cfg,
-		deploymentInfo: getDeploymentInfo(deployerCfg),
+		deploymentInfo: NewDeploymentInfo(deployerCfg),
 		deployments:    map[string]*deploymentConfig{},
 	}
 
@@ -65,7 +65,19 @@ func New(cfg *Config, deployerCfg *deployment.Config) (*Comparison, error) {
 
 // Run performs fully automated load-test comparisons.
 // It returns a list of results or an error in case of failure.
+// The progress of every load-test is persisted as it completes so that a
+// failed comparison can be resumed.
 func (c *Comparison) Run() (Output, error) {
+	return c.run(false)
+}
+
+// Resume resumes a previously interrupted comparison, reusing the existing
+// deployments and skipping the load-tests that already completed.
+func (c *Comparison) Resume() (Output, error) {
+	return c.run(true)
+}
+
+func (c *Comparison) run(resume bool) (Output, error) {
 	var output Output
 
 	extAgent, err := ssh.NewAgent()
@@ -78,46 +90,97 @@ func (c *Comparison) Run() (Output, error) {
 
 	// Run tests concurrently
 	err = c.deploymentAction(func(t *terraform.Terraform, dpID string, dpConfig *deploymentConfig) error {
-		// Create deployments
-		if err := t.Create(extAgent, false); err != nil {
-			return err
+		state := &deploymentState{LoadTests: make(map[int]*loadTestState)}
+		if resume {
+			var err error
+			state, err = readState(dpID, dpConfig.config)
+			if err != nil {
+				return err
+			}
 		}
-		if err := provisionFiles(t, dpConfig, c.config.BaseBuild, c.config.NewBuild); err != nil {
+
+		// Create deployments, unless they already exist when resuming.
+		if !resume || !deploymentExists(t) {
+			if err := t.Create(extAgent, false); err != nil {
+				return err
+			}
+		}
+		if err := provisionFiles(t, dpConfig, c.config.Builds); err != nil {
 			return err
 		}
 
 		// Run tests for each deployment
 		for ltID, lt := range dpConfig.loadTests {
-			res := &Result{deploymentID: dpID}
+			ltState, err := state.loadTest(ltID, lt, c.config.Builds)
+			if err != nil {
+				return err
+			}
+
+			if ltState.Result != nil {
+				mlog.Info("skipping completed load-test", mlog.Int("id", ltID), mlog.String("deployment", dpID))
+				res := ltState.Result
+				res.deploymentID = dpID
+				for i := range res.LoadTests {
+					res.LoadTests[i].loadTestID = ltID
+				}
+				resultsCh <- res
+				continue
+			}
+
+			res := &Result{
+				LoadTests:    make([]LoadTestResult, len(c.config.Builds)),
+				deploymentID: dpID,
+			}
 			dumpFilename := lt.getDumpFilename(ltID)
 			s3BucketURI := lt.S3BucketDumpURI
-			for i, buildCfg := range []BuildConfig{c.config.BaseBuild, c.config.NewBuild} {
+			for step, i := range lt.schedule(len(c.config.Builds)) {
+				// Runs that completed before the comparison was interrupted are skipped.
+				if step < ltState.Completed {
+					continue
+				}
+
+				buildCfg := c.config.Builds[i]
 				mlog.Debug("initializing load-test")
 				// initialize instance state
 				if err := initLoadTest(t, buildCfg, dumpFilename, s3BucketURI); err != nil {
-					res.LoadTests[i] = LoadTestResult{Failed: true}
+					res.LoadTests[i].Failed = true
 					return err
 				}
 				mlog.Debug("load-test init done")
 
 				status, err := runLoadTest(t, lt)
 				if err != nil {
-					res.LoadTests[i] = LoadTestResult{Failed: true}
+					res.LoadTests[i].Failed = true
 					return err
 				}
-				res.LoadTests[i] = LoadTestResult{
-					loadTestID: ltID,
-					Label:      buildCfg.Label,
-					Config:     lt,
-					Status:     status,
+				ltState.Repetitions[i] = append(ltState.Repetitions[i], status)
+				ltState.ServerVersions[i] = getServerVersion(t)
+				ltState.Completed = step + 1
+				if err := state.persist(dpID, dpConfig.config); err != nil {
+					return err
 				}
 			}
 
-			// For each pair of base/new builds, compare the results and generate the report
-			res, err := c.getResults(t, dpConfig, res)
+			for i, buildCfg := range c.config.Builds {
+				res.LoadTests[i].loadTestID = ltID
+				res.LoadTests[i].Label = buildCfg.Label
+				res.LoadTests[i].Build = buildCfg
+				res.LoadTests[i].Config = lt
+				res.LoadTests[i].Repetitions = ltState.Repetitions[i]
+				res.LoadTests[i].ServerVersion = ltState.ServerVersions[i]
+				res.LoadTests[i].aggregate()
+			}
+
+			// Compare the results of each build against the base one and generate the report
+			res, err = c.getResults(t, dpConfig, res)
 			if err != nil {
 				return err
 			}
+
+			ltState.Result = res
+			if err := state.persist(dpID, dpConfig.config); err != nil {
+				return err
+			}
 			resultsCh <- res
 		}
 
@@ -136,10 +199,21 @@ func (c *Comparison) Run() (Output, error) {
 	for res := range resultsCh {
 		output.Results = append(output.Results, *res)
 	}
+	output.Verdict = newVerdict(output.Results)
 
 	return output, nil
 }
 
+// deploymentExists reports whether the given deployment has already been
+// created.
+func deploymentExists(t *terraform.Terraform) bool {
+	output, err := t.Output()
+	if err != nil {
+		return false
+	}
+	return output.HasAppServers()
+}
+
 // Destroy destroys all resources associated with the deployments for the
 // current automated load-test comparisons.
 func (c *Comparison) Destroy(maintainMetrics bool) error {
@@ -158,11 +232,14 @@ func (c *Comparison) Destroy(maintainMetrics bool) error {
 		})
 	}
 
-	return c.deploymentAction(func(t *terraform.Terraform, _ string, _ *deploymentConfig) error {
+	return c.deploymentAction(func(t *terraform.Terraform, dpID string, dpConfig *deploymentConfig) error {
 		if err := t.Sync(); err != nil {
 			return err
 		}
-		return t.Destroy()
+		if err := t.Destroy(); err != nil {
+			return err
+		}
+		return removeState(dpID, dpConfig.config)
 	})
 }
 
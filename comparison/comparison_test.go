@@ -1,9 +1,14 @@
 package comparison
 
 import (
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
+	"github.com/mattermost/mattermost-load-test-ng/coordinator"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/report"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -48,3 +53,28 @@ func TestGetDeploymentIds(t *testing.T) {
 		})
 	}
 }
+
+func TestResultHasFailures(t *testing.T) {
+	require.True(t, Result{}.HasFailures())
+	require.True(t, Result{LoadTests: []LoadTestResult{{}}}.HasFailures())
+	require.False(t, Result{LoadTests: []LoadTestResult{{}, {}, {}}}.HasFailures())
+	require.True(t, Result{LoadTests: []LoadTestResult{{}, {}, {Failed: true}}}.HasFailures())
+}
+
+func TestWriteBundle(t *testing.T) {
+	c := &Comparison{config: &Config{Output: OutputConfig{GraphsPath: t.TempDir()}}}
+	lt := LoadTestResult{
+		Label:         "base",
+		Config:        LoadTestConfig{Type: LoadTestTypeBounded, DBEngine: DBEnginePgSQL},
+		ServerVersion: "10.5.0",
+	}
+	require.NoError(t, c.writeBundle(lt, 0, report.Report{Label: "base"}, coordinator.Status{}, nil))
+
+	f, err := os.Open(filepath.Join(c.config.Output.GraphsPath, getBundleFilename(lt, 0)))
+	require.NoError(t, err)
+	defer f.Close()
+	b, err := report.ReadBundle(f)
+	require.NoError(t, err)
+	require.Equal(t, "10.5.0", b.Manifest.ServerVersion)
+	require.Contains(t, b.Configs, "comparison.json")
+}
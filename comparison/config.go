@@ -4,15 +4,22 @@
 package comparison
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/mattermost/mattermost-load-test-ng/defaults"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/report"
+
+	"github.com/mattermost/mattermost/server/public/model"
 )
 
 type LoadTestType string
 type DatabaseEngine string
+type InterleavingOrder string
 
 const (
 	DBEngineMySQL DatabaseEngine = "mysql"
@@ -24,6 +31,15 @@ const (
 	LoadTestTypeUnbounded LoadTestType = "unbounded"
 )
 
+const (
+	// InterleavingABAB runs all the builds in the same order in every
+	// repetition (e.g. ABAB).
+	InterleavingABAB InterleavingOrder = "ABAB"
+	// InterleavingABBA reverses the order of the builds in every other
+	// repetition (e.g. ABBA), so that each build runs first equally often.
+	InterleavingABBA InterleavingOrder = "ABBA"
+)
+
 // LoadTestConfig holds information about a load-test
 // to be automated.
 type LoadTestConfig struct {
@@ -47,6 +63,13 @@ type LoadTestConfig struct {
 	// The duration of the load-test.
 	// This is only considered if Type is "bounded"
 	Duration string
+
+	// The number of times the load-test is run for each build.
+	// Zero is treated as one.
+	Repetitions int `default:"1" validate:"range:[0,]"`
+	// The order in which builds are run across repetitions.
+	// Defaults to "ABAB" if empty.
+	Interleaving InterleavingOrder `default:"ABAB" validate:"oneof:{,ABAB,ABBA}"`
 }
 
 // IsValid reports whether a given LoadTestConfig is valid or not.
@@ -61,6 +84,23 @@ func (c *LoadTestConfig) IsValid() error {
 	return nil
 }
 
+// schedule returns the indexes of the builds in the order in which they
+// should be load-tested, taking into account repetitions and interleaving.
+func (c *LoadTestConfig) schedule(numBuilds int) []int {
+	reps := max(c.Repetitions, 1)
+	order := make([]int, 0, reps*numBuilds)
+	for rep := 0; rep < reps; rep++ {
+		for i := 0; i < numBuilds; i++ {
+			if c.Interleaving == InterleavingABBA && rep%2 == 1 {
+				order = append(order, numBuilds-1-i)
+				continue
+			}
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
 func (c *LoadTestConfig) getDumpFilename(ltID int) string {
 	var filename string
 	if c.DBDumpURL != "" {
@@ -69,15 +109,70 @@ func (c *LoadTestConfig) getDumpFilename(ltID int) string {
 	return filename
 }
 
-// BuildConfig holds information about a build.
+// BuildConfig holds information about a comparison arm: a build along with
+// an optional server config patch and set of plugins. Multiple arms can share
+// the same build to compare configuration changes.
 type BuildConfig struct {
-	// A label identifying the build.
+	// A label identifying the arm.
 	Label string `validate:"notempty"`
 
 	// URL from where to download a build release.
 	// This can also point to a local file if prefixed with "file://".
 	// In such case, the build file will be uploaded to the app servers.
 	URL string `validate:"url"`
+
+	// Optional path to a partial Mattermost config file to be applied as
+	// patch before running the load-test.
+	ConfigPatchFile string `default:"" validate:"empty|file"`
+
+	// Plugins maps each plugin ID to an URL (or local file if prefixed with
+	// "file://") of a plugin tarball to be installed and enabled before
+	// running the load-test.
+	Plugins map[string]string
+}
+
+// IsValid reports whether a given BuildConfig is valid or not.
+// Returns an error if the validation fails.
+func (c BuildConfig) IsValid() error {
+	if c.ConfigPatchFile != "" {
+		if _, err := c.readConfigPatch(); err != nil {
+			return err
+		}
+	}
+
+	for id, url := range c.Plugins {
+		if id == "" {
+			return fmt.Errorf("empty plugin ID for build %q", c.Label)
+		}
+		if url == "" {
+			return fmt.Errorf("empty URL for plugin %q in build %q", id, c.Label)
+		}
+	}
+
+	return nil
+}
+
+// readConfigPatch reads and parses the config patch file, if any.
+func (c BuildConfig) readConfigPatch() (*model.Config, error) {
+	if c.ConfigPatchFile == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(c.ConfigPatchFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ConfigPatchFile: %w", err)
+	}
+
+	var patch model.Config
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("error parsing ConfigPatchFile: %w", err)
+	}
+
+	return &patch, nil
+}
+
+func getPluginFilename(buildCfg BuildConfig, pluginID string) string {
+	return buildCfg.Label + "_" + pluginID + ".tar.gz"
 }
 
 // OutputConfig defines settings for the output of the comparison.
@@ -88,19 +183,56 @@ type OutputConfig struct {
 	// A boolean indicating whether to generate a markdown report
 	// at the end of the comparison.
 	GenerateReport bool `default:"true"`
-	// A boolean indicating whether to generate gnuplot graphs
+	// A boolean indicating whether to also generate a self-contained HTML
+	// report, with interactive tables and charts, at the end of the
+	// comparison.
+	GenerateHTMLReport bool `default:"false"`
+	// A boolean indicating whether to generate graphs
 	// at the end of the comparison.
 	GenerateGraphs bool `default:"false"`
+	// The image format of the generated graphs.
+	GraphsFormat report.GraphFormat `default:"png" validate:"oneof:{png,svg}"`
+	// A boolean indicating whether to write a report bundle, holding the
+	// raw metrics, config files and coordinator status, for every load-test
+	// run. Bundles are written to GraphsPath.
+	GenerateBundles bool `default:"false"`
 	// An optional path indicating where to write the graphs.
 	GraphsPath string
+	// Thresholds used to decide whether the comparison passed.
+	Gates GatesConfig
 }
 
 // Config holds information needed perform automated load-test comparisons.
 type Config struct {
-	BaseBuild BuildConfig
-	NewBuild  BuildConfig
+	// An ordered list of builds to compare. The first build is considered to
+	// be the base against which all the others are compared.
+	Builds    []BuildConfig
 	LoadTests []LoadTestConfig `validate:"notempty"`
 	Output    OutputConfig
+
+	// Deprecated: use Builds instead. When set, BaseBuild and NewBuild are
+	// converted into the first two Builds by ReadConfig.
+	BaseBuild *BuildConfig
+	NewBuild  *BuildConfig
+}
+
+// convertLegacyBuilds converts the deprecated BaseBuild and NewBuild fields
+// into Builds.
+func (c *Config) convertLegacyBuilds() error {
+	if c.BaseBuild == nil && c.NewBuild == nil {
+		return nil
+	}
+	if c.BaseBuild == nil || c.NewBuild == nil {
+		return errors.New("BaseBuild and NewBuild should be set together")
+	}
+	if len(c.Builds) > 0 {
+		return errors.New("BaseBuild and NewBuild cannot be set along with Builds")
+	}
+
+	c.Builds = []BuildConfig{*c.BaseBuild, *c.NewBuild}
+	c.BaseBuild = nil
+	c.NewBuild = nil
+	return nil
 }
 
 func (c *Config) IsValid() error {
@@ -110,8 +242,16 @@ func (c *Config) IsValid() error {
 		}
 	}
 
-	if c.BaseBuild.Label == c.NewBuild.Label {
-		return fmt.Errorf("the labels for the base and new build must be unique")
+	if len(c.Builds) < 2 {
+		return fmt.Errorf("at least two builds are needed to run a comparison, got %d", len(c.Builds))
+	}
+
+	labels := make(map[string]bool, len(c.Builds))
+	for _, build := range c.Builds {
+		if labels[build.Label] {
+			return fmt.Errorf("the labels for the builds must be unique: %q is duplicated", build.Label)
+		}
+		labels[build.Label] = true
 	}
 
 	return nil
@@ -126,5 +266,9 @@ func ReadConfig(configFilePath string) (*Config, error) {
 		return nil, err
 	}
 
+	if err := cfg.convertLegacyBuilds(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
@@ -1,6 +1,8 @@
 package comparison
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -9,17 +11,128 @@ import (
 func TestIsValid(t *testing.T) {
 	t.Run("default config with different label values is valid", func(t *testing.T) {
 		cfg := Config{}
-		cfg.BaseBuild.Label = "base"
-		cfg.NewBuild.Label = "new"
+		cfg.Builds = []BuildConfig{{Label: "base"}, {Label: "new"}}
 
 		require.NoError(t, cfg.IsValid())
 	})
 
 	t.Run("config with same labels for both build is not valid", func(t *testing.T) {
 		cfg := Config{}
-		cfg.BaseBuild.Label = "label"
-		cfg.NewBuild.Label = "label"
+		cfg.Builds = []BuildConfig{{Label: "label"}, {Label: "label"}}
 
 		require.Error(t, cfg.IsValid())
 	})
+
+	t.Run("config with more than two builds is valid", func(t *testing.T) {
+		cfg := Config{}
+		cfg.Builds = []BuildConfig{{Label: "release"}, {Label: "fix-a"}, {Label: "fix-b"}}
+
+		require.NoError(t, cfg.IsValid())
+	})
+
+	t.Run("config with a single build is not valid", func(t *testing.T) {
+		cfg := Config{}
+		cfg.Builds = []BuildConfig{{Label: "base"}}
+
+		require.Error(t, cfg.IsValid())
+	})
+
+	t.Run("config with a duplicated label among many builds is not valid", func(t *testing.T) {
+		cfg := Config{}
+		cfg.Builds = []BuildConfig{{Label: "release"}, {Label: "fix-a"}, {Label: "release"}}
+
+		require.Error(t, cfg.IsValid())
+	})
+}
+
+func TestSchedule(t *testing.T) {
+	t.Run("single run", func(t *testing.T) {
+		lt := LoadTestConfig{}
+		require.Equal(t, []int{0, 1}, lt.schedule(2))
+	})
+
+	t.Run("ABAB", func(t *testing.T) {
+		lt := LoadTestConfig{Repetitions: 2, Interleaving: InterleavingABAB}
+		require.Equal(t, []int{0, 1, 0, 1}, lt.schedule(2))
+	})
+
+	t.Run("ABBA", func(t *testing.T) {
+		lt := LoadTestConfig{Repetitions: 3, Interleaving: InterleavingABBA}
+		require.Equal(t, []int{0, 1, 1, 0, 0, 1}, lt.schedule(2))
+		require.Equal(t, []int{0, 1, 2, 2, 1, 0, 0, 1, 2}, lt.schedule(3))
+	})
+}
+
+func TestBuildConfigIsValid(t *testing.T) {
+	t.Run("no patch nor plugins", func(t *testing.T) {
+		require.NoError(t, BuildConfig{Label: "base"}.IsValid())
+	})
+
+	t.Run("valid patch", func(t *testing.T) {
+		patchFile := filepath.Join(t.TempDir(), "patch.json")
+		require.NoError(t, os.WriteFile(patchFile, []byte(`{"ServiceSettings": {"EnableLinkPreviews": false}}`), 0600))
+
+		cfg := BuildConfig{Label: "no-previews", ConfigPatchFile: patchFile}
+		require.NoError(t, cfg.IsValid())
+
+		patch, err := cfg.readConfigPatch()
+		require.NoError(t, err)
+		require.NotNil(t, patch.ServiceSettings.EnableLinkPreviews)
+		require.False(t, *patch.ServiceSettings.EnableLinkPreviews)
+	})
+
+	t.Run("invalid patch", func(t *testing.T) {
+		patchFile := filepath.Join(t.TempDir(), "patch.json")
+		require.NoError(t, os.WriteFile(patchFile, []byte(`{"ServiceSettings":`), 0600))
+		require.Error(t, BuildConfig{Label: "base", ConfigPatchFile: patchFile}.IsValid())
+	})
+
+	t.Run("plugin without URL", func(t *testing.T) {
+		cfg := BuildConfig{Label: "base", Plugins: map[string]string{"com.mattermost.calls": ""}}
+		require.Error(t, cfg.IsValid())
+	})
+}
+
+func TestReadConfigLegacyBuilds(t *testing.T) {
+	writeConfig := func(t *testing.T, data string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "comparison.json")
+		require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+		return path
+	}
+
+	t.Run("converted into builds", func(t *testing.T) {
+		path := writeConfig(t, `{
+			"BaseBuild": {"Label": "base", "URL": "https://example.com/base.tar.gz"},
+			"NewBuild": {"Label": "new", "URL": "https://example.com/new.tar.gz"}
+		}`)
+
+		cfg, err := ReadConfig(path)
+		require.NoError(t, err)
+		require.Nil(t, cfg.BaseBuild)
+		require.Nil(t, cfg.NewBuild)
+		require.Len(t, cfg.Builds, 2)
+		require.Equal(t, "base", cfg.Builds[0].Label)
+		require.Equal(t, "https://example.com/base.tar.gz", cfg.Builds[0].URL)
+		require.Equal(t, "new", cfg.Builds[1].Label)
+		require.Equal(t, "https://example.com/new.tar.gz", cfg.Builds[1].URL)
+	})
+
+	t.Run("only one legacy build", func(t *testing.T) {
+		path := writeConfig(t, `{"BaseBuild": {"Label": "base"}}`)
+
+		_, err := ReadConfig(path)
+		require.Error(t, err)
+	})
+
+	t.Run("legacy builds along with builds", func(t *testing.T) {
+		path := writeConfig(t, `{
+			"Builds": [{"Label": "release"}, {"Label": "fix"}],
+			"BaseBuild": {"Label": "base"},
+			"NewBuild": {"Label": "new"}
+		}`)
+
+		_, err := ReadConfig(path)
+		require.Error(t, err)
+	})
 }
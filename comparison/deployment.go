@@ -41,12 +41,12 @@ func (c *Comparison) deploymentAction(action func(t *terraform.Terraform, dpID s
 	return err
 }
 
-// provisionFiles loads the provided build and (optionally) db dump files
+// provisionFiles loads the provided build, plugin and (optionally) db dump files
 // into the app servers to be used later on during initialization.
 // If the URL is an HTTP URL then the file is directly downloaded into the
 // servers. If the URL is prefixed by `file://` then the file is uploaded
 // from the local filesystem.
-func provisionFiles(t *terraform.Terraform, dpConfig *deploymentConfig, baseBuildCfg, newBuildCfg BuildConfig) error {
+func provisionFiles(t *terraform.Terraform, dpConfig *deploymentConfig, builds []BuildConfig) error {
 	output, err := t.Output()
 	if err != nil {
 		return err
@@ -77,10 +77,15 @@ func provisionFiles(t *terraform.Terraform, dpConfig *deploymentConfig, baseBuil
 				}
 			}
 		}
-		for _, cfg := range []BuildConfig{baseBuildCfg, newBuildCfg} {
+		for _, cfg := range builds {
 			if _, err := t.ProvisionURL(client, cfg.URL, getBuildFilename(cfg)); err != nil {
 				return err
 			}
+			for id, url := range cfg.Plugins {
+				if _, err := t.ProvisionURL(client, url, getPluginFilename(cfg, id)); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
@@ -0,0 +1,258 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package comparison
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/report"
+
+	"github.com/prometheus/common/model"
+)
+
+// Names of the regression gates.
+const (
+	GateSupportedUsersDrop = "supported_users_drop"
+	GateP99APITime         = "p99_api_time"
+	GateP99StoreTime       = "p99_store_time"
+	GateErrorRate          = "error_rate"
+)
+
+// GatesConfig holds the thresholds used to decide whether the builds being
+// compared regressed against the base one. A zero value disables a gate.
+type GatesConfig struct {
+	// The maximum allowed drop, in percent, of the number of supported users.
+	// This is only considered for unbounded load-tests.
+	MaxSupportedUsersDropPercent float64 `default:"0" validate:"range:[0,100]"`
+	// The maximum allowed increase, in percent, of the p99 time of any API
+	// handler or store method.
+	MaxP99IncreasePercent float64 `default:"0" validate:"range:[0,]"`
+	// Increases of p99 times smaller than this value, in milliseconds, are
+	// ignored so that fast calls don't trip the gate because of noise.
+	MinP99IncreaseMs float64 `default:"2" validate:"range:[0,]"`
+	// The maximum allowed number of errors per second.
+	MaxErrorRate float64 `default:"0" validate:"range:[0,]"`
+}
+
+// GateViolation describes a regression gate that was not met.
+type GateViolation struct {
+	Gate      string  // The name of the gate.
+	Label     string  // The label of the build that violated the gate.
+	Metric    string  // The API handler or store method, if any.
+	Base      float64 // The value for the base build, if any.
+	Actual    float64
+	Threshold float64
+	Message   string
+}
+
+// Verdict holds the outcome of the regression gates.
+type Verdict struct {
+	Passed bool
+	// The names of the gates that were checked.
+	Checked    []string
+	Violations []GateViolation
+}
+
+// evaluateGates checks the enabled gates for every build of the given result
+// against the base one.
+func evaluateGates(cfg GatesConfig, res *Result, reports []report.Report) Verdict {
+	v := Verdict{Passed: true}
+	if cfg.MaxSupportedUsersDropPercent > 0 && res.LoadTests[0].Config.Type == LoadTestTypeUnbounded {
+		v.Checked = append(v.Checked, GateSupportedUsersDrop)
+	}
+	if cfg.MaxP99IncreasePercent > 0 {
+		v.Checked = append(v.Checked, GateP99APITime, GateP99StoreTime)
+	}
+	if cfg.MaxErrorRate > 0 {
+		v.Checked = append(v.Checked, GateErrorRate)
+	}
+
+	base := res.LoadTests[0]
+	for i, lt := range res.LoadTests[1:] {
+		if cfg.MaxSupportedUsersDropPercent > 0 && lt.Config.Type == LoadTestTypeUnbounded && base.Status.SupportedUsers > 0 {
+			drop := float64(base.Status.SupportedUsers-lt.Status.SupportedUsers) / float64(base.Status.SupportedUsers) * 100
+			if drop > cfg.MaxSupportedUsersDropPercent {
+				v.Violations = append(v.Violations, GateViolation{
+					Gate:      GateSupportedUsersDrop,
+					Label:     lt.Label,
+					Base:      float64(base.Status.SupportedUsers),
+					Actual:    float64(lt.Status.SupportedUsers),
+					Threshold: cfg.MaxSupportedUsersDropPercent,
+					Message:   fmt.Sprintf("supported users dropped by %.2f%% (max %.2f%%)", drop, cfg.MaxSupportedUsersDropPercent),
+				})
+			}
+		}
+
+		if cfg.MaxP99IncreasePercent > 0 {
+			v.Violations = append(v.Violations, checkP99(cfg, GateP99APITime, lt.Label, reports[0].P99APITimes, reports[i+1].P99APITimes)...)
+			v.Violations = append(v.Violations, checkP99(cfg, GateP99StoreTime, lt.Label, reports[0].P99StoreTimes, reports[i+1].P99StoreTimes)...)
+		}
+
+		if cfg.MaxErrorRate > 0 {
+			// The aggregated status of repeated load-tests also spans the
+			// time between repetitions, so the mean of their own rates is
+			// used instead.
+			rate := errorRate(lt.Status)
+			if len(lt.Repetitions) > 0 {
+				rate = lt.ErrorRate.Mean
+			}
+			if rate > cfg.MaxErrorRate {
+				v.Violations = append(v.Violations, GateViolation{
+					Gate:      GateErrorRate,
+					Label:     lt.Label,
+					Actual:    rate,
+					Threshold: cfg.MaxErrorRate,
+					Message:   fmt.Sprintf("error rate of %.4f/s (max %.4f/s)", rate, cfg.MaxErrorRate),
+				})
+			}
+		}
+	}
+
+	v.Passed = len(v.Violations) == 0
+	return v
+}
+
+func checkP99(cfg GatesConfig, gate, label string, base, actual map[model.LabelValue]model.SampleValue) []GateViolation {
+	metrics := make([]string, 0, len(base))
+	for metric := range base {
+		metrics = append(metrics, string(metric))
+	}
+	sort.Strings(metrics)
+
+	var violations []GateViolation
+	for _, metric := range metrics {
+		baseValue := float64(base[model.LabelValue(metric)])
+		actualValue, ok := actual[model.LabelValue(metric)]
+		if !ok || baseValue <= 0 {
+			continue
+		}
+		deltaMs := (float64(actualValue) - baseValue) * 1000
+		increase := deltaMs / (baseValue * 1000) * 100
+		if deltaMs < cfg.MinP99IncreaseMs || increase <= cfg.MaxP99IncreasePercent {
+			continue
+		}
+		violations = append(violations, GateViolation{
+			Gate:      gate,
+			Label:     label,
+			Metric:    metric,
+			Base:      baseValue,
+			Actual:    float64(actualValue),
+			Threshold: cfg.MaxP99IncreasePercent,
+			Message:   fmt.Sprintf("p99 of %s increased by %.2f%% (max %.2f%%)", metric, increase, cfg.MaxP99IncreasePercent),
+		})
+	}
+	return violations
+}
+
+// markdown returns a summary of the verdict in markdown.
+func (v Verdict) markdown() string {
+	if len(v.Checked) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	if v.Passed {
+		sb.WriteString("### Regression gates: passed\n")
+		return sb.String()
+	}
+	sb.WriteString("### Regression gates: failed\n")
+	sb.WriteString("| Gate | Build | Violation |\n")
+	sb.WriteString("| --- | --- | --- |\n")
+	for _, violation := range v.Violations {
+		fmt.Fprintf(&sb, "| %s | %s | %s |\n", violation.Gate, violation.Label, violation.Message)
+	}
+	return sb.String()
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+// WriteJUnit writes the verdict of the comparison as JUnit XML to the given
+// writer. Each load-test is a test suite, with a test case for every
+// checked gate and build.
+func (o Output) WriteJUnit(w io.Writer) error {
+	suites := junitTestSuites{Name: "comparison"}
+	for i, res := range o.Results {
+		suite := junitTestSuite{Name: fmt.Sprintf("%d", i)}
+		if len(res.LoadTests) > 0 {
+			cfg := res.LoadTests[0].Config
+			suite.Name = fmt.Sprintf("%d_%s_%s", i, cfg.DBEngine, cfg.Type)
+		}
+
+		if res.HasFailures() {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      "load-test",
+				ClassName: suite.Name,
+				Failure:   &junitFailure{Message: "load-test failed, no results generated"},
+			})
+		} else {
+			for _, lt := range res.LoadTests[1:] {
+				for _, gate := range res.Verdict.Checked {
+					tc := junitTestCase{Name: gate, ClassName: suite.Name + "." + lt.Label}
+					var messages []string
+					for _, violation := range res.Verdict.Violations {
+						if violation.Gate == gate && violation.Label == lt.Label {
+							messages = append(messages, violation.Message)
+						}
+					}
+					if len(messages) > 0 {
+						tc.Failure = &junitFailure{
+							Message: fmt.Sprintf("%d violation(s) of %s", len(messages), gate),
+							Text:    strings.Join(messages, "\n"),
+						}
+					}
+					suite.TestCases = append(suite.TestCases, tc)
+				}
+			}
+		}
+
+		suite.Tests = len(suite.TestCases)
+		for _, tc := range suite.TestCases {
+			if tc.Failure != nil {
+				suite.Failures++
+			}
+		}
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.TestSuites = append(suites.TestSuites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return fmt.Errorf("failed to encode JUnit XML: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package comparison
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-load-test-ng/coordinator"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/report"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateGates(t *testing.T) {
+	now := time.Now()
+	ltConfig := LoadTestConfig{Type: LoadTestTypeUnbounded, DBEngine: DBEnginePgSQL}
+	res := &Result{
+		LoadTests: []LoadTestResult{
+			{
+				Label:  "base",
+				Config: ltConfig,
+				Status: coordinator.Status{StartTime: now, StopTime: now.Add(100 * time.Second), SupportedUsers: 1000},
+			},
+			{
+				Label:  "new",
+				Config: ltConfig,
+				Status: coordinator.Status{StartTime: now, StopTime: now.Add(100 * time.Second), SupportedUsers: 900, NumErrors: 50},
+			},
+		},
+	}
+	reports := []report.Report{
+		{
+			P99APITimes:   map[model.LabelValue]model.SampleValue{"getPosts": 0.1, "login": 0.001},
+			P99StoreTimes: map[model.LabelValue]model.SampleValue{"PostStore.Get": 0.05},
+		},
+		{
+			P99APITimes:   map[model.LabelValue]model.SampleValue{"getPosts": 0.2, "login": 0.002},
+			P99StoreTimes: map[model.LabelValue]model.SampleValue{"PostStore.Get": 0.051},
+		},
+	}
+
+	t.Run("no gates", func(t *testing.T) {
+		v := evaluateGates(GatesConfig{}, res, reports)
+		require.True(t, v.Passed)
+		require.Empty(t, v.Checked)
+		require.Empty(t, v.markdown())
+	})
+
+	t.Run("within thresholds", func(t *testing.T) {
+		v := evaluateGates(GatesConfig{
+			MaxSupportedUsersDropPercent: 15,
+			MaxP99IncreasePercent:        200,
+			MinP99IncreaseMs:             2,
+			MaxErrorRate:                 1,
+		}, res, reports)
+		require.True(t, v.Passed)
+		require.Equal(t, []string{GateSupportedUsersDrop, GateP99APITime, GateP99StoreTime, GateErrorRate}, v.Checked)
+		require.Contains(t, v.markdown(), "passed")
+	})
+
+	t.Run("violations", func(t *testing.T) {
+		v := evaluateGates(GatesConfig{
+			MaxSupportedUsersDropPercent: 5,
+			MaxP99IncreasePercent:        10,
+			MinP99IncreaseMs:             2,
+			MaxErrorRate:                 0.1,
+		}, res, reports)
+		require.False(t, v.Passed)
+		require.Len(t, v.Violations, 3)
+		require.Equal(t, GateSupportedUsersDrop, v.Violations[0].Gate)
+		// The login handler increased by 100% but only by 1ms, which is ignored.
+		require.Equal(t, GateP99APITime, v.Violations[1].Gate)
+		require.Equal(t, "getPosts", v.Violations[1].Metric)
+		require.Equal(t, GateErrorRate, v.Violations[2].Gate)
+		require.InDelta(t, 0.5, v.Violations[2].Actual, 1e-9)
+		require.Contains(t, v.markdown(), "| p99_api_time | new | p99 of getPosts increased by 100.00% (max 10.00%) |")
+	})
+}
+
+func TestEvaluateGatesErrorRateRepetitions(t *testing.T) {
+	now := time.Now()
+	ltConfig := LoadTestConfig{Type: LoadTestTypeBounded, DBEngine: DBEnginePgSQL, Repetitions: 2}
+	newResult := func(label string, numErrors int64) LoadTestResult {
+		// The repetitions run 100s each, an hour apart.
+		lt := LoadTestResult{
+			Label:  label,
+			Config: ltConfig,
+			Repetitions: []coordinator.Status{
+				{StartTime: now, StopTime: now.Add(100 * time.Second), NumErrors: numErrors},
+				{StartTime: now.Add(time.Hour), StopTime: now.Add(time.Hour + 100*time.Second), NumErrors: numErrors},
+			},
+		}
+		lt.aggregate()
+		return lt
+	}
+	res := &Result{
+		LoadTests: []LoadTestResult{newResult("base", 0), newResult("new", 50)},
+	}
+	reports := []report.Report{{}, {}}
+
+	// Over the span of both repetitions the rate would be below 0.1/s.
+	v := evaluateGates(GatesConfig{MaxErrorRate: 0.1}, res, reports)
+	require.False(t, v.Passed)
+	require.Len(t, v.Violations, 1)
+	require.Equal(t, GateErrorRate, v.Violations[0].Gate)
+	require.InDelta(t, 0.5, v.Violations[0].Actual, 1e-9)
+
+	v = evaluateGates(GatesConfig{MaxErrorRate: 1}, res, reports)
+	require.True(t, v.Passed)
+}
+
+func TestWriteJUnit(t *testing.T) {
+	ltConfig := LoadTestConfig{Type: LoadTestTypeBounded, DBEngine: DBEnginePgSQL}
+	output := Output{
+		Results: []Result{
+			{
+				LoadTests: []LoadTestResult{{Label: "base", Config: ltConfig}, {Label: "new", Config: ltConfig}},
+				Verdict: Verdict{
+					Checked: []string{GateP99APITime, GateErrorRate},
+					Violations: []GateViolation{
+						{Gate: GateP99APITime, Label: "new", Message: "p99 of getPosts increased"},
+					},
+				},
+			},
+			{},
+		},
+	}
+	output.Verdict = newVerdict(output.Results)
+	require.False(t, output.Verdict.Passed)
+
+	var buf bytes.Buffer
+	require.NoError(t, output.WriteJUnit(&buf))
+	out := buf.String()
+	require.Contains(t, out, `<testsuites name="comparison" tests="3" failures="2">`)
+	require.Contains(t, out, `<testsuite name="0_postgresql_bounded" tests="2" failures="1">`)
+	require.Contains(t, out, `<testcase name="p99_api_time" classname="0_postgresql_bounded.new">`)
+	require.Contains(t, out, `<failure message="1 violation(s) of p99_api_time">p99 of getPosts increased</failure>`)
+	require.Contains(t, out, `<testcase name="error_rate" classname="0_postgresql_bounded.new"></testcase>`)
+	require.Contains(t, out, `<failure message="load-test failed, no results generated"></failure>`)
+}
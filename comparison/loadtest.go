@@ -4,7 +4,9 @@
 package comparison
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -18,7 +20,14 @@ import (
 	"github.com/mattermost/mattermost-load-test-ng/deployment/terraform"
 	"github.com/mattermost/mattermost-load-test-ng/deployment/terraform/ssh"
 
+	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/shared/mlog"
+	"github.com/mattermost/mattermost/server/v8/config"
+)
+
+const (
+	mmConfigPath = "/opt/mattermost/config/config.json"
+	mmPluginsDir = "/opt/mattermost/plugins"
 )
 
 func (c *Comparison) getLoadTestsCount() int {
@@ -199,10 +208,22 @@ func initLoadTest(t *terraform.Terraform, buildCfg BuildConfig, dumpFilename str
 		Clients: appClients,
 	}
 
+	// The config originally deployed is kept aside so that every build starts
+	// from it, regardless of the patches applied by previous ones.
 	buildFileName := getBuildFilename(buildCfg)
 	installCmd := deployment.Cmd{
 		Msg:     "Installing app",
-		Value:   fmt.Sprintf("cd ~ && tar xzf %s && cp /opt/mattermost/config/config.json . && sudo rm -rf /opt/mattermost && sudo mv mattermost /opt/ && mv config.json /opt/mattermost/config/", buildFileName),
+		Value:   fmt.Sprintf("cd ~ && tar xzf %s && (test -f config.base.json || cp /opt/mattermost/config/config.json config.base.json) && cp config.base.json config.json && sudo rm -rf /opt/mattermost && sudo mv mattermost /opt/ && mv config.json /opt/mattermost/config/", buildFileName),
+		Clients: appClients,
+	}
+
+	var installPluginsCmds []string
+	for id := range buildCfg.Plugins {
+		installPluginsCmds = append(installPluginsCmds, fmt.Sprintf("tar xzf ~/%s --directory=%s", getPluginFilename(buildCfg, id), mmPluginsDir))
+	}
+	installPluginsCmd := deployment.Cmd{
+		Msg:     "Installing plugins",
+		Value:   "mkdir -p " + mmPluginsDir + " && " + strings.Join(installPluginsCmds, " && "),
 		Clients: appClients,
 	}
 
@@ -248,7 +269,11 @@ func initLoadTest(t *terraform.Terraform, buildCfg BuildConfig, dumpFilename str
 		Clients: []*ssh.Client{agentClient},
 	}
 
-	cmds := []deployment.Cmd{stopCmd, installCmd, resetCmd}
+	installCmds := []deployment.Cmd{stopCmd, installCmd}
+	if len(buildCfg.Plugins) > 0 {
+		installCmds = append(installCmds, installPluginsCmd)
+	}
+	cmds := []deployment.Cmd{resetCmd}
 
 	loadDBDumpCmd := deployment.Cmd{
 		Msg:     "Loading DB dump",
@@ -308,6 +333,30 @@ func initLoadTest(t *terraform.Terraform, buildCfg BuildConfig, dumpFilename str
 		resetBucketErrCh <- nil
 	}()
 
+	if err := runCmds(installCmds); err != nil {
+		return err
+	}
+
+	mlog.Info("Applying build config")
+	for _, client := range appClients {
+		if err := applyBuildConfig(client, buildCfg); err != nil {
+			return fmt.Errorf("failed to apply config for build %q: %w", buildCfg.Label, err)
+		}
+	}
+
+	if err := runCmds(cmds); err != nil {
+		return err
+	}
+
+	if err := t.PostProcessDatabase(extAgent); err != nil {
+		return fmt.Errorf("failed to post-process database: %w", err)
+	}
+
+	// Make sure that the S3 bucket reset routine is finished and return its error, if any
+	return <-resetBucketErrCh
+}
+
+func runCmds(cmds []deployment.Cmd) error {
 	for _, c := range cmds {
 		mlog.Info(c.Msg)
 		for _, client := range c.Clients {
@@ -316,13 +365,75 @@ func initLoadTest(t *terraform.Terraform, buildCfg BuildConfig, dumpFilename str
 			}
 		}
 	}
+	return nil
+}
 
-	if err := t.PostProcessDatabase(extAgent); err != nil {
-		return fmt.Errorf("failed to post-process database: %w", err)
+// applyBuildConfig patches the Mattermost config of the app server with the
+// config patch of the given build and enables its plugins.
+func applyBuildConfig(client *ssh.Client, buildCfg BuildConfig) error {
+	patch, err := buildCfg.readConfigPatch()
+	if err != nil {
+		return err
+	}
+	if patch == nil && len(buildCfg.Plugins) == 0 {
+		return nil
 	}
 
-	// Make sure that the S3 bucket reset routine is finished and return its error, if any
-	return <-resetBucketErrCh
+	var buf bytes.Buffer
+	if err := client.Download(mmConfigPath, &buf, false); err != nil {
+		return fmt.Errorf("failed to download config: %w", err)
+	}
+
+	var cfg *model.Config
+	if err := json.Unmarshal(buf.Bytes(), &cfg); err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if patch != nil {
+		cfg, err = config.Merge(cfg, patch, nil)
+		if err != nil {
+			return fmt.Errorf("error patching config: %w", err)
+		}
+	}
+
+	if len(buildCfg.Plugins) > 0 && cfg.PluginSettings.PluginStates == nil {
+		cfg.PluginSettings.PluginStates = make(map[string]*model.PluginState)
+	}
+	for id := range buildCfg.Plugins {
+		mlog.Info("Enabling plugin", mlog.String("plugin ID", id))
+		cfg.PluginSettings.PluginStates[id] = &model.PluginState{Enable: true}
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if out, err := client.Upload(bytes.NewReader(data), mmConfigPath, false); err != nil {
+		return fmt.Errorf("failed to upload config: %w %s", err, out)
+	}
+
+	return nil
+}
+
+// getServerVersion returns the version of the server of the given deployment,
+// or an empty string if it can't be fetched.
+func getServerVersion(t *terraform.Terraform) string {
+	output, err := t.Output()
+	if err != nil {
+		mlog.Warn("unable to get terraform output, the server version won't be included in the bundle", mlog.Err(err))
+		return ""
+	}
+	if len(output.Instances) == 0 {
+		return ""
+	}
+
+	client := model.NewAPIv4Client("http://" + output.Instances[0].GetConnectionIP() + ":8065")
+	_, resp, err := client.GetPing(context.Background())
+	if err != nil {
+		mlog.Warn("unable to get the server version, it won't be included in the bundle", mlog.Err(err))
+		return ""
+	}
+	return resp.ServerVersion
 }
 
 func runLoadTest(t *terraform.Terraform, lt LoadTestConfig) (coordinator.Status, error) {
@@ -5,7 +5,9 @@ package comparison
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/mattermost/mattermost-load-test-ng/coordinator"
@@ -41,35 +43,102 @@ type DeploymentInfo struct {
 // performed during a comparison.
 type LoadTestResult struct {
 	Failed bool               // A flag indicating whether the load-test failed
-	Label  string             // A label for the load-test.
+	Label  string             // The label of the arm the load-test ran.
+	Build  BuildConfig        // The arm the load-test ran.
 	Config LoadTestConfig     // The config object associated with the load-test.
 	Status coordinator.Status // The final status of the load-test.
+	// The version of the server the load-test ran against, if known.
+	ServerVersion string `json:",omitempty"`
+
+	// The final status of each repetition, in the order they ran. When the
+	// load-test is repeated, Status holds the aggregate of all of them.
+	Repetitions []coordinator.Status `json:",omitempty"`
+	// The supported users, errors and errors per second of each repetition
+	// along with their mean and variance.
+	SupportedUsers RepetitionStats
+	NumErrors      RepetitionStats
+	ErrorRate      RepetitionStats
 
 	loadTestID int
 }
 
+// RepetitionStats holds the values of a metric across the repetitions of a
+// load-test.
+type RepetitionStats struct {
+	Values   []float64 // The value of each repetition, in the order they ran.
+	Mean     float64
+	Variance float64 // The sample variance, zero for a single repetition.
+}
+
 // Results holds information regarding the results of an
 // automated load-test comparison.
 type Result struct {
-	// An array of load-test results where the first element is the base run
-	// and the second element is the new run.
-	LoadTests [2]LoadTestResult
+	// A list of load-test results, one per build, in the same order as the
+	// builds in the config. The first element is the base run.
+	LoadTests []LoadTestResult
 	// The Markdown report for the comparison.
 	Report string
-	// The URL to a comparative Grafana dashboard.
-	DashboardURL string
+	// The HTML report for the comparison, if enabled.
+	HTMLReport string `json:",omitempty"`
+	// The URLs to the comparative Grafana dashboards, one per build compared
+	// against the base one.
+	DashboardURLs []string
+	// The outcome of the regression gates.
+	Verdict Verdict
 
 	deploymentID string
 }
 
+// HasFailures reports whether the result is incomplete, meaning that less
+// than two load-tests ran or any of them failed.
+func (r Result) HasFailures() bool {
+	if len(r.LoadTests) < 2 {
+		return true
+	}
+	for _, lt := range r.LoadTests {
+		if lt.Failed {
+			return true
+		}
+	}
+	return false
+}
+
 type Output struct {
 	// Information about the deployment in which the comparison ran.
 	DeploymentInfo DeploymentInfo
 	// A list of results.
 	Results []Result
+	// The overall outcome of the regression gates. It passes only if all the
+	// results passed.
+	Verdict Verdict
 }
 
-func getDeploymentInfo(config *deployment.Config) DeploymentInfo {
+// newVerdict aggregates the verdicts of the given results.
+func newVerdict(results []Result) Verdict {
+	v := Verdict{Passed: true}
+	checked := make(map[string]bool)
+	for _, res := range results {
+		if res.HasFailures() {
+			v.Passed = false
+			continue
+		}
+		for _, gate := range res.Verdict.Checked {
+			if !checked[gate] {
+				checked[gate] = true
+				v.Checked = append(v.Checked, gate)
+			}
+		}
+		v.Violations = append(v.Violations, res.Verdict.Violations...)
+	}
+	if len(v.Violations) > 0 {
+		v.Passed = false
+	}
+	return v
+}
+
+// NewDeploymentInfo returns the information regarding the deployment
+// described by the given config.
+func NewDeploymentInfo(config *deployment.Config) DeploymentInfo {
 	return DeploymentInfo{
 		AppInstanceCount:          config.AppInstanceCount,
 		AppInstanceType:           config.AppInstanceType,
@@ -84,7 +153,7 @@ func getDeploymentInfo(config *deployment.Config) DeploymentInfo {
 }
 
 func (c *Comparison) getResults(t *terraform.Terraform, dpConfig *deploymentConfig, res *Result) (*Result, error) {
-	if len(res.LoadTests) < 2 || res.LoadTests[0].Failed || res.LoadTests[1].Failed {
+	if res.HasFailures() {
 		return res, fmt.Errorf("unable to generate results; deployment ID: %q", res.deploymentID)
 	}
 
@@ -94,23 +163,46 @@ func (c *Comparison) getResults(t *terraform.Terraform, dpConfig *deploymentConf
 	}
 
 	promURL := "http://" + output.MetricsServer.GetConnectionIP() + ":9090"
-	helper, err := prometheus.NewHelper(promURL)
+	source, err := prometheus.NewSource(dpConfig.config.Report.MetricsSource, promURL)
 	if err != nil {
-		return res, fmt.Errorf("failed to create prometheus.Helper: %w", err)
+		return res, fmt.Errorf("failed to create metrics source: %w", err)
 	}
 
-	g := report.New(res.LoadTests[0].Label, helper, dpConfig.config.Report)
-	baseReport, err := g.Generate(res.LoadTests[0].Status.StartTime, res.LoadTests[0].Status.StopTime)
-	if err != nil {
-		return res, fmt.Errorf("error while generating report: %w", err)
-	}
+	// Reports for repeated load-tests are generated for each repetition and
+	// merged into a single one per build.
+	runs := make([][]report.Report, 0, len(res.LoadTests))
+	reports := make([]report.Report, 0, len(res.LoadTests))
+	for _, lt := range res.LoadTests {
+		var buildRuns []report.Report
+		for i, status := range lt.Repetitions {
+			// Queries are recorded to be included in the bundle.
+			runSource := source
+			var rec *prometheus.RecordingSource
+			if c.config.Output.GenerateBundles {
+				rec = prometheus.NewRecordingSource(source)
+				runSource = rec
+			}
 
-	g = report.New(res.LoadTests[1].Label, helper, dpConfig.config.Report)
-	newReport, err := g.Generate(res.LoadTests[1].Status.StartTime, res.LoadTests[1].Status.StopTime)
-	if err != nil {
-		return res, fmt.Errorf("error while generating report: %w", err)
+			g := report.New(lt.Label, runSource, dpConfig.config.Report)
+			r, err := g.Generate(status.StartTime, status.StopTime)
+			if err != nil {
+				return res, fmt.Errorf("error while generating report for %s: %w", lt.Label, err)
+			}
+			r.CapacityCurve = status.CapacityCurve
+			buildRuns = append(buildRuns, r)
+
+			if rec != nil {
+				if err := c.writeBundle(lt, i, r, status, rec.Recording()); err != nil {
+					return res, fmt.Errorf("failed to write bundle for %s: %w", lt.Label, err)
+				}
+			}
+		}
+		runs = append(runs, buildRuns)
+		reports = append(reports, report.Merge(buildRuns))
 	}
 
+	res.Verdict = evaluateGates(c.config.Output.Gates, res, reports)
+
 	if c.config.Output.GenerateReport {
 		var buf bytes.Buffer
 		graphsPrefix := fmt.Sprintf("%s_%s_%d_", res.LoadTests[0].Config.DBEngine,
@@ -118,31 +210,117 @@ func (c *Comparison) getResults(t *terraform.Terraform, dpConfig *deploymentConf
 		opts := report.CompareOpts{
 			GenGraph:     c.config.Output.GenerateGraphs,
 			GraphsPrefix: graphsPrefix,
+			GraphsFormat: c.config.Output.GraphsFormat,
 		}
 		opts.GraphsPrefix = filepath.Join(c.config.Output.GraphsPath, opts.GraphsPrefix)
 
-		if err := report.Compare(&buf, opts, baseReport, newReport); err != nil {
+		// Repeated runs of two builds are tested for statistical significance.
+		if len(runs) == 2 && len(runs[0]) > 1 && len(runs[1]) > 1 {
+			if err := report.CompareRuns(&buf, opts, runs[0], runs[1]); err != nil {
+				return res, fmt.Errorf("failed to compare reports: %w", err)
+			}
+		} else if err := report.Compare(&buf, opts, reports...); err != nil {
 			return res, fmt.Errorf("failed to compare reports: %w", err)
 		}
 
+		buf.WriteString(res.Verdict.markdown())
 		res.Report = buf.String()
 	}
 
+	if c.config.Output.GenerateHTMLReport {
+		var buf bytes.Buffer
+		opts := report.CompareOpts{Format: report.FormatHTML}
+		if len(runs) == 2 && len(runs[0]) > 1 && len(runs[1]) > 1 {
+			if err := report.CompareRuns(&buf, opts, runs[0], runs[1]); err != nil {
+				return res, fmt.Errorf("failed to compare reports: %w", err)
+			}
+		} else if err := report.Compare(&buf, opts, reports...); err != nil {
+			return res, fmt.Errorf("failed to compare reports: %w", err)
+		}
+		res.HTMLReport = buf.String()
+	}
+
 	if c.config.Output.UploadDashboard {
-		var dashboardData bytes.Buffer
-		title := fmt.Sprintf("Comparison - %d - %s - %s",
-			res.LoadTests[0].loadTestID, res.LoadTests[0].Config.DBEngine, res.LoadTests[0].Config.Type)
-		if err := report.GenerateDashboard(title, baseReport, newReport, &dashboardData); err != nil {
+		for _, newReport := range reports[1:] {
+			var dashboardData bytes.Buffer
+			title := fmt.Sprintf("Comparison - %d - %s - %s",
+				res.LoadTests[0].loadTestID, res.LoadTests[0].Config.DBEngine, res.LoadTests[0].Config.Type)
+			// Dashboard titles must be unique, so we also include the label
+			// when comparing more than two builds.
+			if len(reports) > 2 {
+				title += " - " + newReport.Label
+			}
+			if err := report.GenerateDashboard(title, reports[0], newReport, &dashboardData); err != nil {
+				return res, fmt.Errorf("failed to generate dashboard: %w", err)
+			}
 
-			return res, fmt.Errorf("failed to generate dashboard: %w", err)
+			url, err := t.UploadDashboard(dashboardData.String())
+			if err != nil {
+				return res, fmt.Errorf("failed to upload dashboard: %w", err)
+			}
+			res.DashboardURLs = append(res.DashboardURLs, fmt.Sprintf("http://%s:3000%s", output.MetricsServer.GetConnectionIP(), url))
 		}
+	}
+
+	return res, nil
+}
+
+// getBundleFilename returns the name of the bundle of the given run of a
+// load-test. The index of the run is only included if it was repeated.
+func getBundleFilename(lt LoadTestResult, run int) string {
+	name := fmt.Sprintf("bundle_%s_%s_%d_%s", lt.Config.DBEngine, lt.Config.Type, lt.loadTestID, lt.Label)
+	if len(lt.Repetitions) > 1 {
+		name += fmt.Sprintf("_%d", run)
+	}
+	return name + ".tar.gz"
+}
+
+// writeBundle writes a report bundle for the given run of a load-test to
+// the graphs path.
+func (c *Comparison) writeBundle(lt LoadTestResult, run int, r report.Report, status coordinator.Status, metrics []prometheus.RecordedQuery) error {
+	b := report.Bundle{
+		Report:  r,
+		Metrics: metrics,
+	}
+	b.Manifest.ServerVersion = lt.ServerVersion
 
-		url, err := t.UploadDashboard(dashboardData.String())
+	var err error
+	b.Status, err = json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode coordinator status: %w", err)
+	}
+	b.Configs, err = report.ReadConfigFiles(report.DefaultConfigFiles)
+	if err != nil {
+		return err
+	}
+	if lt.Build.ConfigPatchFile != "" {
+		b.Configs["mattermost.patch.json"], err = os.ReadFile(lt.Build.ConfigPatchFile)
 		if err != nil {
-			return res, fmt.Errorf("failed to upload dashboard: %w", err)
+			return fmt.Errorf("failed to read config patch: %w", err)
 		}
-		res.DashboardURL = fmt.Sprintf("http://%s:3000%s", output.MetricsServer.GetConnectionIP(), url)
+	}
+	b.Configs["comparison.json"], err = json.MarshalIndent(struct {
+		Build    BuildConfig
+		LoadTest LoadTestConfig
+	}{lt.Build, lt.Config}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode comparison config: %w", err)
 	}
 
-	return res, nil
+	b.DeploymentInfo, err = json.Marshal(c.deploymentInfo)
+	if err != nil {
+		return fmt.Errorf("failed to encode deployment info: %w", err)
+	}
+
+	f, err := os.Create(filepath.Join(c.config.Output.GraphsPath, getBundleFilename(lt, run)))
+	if err != nil {
+		return fmt.Errorf("failed to create bundle file: %w", err)
+	}
+	defer f.Close()
+
+	if err := report.WriteBundle(f, b); err != nil {
+		return err
+	}
+
+	return f.Close()
 }
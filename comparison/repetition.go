@@ -0,0 +1,79 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package comparison
+
+import (
+	"math"
+
+	"github.com/mattermost/mattermost-load-test-ng/coordinator"
+)
+
+func newRepetitionStats(values []float64) RepetitionStats {
+	stats := RepetitionStats{
+		Values: values,
+	}
+	if len(values) == 0 {
+		return stats
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	stats.Mean = sum / float64(len(values))
+
+	if len(values) > 1 {
+		var sq float64
+		for _, v := range values {
+			sq += (v - stats.Mean) * (v - stats.Mean)
+		}
+		stats.Variance = sq / float64(len(values)-1)
+	}
+
+	return stats
+}
+
+// errorRate returns the number of errors per second of the given status, or
+// zero if it didn't run for any time.
+func errorRate(st coordinator.Status) float64 {
+	elapsed := st.StopTime.Sub(st.StartTime).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(st.NumErrors) / elapsed
+}
+
+// aggregate sets the status and statistics of the result from the status of
+// its repetitions. The aggregated status spans from the start of the first
+// repetition to the end of the last one, and holds the mean number of
+// supported users and errors. Since the repetitions don't run back to back,
+// the error rate is computed for each of them on its own.
+func (r *LoadTestResult) aggregate() {
+	if len(r.Repetitions) == 0 {
+		return
+	}
+
+	supported := make([]float64, len(r.Repetitions))
+	numErrors := make([]float64, len(r.Repetitions))
+	errorRates := make([]float64, len(r.Repetitions))
+	for i, st := range r.Repetitions {
+		supported[i] = float64(st.SupportedUsers)
+		numErrors[i] = float64(st.NumErrors)
+		errorRates[i] = errorRate(st)
+	}
+	r.SupportedUsers = newRepetitionStats(supported)
+	r.NumErrors = newRepetitionStats(numErrors)
+	r.ErrorRate = newRepetitionStats(errorRates)
+
+	first := r.Repetitions[0]
+	last := r.Repetitions[len(r.Repetitions)-1]
+	r.Status = last
+	r.Status.StartTime = first.StartTime
+	r.Status.SupportedUsers = int(math.Round(r.SupportedUsers.Mean))
+	r.Status.NumErrors = int64(math.Round(r.NumErrors.Mean))
+	if len(r.Repetitions) > 1 {
+		// A single capacity curve cannot represent multiple repetitions.
+		r.Status.CapacityCurve = nil
+	}
+}
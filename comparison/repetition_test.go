@@ -0,0 +1,46 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package comparison
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-load-test-ng/coordinator"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregate(t *testing.T) {
+	now := time.Now()
+
+	t.Run("single repetition", func(t *testing.T) {
+		st := coordinator.Status{StartTime: now, StopTime: now.Add(time.Hour), SupportedUsers: 1000, NumErrors: 5}
+		res := LoadTestResult{Repetitions: []coordinator.Status{st}}
+		res.aggregate()
+		require.Equal(t, st, res.Status)
+		require.Equal(t, []float64{1000}, res.SupportedUsers.Values)
+		require.Equal(t, float64(1000), res.SupportedUsers.Mean)
+		require.Zero(t, res.SupportedUsers.Variance)
+	})
+
+	t.Run("multiple repetitions", func(t *testing.T) {
+		res := LoadTestResult{
+			Repetitions: []coordinator.Status{
+				{StartTime: now, StopTime: now.Add(time.Hour), SupportedUsers: 1000, NumErrors: 2},
+				{StartTime: now.Add(2 * time.Hour), StopTime: now.Add(3 * time.Hour), SupportedUsers: 1100, NumErrors: 4},
+				{StartTime: now.Add(4 * time.Hour), StopTime: now.Add(5 * time.Hour), SupportedUsers: 1200, NumErrors: 6},
+			},
+		}
+		res.aggregate()
+		require.Equal(t, now, res.Status.StartTime)
+		require.Equal(t, now.Add(5*time.Hour), res.Status.StopTime)
+		require.Equal(t, 1100, res.Status.SupportedUsers)
+		require.Equal(t, int64(4), res.Status.NumErrors)
+		require.Equal(t, []float64{1000, 1100, 1200}, res.SupportedUsers.Values)
+		require.InDelta(t, 10000, res.SupportedUsers.Variance, 1e-9)
+		require.InDelta(t, 4, res.NumErrors.Variance, 1e-9)
+		require.InDelta(t, 4.0/3600, res.ErrorRate.Mean, 1e-12)
+	})
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package comparison
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+
+	"github.com/mattermost/mattermost-load-test-ng/coordinator"
+	"github.com/mattermost/mattermost-load-test-ng/deployment"
+)
+
+// Name of the file where the progress of a comparison is persisted.
+// The directory is always inferred from deployment.Config.
+const stateFileName = "comparison.json"
+
+// loadTestState holds the progress of a single load-test.
+type loadTestState struct {
+	// The config of the load-test and the labels of the builds it runs,
+	// used to detect whether the comparison config changed.
+	Config LoadTestConfig
+	Labels []string
+	// The number of steps of the schedule that completed.
+	Completed int
+	// The final status of each completed run, indexed by build.
+	Repetitions [][]coordinator.Status
+	// The version of the server each build ran, if known, indexed by build.
+	ServerVersions []string `json:",omitempty"`
+	// The final result, set once the load-test has completed.
+	Result *Result `json:",omitempty"`
+}
+
+// deploymentState holds the progress of the load-tests of a deployment.
+type deploymentState struct {
+	// The state of each load-test, keyed by its index in the deployment.
+	LoadTests map[int]*loadTestState
+}
+
+func getStatePath(id string, cfg deployment.Config) string {
+	return path.Join(cfg.TerraformStateDir, id+"_"+stateFileName)
+}
+
+// readState reads the persisted state of the given deployment. It returns
+// an empty state if none was persisted.
+func readState(id string, cfg deployment.Config) (*deploymentState, error) {
+	state := &deploymentState{LoadTests: make(map[int]*loadTestState)}
+
+	data, err := os.ReadFile(getStatePath(id, cfg))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read comparison state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("unable to decode comparison state: %w", err)
+	}
+	if state.LoadTests == nil {
+		state.LoadTests = make(map[int]*loadTestState)
+	}
+
+	return state, nil
+}
+
+// persist writes the state of the given deployment to disk.
+func (s *deploymentState) persist(id string, cfg deployment.Config) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode comparison state: %w", err)
+	}
+
+	// We write to a temporary file first so that the state is never left
+	// half written.
+	statePath := getStatePath(id, cfg)
+	if err := os.WriteFile(statePath+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("unable to write comparison state: %w", err)
+	}
+	if err := os.Rename(statePath+".tmp", statePath); err != nil {
+		return fmt.Errorf("unable to write comparison state: %w", err)
+	}
+
+	return nil
+}
+
+// removeState removes the persisted state of the given deployment, if any.
+func removeState(id string, cfg deployment.Config) error {
+	if err := os.Remove(getStatePath(id, cfg)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("unable to remove comparison state: %w", err)
+	}
+	return nil
+}
+
+// loadTest returns the state of the given load-test, initializing it if
+// needed. It returns an error if the persisted state doesn't match the
+// current config.
+func (s *deploymentState) loadTest(ltID int, lt LoadTestConfig, builds []BuildConfig) (*loadTestState, error) {
+	labels := make([]string, len(builds))
+	for i, build := range builds {
+		labels[i] = build.Label
+	}
+
+	ltState, ok := s.LoadTests[ltID]
+	if !ok {
+		ltState = &loadTestState{
+			Config:         lt,
+			Labels:         labels,
+			Repetitions:    make([][]coordinator.Status, len(builds)),
+			ServerVersions: make([]string, len(builds)),
+		}
+		s.LoadTests[ltID] = ltState
+		return ltState, nil
+	}
+
+	if ltState.Config != lt || !slices.Equal(ltState.Labels, labels) || len(ltState.Repetitions) != len(builds) {
+		return nil, fmt.Errorf("persisted state for load-test %d does not match the current config", ltID)
+	}
+	// States persisted before server versions were recorded don't have any.
+	if len(ltState.ServerVersions) != len(builds) {
+		ltState.ServerVersions = make([]string, len(builds))
+	}
+
+	return ltState, nil
+}
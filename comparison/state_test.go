@@ -0,0 +1,83 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package comparison
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mattermost/mattermost-load-test-ng/coordinator"
+	"github.com/mattermost/mattermost-load-test-ng/deployment"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeploymentState(t *testing.T) {
+	cfg := deployment.Config{TerraformStateDir: t.TempDir()}
+	builds := []BuildConfig{{Label: "base"}, {Label: "new"}}
+	lt := LoadTestConfig{Type: LoadTestTypeBounded, DBEngine: "postgresql", NumUsers: 100, Duration: "10m"}
+
+	t.Run("missing", func(t *testing.T) {
+		state, err := readState("deployment0", cfg)
+		require.NoError(t, err)
+		require.Empty(t, state.LoadTests)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		state, err := readState("deployment0", cfg)
+		require.NoError(t, err)
+
+		ltState, err := state.loadTest(0, lt, builds)
+		require.NoError(t, err)
+		require.Len(t, ltState.Repetitions, 2)
+		ltState.Repetitions[0] = append(ltState.Repetitions[0], coordinator.Status{SupportedUsers: 100})
+		ltState.ServerVersions[0] = "10.5.0"
+		ltState.Completed = 1
+		require.NoError(t, state.persist("deployment0", cfg))
+
+		state, err = readState("deployment0", cfg)
+		require.NoError(t, err)
+		ltState, err = state.loadTest(0, lt, builds)
+		require.NoError(t, err)
+		require.Equal(t, 1, ltState.Completed)
+		require.Equal(t, 100, ltState.Repetitions[0][0].SupportedUsers)
+		require.Empty(t, ltState.Repetitions[1])
+		require.Equal(t, []string{"10.5.0", ""}, ltState.ServerVersions)
+		require.Nil(t, ltState.Result)
+
+		_, err = os.Stat(getStatePath("deployment0", cfg) + ".tmp")
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("without server versions", func(t *testing.T) {
+		state := &deploymentState{LoadTests: map[int]*loadTestState{
+			1: {Config: lt, Labels: []string{"base", "new"}, Repetitions: make([][]coordinator.Status, 2)},
+		}}
+		ltState, err := state.loadTest(1, lt, builds)
+		require.NoError(t, err)
+		require.Len(t, ltState.ServerVersions, 2)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		state, err := readState("deployment0", cfg)
+		require.NoError(t, err)
+
+		changed := lt
+		changed.NumUsers = 200
+		_, err = state.loadTest(0, changed, builds)
+		require.Error(t, err)
+
+		_, err = state.loadTest(0, lt, []BuildConfig{{Label: "base"}, {Label: "other"}})
+		require.Error(t, err)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		require.NoError(t, removeState("deployment0", cfg))
+		require.NoError(t, removeState("deployment0", cfg))
+
+		state, err := readState("deployment0", cfg)
+		require.NoError(t, err)
+		require.Empty(t, state.LoadTests)
+	})
+}
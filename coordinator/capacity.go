@@ -0,0 +1,88 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package coordinator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mattermost/mattermost-load-test-ng/coordinator/capacity"
+)
+
+const (
+	// The minimum time between two samples of the capacity curve.
+	capacitySampleInterval = 5 * time.Second
+)
+
+// newCapacityCurve computes a capacity curve from the given samples. The
+// supported slice holds the samples the number of supported users was
+// estimated from, if any.
+func newCapacityCurve(samples []capacity.Sample, supported []point) *capacity.Curve {
+	curve := &capacity.Curve{
+		Samples: samples,
+	}
+
+	// Samples are grouped by number of active users so that each query
+	// yields a function of users.
+	type acc struct {
+		sum   float64
+		count int
+	}
+	byQuery := make(map[string]map[int]*acc)
+	for _, s := range samples {
+		for query, value := range s.Values {
+			if byQuery[query] == nil {
+				byQuery[query] = make(map[int]*acc)
+			}
+			a := byQuery[query][s.ActiveUsers]
+			if a == nil {
+				a = &acc{}
+				byQuery[query][s.ActiveUsers] = a
+			}
+			a.sum += value
+			a.count++
+		}
+	}
+
+	queries := make([]string, 0, len(byQuery))
+	for query := range byQuery {
+		queries = append(queries, query)
+	}
+	sort.Strings(queries)
+
+	for _, query := range queries {
+		users := make([]int, 0, len(byQuery[query]))
+		for u := range byQuery[query] {
+			users = append(users, u)
+		}
+		sort.Ints(users)
+
+		xs := make([]float64, len(users))
+		ys := make([]float64, len(users))
+		for i, u := range users {
+			a := byQuery[query][u]
+			xs[i] = float64(u)
+			ys[i] = a.sum / float64(a.count)
+		}
+
+		if k := kneeIndex(xs, ys); k >= 0 {
+			curve.Knees = append(curve.Knees, capacity.KneePoint{
+				Query:       query,
+				ActiveUsers: users[k],
+				Value:       ys[k],
+			})
+		}
+	}
+
+	if len(supported) > 0 {
+		users := make([]float64, len(supported))
+		for i, p := range supported {
+			users[i] = float64(p.y)
+		}
+		ci := capacity.NewConfidenceInterval(users)
+		curve.SupportedUsers = &ci
+	}
+
+	return curve
+}
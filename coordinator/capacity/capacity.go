@@ -0,0 +1,85 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package capacity defines the capacity curve computed by the coordinator
+// over the course of unbounded load-tests. It is kept apart from the
+// coordinator so that reports can make use of it.
+package capacity
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// The confidence level of the intervals computed by NewConfidenceInterval.
+	confidenceLevel = 0.95
+	// The z-score used to compute the 95% confidence interval.
+	confidenceZScore = 1.96
+)
+
+// Sample is a single observation of the capacity curve.
+type Sample struct {
+	Time        time.Time
+	ActiveUsers int
+	// The value returned by each monitored query, keyed by the query description.
+	Values map[string]float64
+}
+
+// KneePoint is the point of a capacity curve after which the value of a
+// monitored query starts to degrade significantly as users are added.
+type KneePoint struct {
+	Query       string  // The description of the query.
+	ActiveUsers int     // The number of active users at the knee.
+	Value       float64 // The average value of the query at the knee.
+}
+
+// ConfidenceInterval holds the mean of a set of samples and the bounds of
+// its confidence interval.
+type ConfidenceInterval struct {
+	Level float64 // The confidence level (e.g. 0.95).
+	Mean  float64
+	Lower float64
+	Upper float64
+}
+
+// Curve relates the number of active users to the values of the monitored
+// queries over the course of a load-test.
+type Curve struct {
+	Samples []Sample
+	// The detected knee points, one per query for which one was found.
+	Knees []KneePoint
+	// The confidence interval of the number of supported users, computed
+	// from the samples used to estimate it. It's nil if the load-test did
+	// not converge.
+	SupportedUsers *ConfidenceInterval `json:",omitempty"`
+}
+
+// NewConfidenceInterval returns the mean of the given values along with the
+// bounds of its 95% confidence interval, using the normal approximation.
+func NewConfidenceInterval(values []float64) ConfidenceInterval {
+	ci := ConfidenceInterval{Level: confidenceLevel}
+	if len(values) == 0 {
+		return ci
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	ci.Mean = sum / float64(len(values))
+	ci.Lower, ci.Upper = ci.Mean, ci.Mean
+	if len(values) < 2 {
+		return ci
+	}
+
+	var sqSum float64
+	for _, v := range values {
+		sqSum += (v - ci.Mean) * (v - ci.Mean)
+	}
+	stddev := math.Sqrt(sqSum / float64(len(values)-1))
+	margin := confidenceZScore * stddev / math.Sqrt(float64(len(values)))
+	ci.Lower = ci.Mean - margin
+	ci.Upper = ci.Mean + margin
+	return ci
+}
@@ -0,0 +1,27 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfidenceInterval(t *testing.T) {
+	require.Equal(t, ConfidenceInterval{Level: 0.95}, NewConfidenceInterval(nil))
+
+	ci := NewConfidenceInterval([]float64{100})
+	require.Equal(t, ConfidenceInterval{Level: 0.95, Mean: 100, Lower: 100, Upper: 100}, ci)
+
+	ci = NewConfidenceInterval([]float64{98, 102, 98, 102})
+	require.Equal(t, 100.0, ci.Mean)
+	require.InDelta(t, 97.7368, ci.Lower, 1e-4)
+	require.InDelta(t, 102.2632, ci.Upper, 1e-4)
+
+	ci = NewConfidenceInterval([]float64{1, 2, 3, 4})
+	require.Equal(t, 2.5, ci.Mean)
+	require.InDelta(t, 2.5-1.96*0.6454972, ci.Lower, 1e-6)
+	require.InDelta(t, 2.5+1.96*0.6454972, ci.Upper, 1e-6)
+}
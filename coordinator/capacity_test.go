@@ -0,0 +1,53 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package coordinator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-load-test-ng/coordinator/capacity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCapacityCurve(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		curve := newCapacityCurve(nil, nil)
+		require.NotNil(t, curve)
+		require.Empty(t, curve.Knees)
+		require.Nil(t, curve.SupportedUsers)
+	})
+
+	t.Run("knee and confidence interval", func(t *testing.T) {
+		now := time.Now()
+		users := []int{100, 200, 300, 400, 500, 600, 600}
+		latencies := []float64{0.10, 0.11, 0.11, 0.12, 0.40, 1.10, 1.30}
+		var samples []capacity.Sample
+		for i := range users {
+			samples = append(samples, capacity.Sample{
+				Time:        now.Add(time.Duration(i) * capacitySampleInterval),
+				ActiveUsers: users[i],
+				Values: map[string]float64{
+					"p99":    latencies[i],
+					"errors": 0,
+				},
+			})
+		}
+		supported := []point{
+			{now, 390},
+			{now.Add(time.Second), 410},
+		}
+
+		curve := newCapacityCurve(samples, supported)
+		require.Len(t, curve.Samples, len(samples))
+		require.Equal(t, []capacity.KneePoint{
+			{Query: "p99", ActiveUsers: 400, Value: 0.12},
+		}, curve.Knees)
+		require.NotNil(t, curve.SupportedUsers)
+		require.Equal(t, float64(400), curve.SupportedUsers.Mean)
+		require.Less(t, curve.SupportedUsers.Lower, float64(400))
+		require.Greater(t, curve.SupportedUsers.Upper, float64(400))
+	})
+}
@@ -13,10 +13,18 @@ import (
 	client "github.com/mattermost/mattermost-load-test-ng/api/client/agent"
 	"github.com/mattermost/mattermost-load-test-ng/defaults"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest"
-	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/simplecontroller"
-	"github.com/mattermost/mattermost-load-test-ng/loadtest/control/simulcontroller"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/control"
 	"github.com/wiggin77/merror"
 
+	// The built-in controllers are registered so that their config is
+	// read and sent to the agents.
+	_ "github.com/mattermost/mattermost-load-test-ng/loadtest/control/botcontroller"
+	_ "github.com/mattermost/mattermost-load-test-ng/loadtest/control/clustercontroller"
+	_ "github.com/mattermost/mattermost-load-test-ng/loadtest/control/gencontroller"
+	_ "github.com/mattermost/mattermost-load-test-ng/loadtest/control/noopcontroller"
+	_ "github.com/mattermost/mattermost-load-test-ng/loadtest/control/simplecontroller"
+	_ "github.com/mattermost/mattermost-load-test-ng/loadtest/control/simulcontroller"
+
 	"github.com/mattermost/mattermost/server/public/shared/mlog"
 )
 
@@ -40,15 +48,14 @@ func createAgent(agent *client.Agent, ltConfig loadtest.Config) error {
 	// TODO: UserController config should probably come from the upper layer
 	// and be passed through.
 	var ucConfig interface{}
-	var err error
-	switch ltConfig.UserControllerConfiguration.Type {
-	case loadtest.UserControllerSimple:
-		ucConfig, err = simplecontroller.ReadConfig("")
-	case loadtest.UserControllerSimulative:
-		ucConfig, err = simulcontroller.ReadConfig("")
-	}
-	if err != nil {
-		return fmt.Errorf("cluster: failed to read controller config: %w", err)
+	// Types not registered in the coordinator may still be known to the
+	// agents, which fall back to their default config.
+	if ucType := string(ltConfig.UserControllerConfiguration.Type); control.IsControllerRegistered(ucType) {
+		var err error
+		ucConfig, err = control.ReadControllerConfig(ucType, "")
+		if err != nil {
+			return fmt.Errorf("cluster: failed to read controller config: %w", err)
+		}
 	}
 
 	if _, err := agent.Create(&ltConfig, ucConfig); err != nil {
@@ -0,0 +1,49 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package cluster
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client "github.com/mattermost/mattermost-load-test-ng/api/client/agent"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The controller packages are not imported here, so that the test relies on
+// the package registering them.
+func TestCreateAgent(t *testing.T) {
+	var data struct {
+		LoadTestConfig   *loadtest.Config
+		ControllerConfig json.RawMessage
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/loadagent/create", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&data))
+		w.WriteHeader(http.StatusCreated)
+		require.NoError(t, json.NewEncoder(w).Encode(client.AgentResponse{Id: "agent0", Status: &loadtest.Status{}}))
+	}))
+	defer server.Close()
+
+	agent, err := client.New("agent0", server.URL, nil)
+	require.NoError(t, err)
+
+	ltConfig, err := loadtest.ReadConfig("")
+	require.NoError(t, err)
+	ltConfig.UserControllerConfiguration.Type = loadtest.UserControllerSimulative
+
+	require.NoError(t, createAgent(agent, *ltConfig))
+	require.NotNil(t, data.LoadTestConfig)
+	require.EqualValues(t, loadtest.UserControllerSimulative, data.LoadTestConfig.UserControllerConfiguration.Type)
+
+	require.NotEmpty(t, data.ControllerConfig, "the simulcontroller config should be sent")
+	var sent map[string]any
+	require.NoError(t, json.Unmarshal(data.ControllerConfig, &sent))
+	require.Equal(t, 1000.0, sent["MinIdleTimeMs"])
+	require.Equal(t, 20000.0, sent["AvgIdleTimeMs"])
+}
@@ -7,9 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/mattermost/mattermost-load-test-ng/coordinator/capacity"
 	"github.com/mattermost/mattermost-load-test-ng/coordinator/cluster"
 	"github.com/mattermost/mattermost-load-test-ng/coordinator/performance"
 	"github.com/mattermost/mattermost-load-test-ng/defaults"
@@ -25,6 +27,7 @@ type Coordinator struct {
 	stopChan chan struct{}
 	doneChan chan struct{}
 	status   Status
+	curve    *capacity.Curve
 	config   *Config
 	cluster  *cluster.LoadAgentCluster
 	monitor  *performance.Monitor
@@ -84,9 +87,15 @@ func (c *Coordinator) Run() (<-chan struct{}, error) {
 
 	go func() {
 		var supported int
+		var supportedSamples []point
+		var curveSamples []capacity.Sample
+		var lastCurveSampleTime time.Time
 
 		defer func() {
 			c.monitor.Stop()
+			// The curve needs to be set before signaling we are done
+			// as Stop() relies on it.
+			c.curve = newCapacityCurve(curveSamples, supportedSamples)
 			clusterStatus, err := c.cluster.Status()
 			if err != nil {
 				c.log.Error("coordinator: cluster status error:", mlog.Err(err))
@@ -97,6 +106,7 @@ func (c *Coordinator) Run() (<-chan struct{}, error) {
 			c.status.State = Done
 			c.status.SupportedUsers = supported
 			c.status.StopTime = time.Now()
+			c.status.CapacityCurve = c.curve
 			if clusterStatus.NumErrors > 0 {
 				c.status.NumErrors = clusterStatus.NumErrors
 			}
@@ -126,6 +136,15 @@ func (c *Coordinator) Run() (<-chan struct{}, error) {
 			}
 			c.log.Info("coordinator: cluster status:", mlog.Int("active_users", status.ActiveUsers), mlog.Int("errors", status.NumErrors))
 
+			if hasPassed(lastCurveSampleTime, capacitySampleInterval) {
+				lastCurveSampleTime = time.Now()
+				curveSamples = append(curveSamples, capacity.Sample{
+					Time:        lastCurveSampleTime,
+					ActiveUsers: status.ActiveUsers,
+					Values:      perfStatus.Values,
+				})
+			}
+
 			if !lastAlertTime.IsZero() {
 				samples = append(samples, point{
 					x: time.Now(),
@@ -139,6 +158,7 @@ func (c *Coordinator) Run() (<-chan struct{}, error) {
 				if len(latest) > 0 && len(latest) < len(samples) && math.Abs(slope(latest)) < stopThreshold {
 					c.log.Info("coordinator done!")
 					supported = int(math.Round(avg(latest)))
+					supportedSamples = latest
 					c.log.Info(fmt.Sprintf("estimated number of supported users is %d", supported))
 					return
 				}
@@ -213,6 +233,7 @@ func (c *Coordinator) Stop() error {
 		ActiveUsers:    clusterStatus.ActiveUsers,
 		NumErrors:      clusterStatus.NumErrors,
 		SupportedUsers: c.status.SupportedUsers,
+		CapacityCurve:  c.curve,
 	}
 	return nil
 }
@@ -272,7 +293,7 @@ func New(config *Config, ltConfig loadtest.Config, log *mlog.Logger) (*Coordinat
 		return nil, fmt.Errorf("coordinator: failed to create cluster: %w", err)
 	}
 
-	monitor, err := performance.NewMonitor(config.MonitorConfig, log)
+	monitor, err := performance.NewMonitor(config.MonitorConfig, slices.Concat(clusterConfig.Agents, clusterConfig.BrowserAgents), log)
 	if err != nil {
 		return nil, fmt.Errorf("coordinator: failed to create performance monitor: %w", err)
 	}
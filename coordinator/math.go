@@ -1,6 +1,7 @@
 package coordinator
 
 import (
+	"math"
 	"time"
 )
 
@@ -37,3 +38,48 @@ func avg(points []point) float64 {
 	}
 	return float64(total) / float64(len(points))
 }
+
+// kneeIndex returns the index of the knee point of the curve defined by the
+// given coordinates, sorted by x. After normalizing both axes, the knee is
+// the point with the maximum distance from the straight line joining the
+// first and last points of the curve. It returns -1 if the curve has fewer
+// than three points or no knee could be found.
+func kneeIndex(xs, ys []float64) int {
+	n := len(xs)
+	if n < 3 || len(ys) != n {
+		return -1
+	}
+
+	minY, maxY := ys[0], ys[0]
+	for _, y := range ys {
+		minY = math.Min(minY, y)
+		maxY = math.Max(maxY, y)
+	}
+	rangeX := xs[n-1] - xs[0]
+	rangeY := maxY - minY
+	if rangeX <= 0 || rangeY <= 0 {
+		return -1
+	}
+
+	normX := func(i int) float64 { return (xs[i] - xs[0]) / rangeX }
+	normY := func(i int) float64 { return (ys[i] - minY) / rangeY }
+
+	// Line joining the first and last points: a*x + b*y + c = 0.
+	a := normY(n-1) - normY(0)
+	b := normX(0) - normX(n-1)
+	c := normX(n-1)*normY(0) - normX(0)*normY(n-1)
+	norm := math.Hypot(a, b)
+
+	knee := -1
+	// Distances below this value are considered rounding errors.
+	maxDist := 1e-9
+	for i := 1; i < n-1; i++ {
+		dist := math.Abs(a*normX(i)+b*normY(i)+c) / norm
+		if dist > maxDist {
+			maxDist = dist
+			knee = i
+		}
+	}
+
+	return knee
+}
@@ -65,6 +65,22 @@ func TestSlope(t *testing.T) {
 	require.Equal(t, float64(1.1879), math.Round(s*10000)/10000)
 }
 
+func TestKneeIndex(t *testing.T) {
+	require.Equal(t, -1, kneeIndex(nil, nil))
+	require.Equal(t, -1, kneeIndex([]float64{1, 2}, []float64{1, 2}))
+
+	// A flat curve has no knee.
+	require.Equal(t, -1, kneeIndex([]float64{1, 2, 3}, []float64{5, 5, 5}))
+
+	// A straight line has no knee.
+	require.Equal(t, -1, kneeIndex([]float64{1, 2, 3, 4}, []float64{1, 2, 3, 4}))
+
+	// Latency stays flat until 400 users and then grows quickly.
+	xs := []float64{100, 200, 300, 400, 500, 600}
+	ys := []float64{0.10, 0.11, 0.11, 0.12, 0.40, 1.20}
+	require.Equal(t, 3, kneeIndex(xs, ys))
+}
+
 var slopeSink float64
 
 func BenchmarkSlope(b *testing.B) {
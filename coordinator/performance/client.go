@@ -0,0 +1,292 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package performance
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	client "github.com/mattermost/mattermost-load-test-ng/api/client/agent"
+	"github.com/mattermost/mattermost-load-test-ng/coordinator/cluster"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	defaultClientQueryWindowSec = 60
+	clientRequestTimeout        = 5 * time.Second
+)
+
+// Names of the metrics exposed by the load-test agents.
+const (
+	metricHTTPRequestTime            = "loadtest_http_request_time"
+	metricHTTPErrors                 = "loadtest_http_errors_total"
+	metricHTTPTimeouts               = "loadtest_http_timeouts_total"
+	metricHTTPThrottled              = "loadtest_http_throttled_total"
+	metricWebSocketEventDeliveryTime = "loadtest_websocket_event_delivery_time"
+)
+
+// histogramSnapshot holds the cumulative values of a histogram.
+type histogramSnapshot struct {
+	buckets map[float64]float64 // Cumulative counts by upper bound.
+	count   float64
+	sum     float64
+}
+
+func (h *histogramSnapshot) add(hist *dto.Histogram) {
+	if h.buckets == nil {
+		h.buckets = make(map[float64]float64)
+	}
+	for _, b := range hist.GetBucket() {
+		h.buckets[b.GetUpperBound()] += float64(b.GetCumulativeCount())
+	}
+	h.count += float64(hist.GetSampleCount())
+	h.sum += hist.GetSampleSum()
+}
+
+// delta returns the increase of the histogram since base. A decrease in the
+// number of samples means an agent restarted, in which case the current
+// values are taken as the increase.
+func (h histogramSnapshot) delta(base histogramSnapshot) histogramSnapshot {
+	if h.count < base.count {
+		return h
+	}
+	d := histogramSnapshot{
+		buckets: make(map[float64]float64, len(h.buckets)),
+		count:   h.count - base.count,
+		sum:     h.sum - base.sum,
+	}
+	for le, v := range h.buckets {
+		d.buckets[le] = v - base.buckets[le]
+	}
+	return d
+}
+
+// quantile estimates the q-quantile of the histogram by linear
+// interpolation within buckets, as PromQL's histogram_quantile does.
+func (h histogramSnapshot) quantile(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+
+	bounds := make([]float64, 0, len(h.buckets))
+	for le := range h.buckets {
+		bounds = append(bounds, le)
+	}
+	sort.Float64s(bounds)
+
+	rank := q * h.count
+	var prevBound, prevCount float64
+	for _, le := range bounds {
+		count := h.buckets[le]
+		if count >= rank {
+			if count == prevCount {
+				return le
+			}
+			return prevBound + (le-prevBound)*((rank-prevCount)/(count-prevCount))
+		}
+		prevBound, prevCount = le, count
+	}
+
+	// The quantile falls in the implicit +Inf bucket, so we return
+	// the highest finite upper bound.
+	return prevBound
+}
+
+// clientSnapshot holds the client-side metrics aggregated across the whole
+// cluster of load-test agents at a given point in time.
+type clientSnapshot struct {
+	time            time.Time
+	httpRequestTime histogramSnapshot
+	wsDeliveryTime  histogramSnapshot
+	httpErrors      float64
+	httpTimeouts    float64
+	httpThrottled   float64
+	actionErrors    float64
+}
+
+// clientCollector periodically gathers the metrics exposed by the load-test
+// agents through their API.
+type clientCollector struct {
+	metricsURLs []string
+	agents      []*client.Agent
+	httpClient  *http.Client
+	maxAge      time.Duration
+	samples     []clientSnapshot
+}
+
+func newClientCollector(agents []cluster.LoadAgentConfig, queries []ClientQuery) (*clientCollector, error) {
+	if len(agents) == 0 {
+		return nil, errors.New("at least one agent is needed to evaluate client queries")
+	}
+
+	c := &clientCollector{
+		httpClient: &http.Client{Timeout: clientRequestTimeout},
+	}
+
+	// Multiple agents can share the same API server, which exposes a single
+	// set of metrics for all of them. The status is still reported by each
+	// agent on its own.
+	seen := make(map[string]bool)
+	for _, cfg := range agents {
+		agent, err := client.New(cfg.Id, cfg.ApiURL, c.httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create api client for agent: %w", err)
+		}
+		c.agents = append(c.agents, agent)
+
+		metricsURL := strings.TrimSuffix(cfg.ApiURL, "/") + "/metrics"
+		if !seen[metricsURL] {
+			seen[metricsURL] = true
+			c.metricsURLs = append(c.metricsURLs, metricsURL)
+		}
+	}
+
+	for _, q := range queries {
+		c.maxAge = max(c.maxAge, q.window())
+	}
+
+	return c, nil
+}
+
+// collect gathers a new snapshot of the metrics from all the agents and
+// discards the ones no longer needed to evaluate queries.
+func (c *clientCollector) collect() error {
+	snap := clientSnapshot{time: time.Now()}
+
+	for _, u := range c.metricsURLs {
+		families, err := c.fetchMetrics(u)
+		if err != nil {
+			return err
+		}
+		for _, m := range families[metricHTTPRequestTime].GetMetric() {
+			snap.httpRequestTime.add(m.GetHistogram())
+		}
+		for _, m := range families[metricWebSocketEventDeliveryTime].GetMetric() {
+			snap.wsDeliveryTime.add(m.GetHistogram())
+		}
+		for _, m := range families[metricHTTPErrors].GetMetric() {
+			snap.httpErrors += m.GetCounter().GetValue()
+		}
+		for _, m := range families[metricHTTPTimeouts].GetMetric() {
+			snap.httpTimeouts += m.GetCounter().GetValue()
+		}
+		for _, m := range families[metricHTTPThrottled].GetMetric() {
+			snap.httpThrottled += m.GetCounter().GetValue()
+		}
+	}
+
+	for _, agent := range c.agents {
+		st, err := agent.Status()
+		if err != nil {
+			return fmt.Errorf("failed to get status for agent %s: %w", agent.Id(), err)
+		}
+		snap.actionErrors += float64(st.NumErrors)
+	}
+
+	c.samples = append(c.samples, snap)
+
+	// We keep the most recent sample older than maxAge so that queries always
+	// have a base covering their whole window.
+	var i int
+	for i < len(c.samples)-1 && snap.time.Sub(c.samples[i+1].time) >= c.maxAge {
+		i++
+	}
+	c.samples = c.samples[i:]
+
+	return nil
+}
+
+func (c *clientCollector) fetchMetrics(u string) (map[string]*dto.MetricFamily, error) {
+	resp, err := c.httpClient.Get(u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch metrics from %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch metrics from %s: bad response status code %d", u, resp.StatusCode)
+	}
+
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics from %s: %w", u, err)
+	}
+	return families, nil
+}
+
+// value evaluates the given query over the collected samples.
+func (c *clientCollector) value(q ClientQuery) (float64, error) {
+	if len(c.samples) < 2 {
+		return 0, errors.New("not enough samples collected")
+	}
+
+	latest := c.samples[len(c.samples)-1]
+	base := c.samples[0]
+	for _, s := range c.samples[:len(c.samples)-1] {
+		if latest.time.Sub(s.time) <= q.window() {
+			break
+		}
+		base = s
+	}
+	elapsed := latest.time.Sub(base.time).Seconds()
+
+	switch q.Metric {
+	case ClientMetricHTTPRequestTime:
+		return histogramValue(latest.httpRequestTime.delta(base.httpRequestTime), q.Quantile), nil
+	case ClientMetricWebSocketDeliveryTime:
+		return histogramValue(latest.wsDeliveryTime.delta(base.wsDeliveryTime), q.Quantile), nil
+	case ClientMetricHTTPTimeoutsRate:
+		return counterDelta(latest.httpTimeouts, base.httpTimeouts) / elapsed, nil
+	case ClientMetricActionErrorsRate:
+		return counterDelta(latest.actionErrors, base.actionErrors) / elapsed, nil
+	case ClientMetricHTTPErrorsRatio:
+		requests := latest.httpRequestTime.delta(base.httpRequestTime).count
+		if requests == 0 {
+			return 0, nil
+		}
+		return math.Min(counterDelta(latest.httpErrors, base.httpErrors)/requests, 1), nil
+	case ClientMetricHTTPThrottledRatio:
+		requests := latest.httpRequestTime.delta(base.httpRequestTime).count
+		if requests == 0 {
+			return 0, nil
+		}
+		return math.Min(counterDelta(latest.httpThrottled, base.httpThrottled)/requests, 1), nil
+	default:
+		return 0, fmt.Errorf("unsupported client metric %q", q.Metric)
+	}
+}
+
+func (q ClientQuery) window() time.Duration {
+	if q.WindowSec == 0 {
+		return defaultClientQueryWindowSec * time.Second
+	}
+	return time.Duration(q.WindowSec) * time.Second
+}
+
+func histogramValue(h histogramSnapshot, quantile float64) float64 {
+	if quantile > 0 {
+		return h.quantile(quantile)
+	}
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / h.count
+}
+
+// counterDelta returns the increase of a counter, taking into account
+// resets caused by agent restarts.
+func counterDelta(latest, base float64) float64 {
+	if latest < base {
+		return latest
+	}
+	return latest - base
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package performance
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	client "github.com/mattermost/mattermost-load-test-ng/api/client/agent"
+	"github.com/mattermost/mattermost-load-test-ng/coordinator/cluster"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest"
+	"github.com/mattermost/mattermost-load-test-ng/performance"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	h := histogramSnapshot{
+		buckets: map[float64]float64{
+			0.1: 50,
+			0.5: 90,
+			1:   100,
+		},
+		count: 100,
+		sum:   20,
+	}
+
+	require.InDelta(t, 0.1, h.quantile(0.5), 1e-9)
+	require.InDelta(t, 0.5, h.quantile(0.9), 1e-9)
+	require.InDelta(t, 0.75, h.quantile(0.95), 1e-9)
+	require.InDelta(t, 0.2, histogramValue(h, 0), 1e-9)
+	require.Zero(t, histogramSnapshot{}.quantile(0.99))
+}
+
+func TestClientCollector(t *testing.T) {
+	metrics := performance.NewMetrics()
+	ueMetrics := metrics.UserEntityMetrics()
+	var numErrors atomic.Int64
+	var mut sync.Mutex
+	polled := map[string]bool{}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/loadagent/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/loadagent/")
+		mut.Lock()
+		polled[id] = true
+		mut.Unlock()
+
+		// Each agent reports its own errors.
+		agentErrors := numErrors.Load()
+		if id == "lt1" {
+			agentErrors *= 2
+		}
+		json.NewEncoder(w).Encode(client.AgentResponse{
+			Status: &loadtest.Status{NumErrors: agentErrors},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	agents := []cluster.LoadAgentConfig{
+		{Id: "lt0", ApiURL: srv.URL},
+		{Id: "lt1", ApiURL: srv.URL},
+	}
+	queries := []ClientQuery{
+		{Description: "p50", Metric: ClientMetricHTTPRequestTime, Quantile: 0.5},
+		{Description: "errors", Metric: ClientMetricHTTPErrorsRatio},
+		{Description: "actions", Metric: ClientMetricActionErrorsRate},
+		{Description: "throttled", Metric: ClientMetricHTTPThrottledRatio},
+	}
+
+	c, err := newClientCollector(agents, queries)
+	require.NoError(t, err)
+	require.Len(t, c.metricsURLs, 1)
+	require.Len(t, c.agents, 2)
+
+	require.NoError(t, c.collect())
+	_, err = c.value(queries[0])
+	require.Error(t, err)
+
+	for range 10 {
+		ueMetrics.HTTPRequestTimes.Observe(0.2)
+	}
+	ueMetrics.HTTPErrors.With(prometheus.Labels{"path": "/api/v4/posts", "method": "POST", "status_code": "500"}).Inc()
+	ueMetrics.HTTPThrottled.With(prometheus.Labels{"path": "/api/v4/posts", "method": "POST"}).Add(2)
+	numErrors.Store(5)
+
+	// Ensure some time elapses between samples.
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, c.collect())
+	require.Len(t, c.samples, 2)
+
+	value, err := c.value(queries[0])
+	require.NoError(t, err)
+	require.Greater(t, value, 0.1)
+	require.LessOrEqual(t, value, 0.25)
+
+	value, err = c.value(queries[1])
+	require.NoError(t, err)
+	require.InDelta(t, 0.1, value, 1e-9)
+
+	// Both agents are polled for their status and their errors summed, even
+	// though they share the same API server.
+	require.Equal(t, map[string]bool{"lt0": true, "lt1": true}, polled)
+	value, err = c.value(queries[2])
+	require.NoError(t, err)
+	elapsed := c.samples[1].time.Sub(c.samples[0].time).Seconds()
+	require.InDelta(t, 15, value*elapsed, 1e-9)
+
+	value, err = c.value(queries[3])
+	require.NoError(t, err)
+	require.InDelta(t, 0.2, value, 1e-9)
+}
+
+func TestNewClientCollectorNoAgents(t *testing.T) {
+	_, err := newClientCollector(nil, []ClientQuery{{Metric: ClientMetricHTTPRequestTime}})
+	require.Error(t, err)
+}
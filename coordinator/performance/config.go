@@ -16,19 +16,74 @@ import (
 type MonitorConfig struct {
 	// The URL of the Prometheus server to query.
 	PrometheusURL string `default:"http://localhost:9090" validate:"url"`
+	// The source to fetch metrics from. Defaults to the Prometheus server
+	// at PrometheusURL.
+	MetricsSource prometheus.SourceConfig
 	// The time interval in milliseconds to wait before querying again.
 	// DEPRECATED as of MM-61922. It defaults to 1000 and values in config files are ignored.
 	UpdateIntervalMs int `default:"1000"`
 	// The slice of queries to run.
 	Queries []prometheus.Query `default_size:"0"`
+	// The slice of queries to evaluate over the client-side metrics
+	// collected from the load-test agents.
+	ClientQueries []ClientQuery `default_size:"0"`
+}
+
+// ClientMetric is the name of a client-side metric aggregated across the
+// load-test agents.
+type ClientMetric string
+
+// Available client-side metrics.
+const (
+	// The time taken to execute HTTP requests, in seconds.
+	ClientMetricHTTPRequestTime ClientMetric = "http_request_time"
+	// The number of HTTP client timeouts per second.
+	ClientMetricHTTPTimeoutsRate ClientMetric = "http_timeouts_rate"
+	// The ratio of failed HTTP requests over the total number of requests.
+	ClientMetricHTTPErrorsRatio ClientMetric = "http_errors_ratio"
+	// The ratio of HTTP requests rate limited by the server over the total
+	// number of requests.
+	ClientMetricHTTPThrottledRatio ClientMetric = "http_throttled_ratio"
+	// The time elapsed between the creation of a post and the reception of
+	// the related WebSocket event, in seconds.
+	ClientMetricWebSocketDeliveryTime ClientMetric = "websocket_delivery_time"
+	// The number of failed user actions per second.
+	ClientMetricActionErrorsRate ClientMetric = "action_errors_rate"
+)
+
+// ClientQuery contains the information needed to evaluate a threshold over
+// the metrics reported by the load-test agents themselves, without relying
+// on a Prometheus server.
+type ClientQuery struct {
+	// The description for the query.
+	Description string `validate:"notempty"`
+	// The client-side metric to evaluate.
+	Metric ClientMetric `validate:"oneof:{http_request_time,http_timeouts_rate,http_errors_ratio,http_throttled_ratio,websocket_delivery_time,action_errors_rate}"`
+	// The quantile to compute for time based metrics (e.g. 0.99). A value of
+	// zero computes the average instead.
+	Quantile float64 `validate:"range:[0,1)"`
+	// The time window (in seconds) over which the metric is computed.
+	// It defaults to 60 when not set.
+	WindowSec int `validate:"range:[0,]"`
+	// The value over which the performance monitor will fire an alert
+	// to the coordinator's feedback loop.
+	Threshold float64 `validate:"range:[0,]"`
+	// The minimum amount of time (in seconds) that needs to have passed
+	// since the start of the monitoring process before the query can be run.
+	MinIntervalSec int `validate:"range:[0,]"`
+	// The value indicating whether or not to fire an alert.
+	Alert bool
 }
 
 // IsValid checks whether a MonitorConfig is valid or not.
 // Returns an error if the validation fails.
 func (c MonitorConfig) IsValid() error {
-	if c.PrometheusURL == "" {
+	if c.MetricsSource.Type != prometheus.SourceTypeFile && c.PrometheusURL == "" {
 		return errors.New("PrometheusURL cannot be empty")
 	}
+	if err := c.MetricsSource.IsValid(); err != nil {
+		return fmt.Errorf("invalid MetricsSource: %w", err)
+	}
 	if c.UpdateIntervalMs != defaultUpdateIntervalMs {
 		mlog.Warn(fmt.Sprintf("monitor: UpdateIntervalMs (%v) is deprecated and will be ignored. Its value always defaults to 1000ms.", c.UpdateIntervalMs))
 	}
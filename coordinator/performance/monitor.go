@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/mattermost/mattermost-load-test-ng/coordinator/cluster"
 	"github.com/mattermost/mattermost-load-test-ng/coordinator/performance/prometheus"
 
 	"github.com/mattermost/mattermost/server/public/shared/mlog"
@@ -15,7 +16,8 @@ import (
 
 type Monitor struct {
 	config     MonitorConfig
-	helper     *prometheus.Helper
+	source     prometheus.MetricsSource
+	collector  *clientCollector
 	stopChan   chan struct{}
 	statusChan chan Status
 	log        *mlog.Logger
@@ -25,20 +27,30 @@ type Monitor struct {
 const defaultUpdateIntervalMs = 1000
 
 // NewMonitor creates and initializes a new Monitor.
-func NewMonitor(config MonitorConfig, log *mlog.Logger) (*Monitor, error) {
+// The given agents are used to gather the client-side metrics needed to
+// evaluate config.ClientQueries.
+func NewMonitor(config MonitorConfig, agents []cluster.LoadAgentConfig, log *mlog.Logger) (*Monitor, error) {
 	if log == nil {
 		return nil, errors.New("logger should not be nil")
 	}
 	if err := config.IsValid(); err != nil {
 		return nil, fmt.Errorf("could not validate configuration: %w", err)
 	}
-	helper, err := prometheus.NewHelper(config.PrometheusURL)
+	source, err := prometheus.NewSource(config.MetricsSource, config.PrometheusURL)
 	if err != nil {
-		return nil, fmt.Errorf("performance: failed to create prometheus.Helper: %w", err)
+		return nil, fmt.Errorf("performance: failed to create metrics source: %w", err)
+	}
+	var collector *clientCollector
+	if len(config.ClientQueries) > 0 {
+		collector, err = newClientCollector(agents, config.ClientQueries)
+		if err != nil {
+			return nil, fmt.Errorf("performance: failed to create client metrics collector: %w", err)
+		}
 	}
 	return &Monitor{
 		config:     config,
-		helper:     helper,
+		source:     source,
+		collector:  collector,
 		stopChan:   make(chan struct{}),
 		statusChan: make(chan Status),
 		log:        log,
@@ -73,7 +85,7 @@ func (m *Monitor) Stop() {
 }
 
 func (m *Monitor) runQueries() Status {
-	var status Status
+	status := Status{Values: make(map[string]float64)}
 	for _, query := range m.config.Queries {
 		select {
 		case <-m.stopChan:
@@ -85,25 +97,62 @@ func (m *Monitor) runQueries() Status {
 			m.log.Info("monitor: MinIntervalSec has not passed yet, skipping query")
 			continue
 		}
-		value, err := m.helper.VectorFirst(query.Query)
+		value, err := m.source.VectorFirst(query.Query)
 		if err != nil {
-			m.log.Warn("monitor: error while querying Prometheus:", mlog.String("query_description", query.Description), mlog.Err(err))
+			m.log.Warn("monitor: error while querying metrics source:", mlog.String("query_description", query.Description), mlog.Err(err))
 			continue
 		}
 
-		m.log.Debug("monitor: ran query",
-			mlog.String("query_description", query.Description),
-			mlog.String("query_returned_value", fmt.Sprintf("%2.8f", value)),
-			mlog.String("query_threshold", fmt.Sprintf("%2.8f", query.Threshold)),
-		)
-		if query.Alert && value >= query.Threshold {
-			m.log.Warn("monitor: returned value is above the threshold",
-				mlog.String("query_description", query.Description),
-				mlog.String("query_returned_value", fmt.Sprintf("%2.8f", value)),
-				mlog.String("query_threshold", fmt.Sprintf("%2.8f", query.Threshold)),
-			)
-			status = Status{Alert: true}
+		status.Values[query.Description] = value
+		if m.checkThreshold(query.Description, value, query.Threshold, query.Alert) {
+			status.Alert = true
 		}
 	}
+
+	if m.collector == nil {
+		return status
+	}
+
+	if err := m.collector.collect(); err != nil {
+		m.log.Warn("monitor: error while collecting client metrics:", mlog.Err(err))
+		return status
+	}
+
+	for _, query := range m.config.ClientQueries {
+		if time.Now().Before(m.startTime.Add(time.Duration(query.MinIntervalSec) * time.Second)) {
+			m.log.Info("monitor: MinIntervalSec has not passed yet, skipping client query")
+			continue
+		}
+		value, err := m.collector.value(query)
+		if err != nil {
+			m.log.Debug("monitor: error while evaluating client query:", mlog.String("query_description", query.Description), mlog.Err(err))
+			continue
+		}
+
+		status.Values[query.Description] = value
+		if m.checkThreshold(query.Description, value, query.Threshold, query.Alert) {
+			status.Alert = true
+		}
+	}
+
 	return status
 }
+
+// checkThreshold logs the value returned by a query and reports whether
+// an alert should be fired.
+func (m *Monitor) checkThreshold(description string, value, threshold float64, alert bool) bool {
+	m.log.Debug("monitor: ran query",
+		mlog.String("query_description", description),
+		mlog.String("query_returned_value", fmt.Sprintf("%2.8f", value)),
+		mlog.String("query_threshold", fmt.Sprintf("%2.8f", threshold)),
+	)
+	if alert && value >= threshold {
+		m.log.Warn("monitor: returned value is above the threshold",
+			mlog.String("query_description", description),
+			mlog.String("query_returned_value", fmt.Sprintf("%2.8f", value)),
+			mlog.String("query_threshold", fmt.Sprintf("%2.8f", threshold)),
+		)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// FakeSource is an in-memory MetricsSource meant to be used in tests.
+// Values are returned regardless of the requested time range.
+type FakeSource struct {
+	mut      sync.RWMutex
+	vectors  map[string]float64
+	matrices map[string]model.Matrix
+	err      error
+}
+
+// NewFakeSource creates an empty FakeSource.
+func NewFakeSource() *FakeSource {
+	return &FakeSource{
+		vectors:  make(map[string]float64),
+		matrices: make(map[string]model.Matrix),
+	}
+}
+
+// SetVector sets the value returned by VectorFirst for the given query.
+func (s *FakeSource) SetVector(query string, value float64) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.vectors[query] = value
+}
+
+// SetMatrix sets the value returned by Matrix for the given query.
+func (s *FakeSource) SetMatrix(query string, mat model.Matrix) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.matrices[query] = mat
+}
+
+// SetError makes all subsequent queries fail with the given error.
+// Passing nil restores the normal behavior.
+func (s *FakeSource) SetError(err error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.err = err
+}
+
+// VectorFirst returns the value previously set for the given query.
+func (s *FakeSource) VectorFirst(query string) (float64, error) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	value, ok := s.vectors[query]
+	if !ok {
+		return 0, fmt.Errorf("no value set for query %q", query)
+	}
+	return value, nil
+}
+
+// Matrix returns the matrix previously set for the given query.
+func (s *FakeSource) Matrix(query string, startTime, endTime time.Time) (model.Matrix, error) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	mat := s.matrices[query]
+	if len(mat) == 0 {
+		return nil, errors.New("matrix has length = 0")
+	}
+	return mat, nil
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package prometheus
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// RecordedQuery holds the recorded result of a single PromQL query.
+type RecordedQuery struct {
+	Query  string
+	Result model.Matrix
+}
+
+// FileSource is a MetricsSource serving previously recorded metrics
+// loaded from a local file. Queries are matched verbatim against the
+// recorded ones.
+type FileSource struct {
+	data map[string]model.Matrix
+}
+
+// NewFileSource loads recorded metrics from the file at the given path.
+// Files with a .csv extension are expected to have a header with
+// the query, labels, timestamp and value columns, where labels are
+// semicolon-separated name=value pairs and timestamps are Unix seconds.
+// Any other file is decoded as a JSON array of RecordedQuery.
+func NewFileSource(path string) (*FileSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metrics file: %w", err)
+	}
+	defer f.Close()
+
+	var recorded []RecordedQuery
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		recorded, err = readCSVRecording(f)
+	} else {
+		err = json.NewDecoder(f).Decode(&recorded)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics file %q: %w", path, err)
+	}
+
+	return NewFileSourceFromRecording(recorded), nil
+}
+
+// NewFileSourceFromRecording creates a FileSource from the given recorded
+// queries. Results recorded more than once for the same query are merged.
+func NewFileSourceFromRecording(recorded []RecordedQuery) *FileSource {
+	s := &FileSource{
+		data: make(map[string]model.Matrix, len(recorded)),
+	}
+	for _, rq := range recorded {
+		s.data[rq.Query] = append(s.data[rq.Query], rq.Result...)
+	}
+	return s
+}
+
+// VectorFirst returns the most recent recorded value of the first series
+// for the given query.
+func (s *FileSource) VectorFirst(query string) (float64, error) {
+	mat, ok := s.data[query]
+	if !ok || len(mat) == 0 || len(mat[0].Values) == 0 {
+		return 0, fmt.Errorf("no recorded data for query %q", query)
+	}
+	values := mat[0].Values
+	return float64(values[len(values)-1].Value), nil
+}
+
+// Matrix returns the recorded samples of the given query that fall within
+// the given time range.
+func (s *FileSource) Matrix(query string, startTime, endTime time.Time) (model.Matrix, error) {
+	start := model.TimeFromUnixNano(startTime.UnixNano())
+	end := model.TimeFromUnixNano(endTime.UnixNano())
+
+	var mat model.Matrix
+	for _, stream := range s.data[query] {
+		var values []model.SamplePair
+		for _, v := range stream.Values {
+			if v.Timestamp.Before(start) || v.Timestamp.After(end) {
+				continue
+			}
+			values = append(values, v)
+		}
+		if len(values) == 0 {
+			continue
+		}
+		mat = append(mat, &model.SampleStream{
+			Metric: stream.Metric,
+			Values: values,
+		})
+	}
+
+	if len(mat) == 0 {
+		return nil, errors.New("matrix has length = 0")
+	}
+
+	return mat, nil
+}
+
+func readCSVRecording(r io.Reader) ([]RecordedQuery, error) {
+	rd := csv.NewReader(r)
+	header, err := rd.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"query", "labels", "timestamp", "value"} {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("missing %q column", name)
+		}
+	}
+
+	// Samples are grouped by query first and by label set second.
+	streams := make(map[string]map[model.Fingerprint]*model.SampleStream)
+	var queries []string
+	for {
+		rec, err := rd.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		metric, err := parseLabels(rec[cols["labels"]])
+		if err != nil {
+			return nil, err
+		}
+		ts, err := strconv.ParseFloat(rec[cols["timestamp"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %w", rec[cols["timestamp"]], err)
+		}
+		value, err := strconv.ParseFloat(rec[cols["value"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %w", rec[cols["value"]], err)
+		}
+
+		query := rec[cols["query"]]
+		if _, ok := streams[query]; !ok {
+			streams[query] = make(map[model.Fingerprint]*model.SampleStream)
+			queries = append(queries, query)
+		}
+		fp := metric.Fingerprint()
+		stream, ok := streams[query][fp]
+		if !ok {
+			stream = &model.SampleStream{Metric: metric}
+			streams[query][fp] = stream
+		}
+		stream.Values = append(stream.Values, model.SamplePair{
+			Timestamp: model.TimeFromUnixNano(int64(ts * float64(time.Second))),
+			Value:     model.SampleValue(value),
+		})
+	}
+
+	recorded := make([]RecordedQuery, 0, len(queries))
+	for _, query := range queries {
+		var mat model.Matrix
+		for _, stream := range streams[query] {
+			sort.Slice(stream.Values, func(i, j int) bool {
+				return stream.Values[i].Timestamp.Before(stream.Values[j].Timestamp)
+			})
+			mat = append(mat, stream)
+		}
+		sort.Sort(mat)
+		recorded = append(recorded, RecordedQuery{Query: query, Result: mat})
+	}
+
+	return recorded, nil
+}
+
+// parseLabels parses a set of semicolon-separated name=value pairs.
+func parseLabels(s string) (model.Metric, error) {
+	metric := make(model.Metric)
+	for pair := range strings.SplitSeq(s, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid label pair %q", pair)
+		}
+		metric[model.LabelName(strings.TrimSpace(name))] = model.LabelValue(strings.TrimSpace(value))
+	}
+	return metric, nil
+}
@@ -26,7 +26,10 @@ type Helper struct {
 // NewHelper creates a helper with the standard Prometheus client
 // and API inside it, encapsulating all Prometheus dependencies.
 func NewHelper(prometheusURL string) (*Helper, error) {
-	config := prometheus.Config{Address: prometheusURL}
+	return newHelper(prometheus.Config{Address: prometheusURL})
+}
+
+func newHelper(config prometheus.Config) (*Helper, error) {
 	client, err := prometheus.NewClient(config)
 	if err != nil {
 		return nil, err
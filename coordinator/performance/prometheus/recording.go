@@ -0,0 +1,66 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package prometheus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// RecordingSource is a MetricsSource recording the results of all the
+// queries made through another source, so that they can be replayed later
+// through a FileSource.
+type RecordingSource struct {
+	source   MetricsSource
+	mut      sync.Mutex
+	recorded []RecordedQuery
+}
+
+// NewRecordingSource returns a MetricsSource recording the results of the
+// queries made through the given source.
+func NewRecordingSource(source MetricsSource) *RecordingSource {
+	return &RecordingSource{source: source}
+}
+
+// VectorFirst returns the first element from a vector query, which is
+// recorded as a single sample at the current time.
+func (s *RecordingSource) VectorFirst(query string) (float64, error) {
+	value, err := s.source.VectorFirst(query)
+	if err != nil {
+		return value, err
+	}
+	s.record(query, model.Matrix{{
+		Metric: model.Metric{},
+		Values: []model.SamplePair{{Timestamp: model.Now(), Value: model.SampleValue(value)}},
+	}})
+	return value, nil
+}
+
+// Matrix returns the matrix of metrics of a query in the given duration.
+func (s *RecordingSource) Matrix(query string, startTime, endTime time.Time) (model.Matrix, error) {
+	mat, err := s.source.Matrix(query, startTime, endTime)
+	if err != nil {
+		return mat, err
+	}
+	s.record(query, mat)
+	return mat, nil
+}
+
+// Recording returns the results of the queries recorded so far, in the
+// order they were made.
+func (s *RecordingSource) Recording() []RecordedQuery {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	recorded := make([]RecordedQuery, len(s.recorded))
+	copy(recorded, s.recorded)
+	return recorded
+}
+
+func (s *RecordingSource) record(query string, mat model.Matrix) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.recorded = append(s.recorded, RecordedQuery{Query: query, Result: mat})
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	prometheus "github.com/prometheus/client_golang/api"
+	"github.com/prometheus/common/model"
+)
+
+// SourceType is the type of backend metrics are fetched from.
+type SourceType string
+
+// Available metrics source types.
+const (
+	SourceTypePrometheus SourceType = "prometheus"
+	SourceTypeFile       SourceType = "file"
+)
+
+// MetricsSource is the interface used by the performance monitor and the
+// report generator to fetch metrics. Queries are expressed in PromQL, so any
+// backend exposing a Prometheus compatible API (e.g. VictoriaMetrics, Mimir)
+// can be used through the Prometheus implementation.
+type MetricsSource interface {
+	// VectorFirst returns the first element from a vector query.
+	VectorFirst(query string) (float64, error)
+	// Matrix returns the matrix of metrics of a query in the given duration.
+	Matrix(query string, startTime, endTime time.Time) (model.Matrix, error)
+}
+
+// SourceConfig holds the information needed to create a MetricsSource.
+type SourceConfig struct {
+	// The type of source to fetch metrics from.
+	Type SourceType `default:"prometheus" validate:"oneof:{prometheus,file}"`
+	// The username used for HTTP basic authentication against the
+	// Prometheus compatible endpoint.
+	BasicAuthUsername string
+	// The password used for HTTP basic authentication against the
+	// Prometheus compatible endpoint.
+	BasicAuthPassword string
+	// The token sent in the Authorization header of every request to the
+	// Prometheus compatible endpoint.
+	BearerToken string
+	// Additional headers sent with every request to the Prometheus
+	// compatible endpoint (e.g. X-Scope-OrgID for Mimir).
+	Headers map[string]string
+	// The path to a JSON or CSV file containing recorded metrics.
+	// Only used when Type is "file".
+	FilePath string `validate:"empty|file"`
+}
+
+// IsValid checks whether a SourceConfig is valid or not.
+// Returns an error if the validation fails.
+func (c SourceConfig) IsValid() error {
+	switch c.Type {
+	case SourceTypePrometheus, "":
+		if c.BearerToken != "" && (c.BasicAuthUsername != "" || c.BasicAuthPassword != "") {
+			return errors.New("BearerToken and BasicAuth settings cannot be used together")
+		}
+	case SourceTypeFile:
+		if c.FilePath == "" {
+			return errors.New("FilePath cannot be empty when Type is file")
+		}
+	default:
+		return fmt.Errorf("unsupported metrics source type %q", c.Type)
+	}
+	return nil
+}
+
+// NewSource creates a MetricsSource from the given configuration. The
+// prometheusURL is only used when the source is of type "prometheus".
+func NewSource(cfg SourceConfig, prometheusURL string) (MetricsSource, error) {
+	if err := cfg.IsValid(); err != nil {
+		return nil, fmt.Errorf("invalid metrics source configuration: %w", err)
+	}
+
+	switch cfg.Type {
+	case SourceTypeFile:
+		return NewFileSource(cfg.FilePath)
+	default:
+		if prometheusURL == "" {
+			return nil, errors.New("prometheus URL cannot be empty")
+		}
+		return newHelper(prometheus.Config{
+			Address:      prometheusURL,
+			RoundTripper: newAuthRoundTripper(cfg, prometheus.DefaultRoundTripper),
+		})
+	}
+}
+
+// authRoundTripper decorates every request with the configured
+// authentication and custom headers.
+type authRoundTripper struct {
+	cfg  SourceConfig
+	next http.RoundTripper
+}
+
+func newAuthRoundTripper(cfg SourceConfig, next http.RoundTripper) http.RoundTripper {
+	if cfg.BasicAuthUsername == "" && cfg.BasicAuthPassword == "" && cfg.BearerToken == "" && len(cfg.Headers) == 0 {
+		return next
+	}
+	return &authRoundTripper{cfg: cfg, next: next}
+}
+
+func (rt *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers should not modify the original request.
+	req = req.Clone(req.Context())
+	for k, v := range rt.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if rt.cfg.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+rt.cfg.BearerToken)
+	} else if rt.cfg.BasicAuthUsername != "" || rt.cfg.BasicAuthPassword != "" {
+		req.SetBasicAuth(rt.cfg.BasicAuthUsername, rt.cfg.BasicAuthPassword)
+	}
+	return rt.next.RoundTrip(req)
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package prometheus
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceConfigIsValid(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		require.NoError(t, SourceConfig{}.IsValid())
+	})
+
+	t.Run("bearer and basic auth", func(t *testing.T) {
+		cfg := SourceConfig{
+			Type:              SourceTypePrometheus,
+			BasicAuthUsername: "user",
+			BearerToken:       "token",
+		}
+		require.Error(t, cfg.IsValid())
+	})
+
+	t.Run("file without path", func(t *testing.T) {
+		require.Error(t, SourceConfig{Type: SourceTypeFile}.IsValid())
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		require.Error(t, SourceConfig{Type: "influx"}.IsValid())
+	})
+}
+
+func TestNewSourcePrometheusAuth(t *testing.T) {
+	var req *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"42"]}]}}`))
+	}))
+	defer srv.Close()
+
+	t.Run("bearer token and headers", func(t *testing.T) {
+		source, err := NewSource(SourceConfig{
+			Type:        SourceTypePrometheus,
+			BearerToken: "token",
+			Headers:     map[string]string{"X-Scope-OrgID": "tenant"},
+		}, srv.URL)
+		require.NoError(t, err)
+
+		value, err := source.VectorFirst("up")
+		require.NoError(t, err)
+		require.Equal(t, float64(42), value)
+		require.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+		require.Equal(t, "tenant", req.Header.Get("X-Scope-OrgID"))
+	})
+
+	t.Run("basic auth", func(t *testing.T) {
+		source, err := NewSource(SourceConfig{
+			Type:              SourceTypePrometheus,
+			BasicAuthUsername: "user",
+			BasicAuthPassword: "pass",
+		}, srv.URL)
+		require.NoError(t, err)
+
+		_, err = source.VectorFirst("up")
+		require.NoError(t, err)
+		username, password, ok := req.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "user", username)
+		require.Equal(t, "pass", password)
+	})
+
+	t.Run("missing url", func(t *testing.T) {
+		_, err := NewSource(SourceConfig{Type: SourceTypePrometheus}, "")
+		require.Error(t, err)
+	})
+}
+
+func TestFileSource(t *testing.T) {
+	start := time.Unix(1000, 0)
+	recorded := []RecordedQuery{
+		{
+			Query: "rate(requests[1m])",
+			Result: model.Matrix{
+				&model.SampleStream{
+					Metric: model.Metric{"handler": "getPosts"},
+					Values: []model.SamplePair{
+						{Timestamp: model.TimeFromUnix(1000), Value: 1},
+						{Timestamp: model.TimeFromUnix(1005), Value: 2},
+						{Timestamp: model.TimeFromUnix(1010), Value: 3},
+					},
+				},
+			},
+		},
+	}
+
+	check := func(t *testing.T, source MetricsSource) {
+		t.Helper()
+
+		value, err := source.VectorFirst("rate(requests[1m])")
+		require.NoError(t, err)
+		assert.Equal(t, float64(3), value)
+
+		mat, err := source.Matrix("rate(requests[1m])", start.Add(5*time.Second), start.Add(10*time.Second))
+		require.NoError(t, err)
+		require.Len(t, mat, 1)
+		assert.Equal(t, model.LabelValue("getPosts"), mat[0].Metric["handler"])
+		assert.Equal(t, []model.SamplePair{
+			{Timestamp: model.TimeFromUnix(1005), Value: 2},
+			{Timestamp: model.TimeFromUnix(1010), Value: 3},
+		}, mat[0].Values)
+
+		_, err = source.Matrix("rate(requests[1m])", start.Add(time.Hour), start.Add(2*time.Hour))
+		require.Error(t, err)
+
+		_, err = source.VectorFirst("unknown")
+		require.Error(t, err)
+	}
+
+	t.Run("json", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.json")
+		data, err := json.Marshal(recorded)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0600))
+
+		source, err := NewSource(SourceConfig{Type: SourceTypeFile, FilePath: path}, "")
+		require.NoError(t, err)
+		check(t, source)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.csv")
+		data := "query,labels,timestamp,value\n" +
+			"rate(requests[1m]),handler=getPosts,1010,3\n" +
+			"rate(requests[1m]),handler=getPosts,1000,1\n" +
+			"rate(requests[1m]),handler=getPosts,1005,2\n"
+		require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+
+		source, err := NewFileSource(path)
+		require.NoError(t, err)
+		check(t, source)
+	})
+
+	t.Run("csv missing column", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics.csv")
+		require.NoError(t, os.WriteFile(path, []byte("query,timestamp,value\n"), 0600))
+
+		_, err := NewFileSource(path)
+		require.Error(t, err)
+	})
+}
+
+func TestFakeSource(t *testing.T) {
+	source := NewFakeSource()
+
+	_, err := source.VectorFirst("up")
+	require.Error(t, err)
+
+	source.SetVector("up", 1)
+	value, err := source.VectorFirst("up")
+	require.NoError(t, err)
+	require.Equal(t, float64(1), value)
+
+	source.SetError(errors.New("unavailable"))
+	_, err = source.VectorFirst("up")
+	require.EqualError(t, err, "unavailable")
+}
+
+func TestRecordingSource(t *testing.T) {
+	start := time.Unix(1000, 0)
+	source := NewFileSourceFromRecording([]RecordedQuery{
+		{
+			Query: "rate(requests[1m])",
+			Result: model.Matrix{
+				&model.SampleStream{
+					Metric: model.Metric{"handler": "getPosts"},
+					Values: []model.SamplePair{
+						{Timestamp: model.TimeFromUnix(1000), Value: 1},
+						{Timestamp: model.TimeFromUnix(1010), Value: 3},
+					},
+				},
+			},
+		},
+	})
+
+	rec := NewRecordingSource(source)
+	mat, err := rec.Matrix("rate(requests[1m])", start, start.Add(10*time.Second))
+	require.NoError(t, err)
+	_, err = rec.Matrix("unknown", start, start.Add(10*time.Second))
+	require.Error(t, err)
+	value, err := rec.VectorFirst("rate(requests[1m])")
+	require.NoError(t, err)
+	require.Equal(t, float64(3), value)
+
+	recording := rec.Recording()
+	// Failed queries are not recorded.
+	require.Len(t, recording, 2)
+	require.Equal(t, "rate(requests[1m])", recording[0].Query)
+	require.Equal(t, mat, recording[0].Result)
+
+	// The recording can be replayed.
+	replayed, err := NewFileSourceFromRecording(recording[:1]).Matrix("rate(requests[1m])", start, start.Add(10*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, mat, replayed)
+}
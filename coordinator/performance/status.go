@@ -8,4 +8,6 @@ package performance
 type Status struct {
 	// A boolean value indicating if performance degradation occurred.
 	Alert bool
+	// The value returned by each query, keyed by the query description.
+	Values map[string]float64
 }
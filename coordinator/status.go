@@ -8,6 +8,8 @@ import (
 	"errors"
 	"strings"
 	"time"
+
+	"github.com/mattermost/mattermost-load-test-ng/coordinator/capacity"
 )
 
 // State determines which state a Coordinator is in.
@@ -90,4 +92,7 @@ type Status struct {
 	SupportedUsers     int       // Number of supported users.
 	ActiveBrowserUsers int       // Total browser users.
 	NumBrowserErrors   int64     // Total browser errors.
+	// The capacity curve of the load-test. It's only set once the
+	// coordinator is done.
+	CapacityCurve *capacity.Curve `json:",omitempty"`
 }
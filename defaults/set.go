@@ -91,6 +91,9 @@ func structDefaults(value interface{}) error {
 				return fmt.Errorf("could not set value: %w", err)
 			}
 			field.Set(def)
+		case reflect.Ptr:
+			// Pointers mark optional values, so they are left nil.
+			continue
 		default:
 			return fmt.Errorf("unimplemented struct field type: %s", t.Field(i).Type.Kind())
 		}
@@ -112,4 +112,16 @@ func TestSet(t *testing.T) {
 		assert.NotNil(t, cfg.Strings)
 		assert.Len(t, cfg.Strings, 0)
 	})
+
+	t.Run("should leave pointers nil", func(t *testing.T) {
+		cfg := struct {
+			Optional *struct {
+				String string `default:"text"`
+			}
+		}{}
+
+		err := Set(&cfg)
+		require.NoError(t, err)
+		assert.Nil(t, cfg.Optional)
+	})
 }
@@ -86,6 +86,14 @@ func Validate(value interface{}) error {
 			}
 		case reflect.Chan:
 			return nil
+		case reflect.Ptr:
+			// Pointers mark optional values, which are only validated when set.
+			if field.IsNil() || field.Elem().Kind() != reflect.Struct {
+				continue
+			}
+			if err := Validate(field.Interface()); err != nil {
+				return err
+			}
 		default:
 			return fmt.Errorf("unimplemented struct field type: %s", t.Field(i).Name)
 		}
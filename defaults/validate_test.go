@@ -448,6 +448,19 @@ func (c *testConfig) IsValid() error {
 	return errors.New("some error")
 }
 
+func TestValidatePointer(t *testing.T) {
+	type inner struct {
+		Name string `validate:"notempty"`
+	}
+	type config struct {
+		Optional *inner
+	}
+
+	require.NoError(t, Validate(config{}))
+	require.NoError(t, Validate(config{Optional: &inner{Name: "name"}}))
+	require.Error(t, Validate(config{Optional: &inner{}}))
+}
+
 func TestValidateComparisonConfig(t *testing.T) {
 	type LoadTestType string
 	type DatabaseEngine string
@@ -7,6 +7,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -43,11 +45,25 @@ func (t *Terraform) generateLoadtestAgentConfig() (*loadtest.Config, error) {
 
 	if t.config.UsersFilePath != "" {
 		cfg.UsersConfiguration.UsersFilePath = t.ExpandWithUser(dstUsersFilePath)
+		cfg.UsersConfiguration.UsersFileFormat = usersFileFormat(t.config.UsersFilePath)
 	}
 
 	return cfg, nil
 }
 
+// usersFileFormat returns the format of the given file of user credentials,
+// as told by its extension.
+func usersFileFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return loadtest.UsersFileFormatCSV
+	case ".jsonl":
+		return loadtest.UsersFileFormatJSONL
+	default:
+		return loadtest.UsersFileFormatText
+	}
+}
+
 func (t *Terraform) configureAndRunAgents(extAgent *ssh.ExtAgent) error {
 	var uploadBinary bool
 	var packagePath string
@@ -85,8 +101,13 @@ func (t *Terraform) configureAndRunAgents(extAgent *ssh.ExtAgent) error {
 			return fmt.Errorf("error opening UsersFilePath %q", t.config.UsersFilePath)
 		}
 		scanner := bufio.NewScanner(f)
+		// CSV files need the header line in each of the split files.
+		var header []string
+		if usersFileFormat(t.config.UsersFilePath) == loadtest.UsersFileFormatCSV && scanner.Scan() {
+			header = []string{scanner.Text()}
+		}
 		for range t.output.Agents {
-			splitFiles = append(splitFiles, []string{})
+			splitFiles = append(splitFiles, slices.Clone(header))
 		}
 		i := 0
 		for scanner.Scan() {
@@ -19,23 +19,18 @@ import (
 
 const ltAPIPort = "4000"
 
-// StartCoordinator starts the coordinator in the current load-test deployment.
-func (t *Terraform) StartCoordinator(config *coordinator.Config) error {
-	if err := t.preFlightCheck(); err != nil {
-		return err
-	}
-
-	if err := t.setOutput(); err != nil {
-		return err
-	}
-
-	if len(t.output.Agents) == 0 {
-		return errors.New("there are no agent instances to run the coordinator")
+// generateCoordinatorConfig fills the given coordinator config, or the
+// default one if nil, with the agents and the Prometheus server of the
+// deployment.
+func (t *Terraform) generateCoordinatorConfig(config *coordinator.Config) (*coordinator.Config, error) {
+	if config == nil {
+		var err error
+		config, err = coordinator.ReadConfig("")
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Coordinator resides in the first agent instance
-	coordinatorIP := t.output.Agents[0].GetConnectionIP()
-
 	var loadAgentConfigs []cluster.LoadAgentConfig
 	for _, val := range t.output.Agents {
 		loadAgentConfigs = append(loadAgentConfigs, cluster.LoadAgentConfig{
@@ -54,6 +49,30 @@ func (t *Terraform) StartCoordinator(config *coordinator.Config) error {
 		})
 	}
 
+	config.ClusterConfig.Agents = loadAgentConfigs
+	config.ClusterConfig.BrowserAgents = browserAgentConfigs
+	config.MonitorConfig.PrometheusURL = "http://" + t.output.MetricsServer.GetConnectionIP() + ":9090"
+
+	return config, nil
+}
+
+// StartCoordinator starts the coordinator in the current load-test deployment.
+func (t *Terraform) StartCoordinator(config *coordinator.Config) error {
+	if err := t.preFlightCheck(); err != nil {
+		return err
+	}
+
+	if err := t.setOutput(); err != nil {
+		return err
+	}
+
+	if len(t.output.Agents) == 0 {
+		return errors.New("there are no agent instances to run the coordinator")
+	}
+
+	// Coordinator resides in the first agent instance
+	coordinatorIP := t.output.Agents[0].GetConnectionIP()
+
 	extAgent, err := ssh.NewAgent()
 	if err != nil {
 		return err
@@ -67,15 +86,10 @@ func (t *Terraform) StartCoordinator(config *coordinator.Config) error {
 
 	mlog.Info("Setting up coordinator", mlog.String("ip", coordinatorIP))
 
-	if config == nil {
-		config, err = coordinator.ReadConfig("")
-		if err != nil {
-			return err
-		}
+	config, err = t.generateCoordinatorConfig(config)
+	if err != nil {
+		return err
 	}
-	config.ClusterConfig.Agents = loadAgentConfigs
-	config.ClusterConfig.BrowserAgents = browserAgentConfigs
-	config.MonitorConfig.PrometheusURL = "http://" + t.output.MetricsServer.GetConnectionIP() + ":9090"
 
 	// TODO: consider removing this. Config is passed dynamically when creating
 	// a coordinator resource through the API.
@@ -253,26 +253,7 @@ func (t *Terraform) Create(extAgent *ssh.ExtAgent, initData bool) error {
 	}
 
 	if t.output.HasAppServers() {
-		var siteURL string
-		switch {
-		// SiteURL defined, multiple app nodes: we use SiteURL, since that points to the proxy itself
-		case t.config.SiteURL != "" && t.output.HasProxy():
-			siteURL = t.config.ServerScheme + "://" + t.config.SiteURL
-		// SiteURL defined, single app node: we use SiteURL plus the port, since SiteURL points to the app node (which is listening in 8065)
-		case t.config.SiteURL != "":
-			siteURL = t.config.ServerScheme + "://" + t.config.SiteURL + ":8065"
-		// SiteURL not defined, multiple app nodes: we use the proxy's public DNS
-		case t.output.HasProxy():
-			// This case will only succeed if siteURL is empty.
-			// And it's an error to have siteURL empty and set multiple proxies. (see (c *Config) validateProxyConfig)
-			// So we can safely take the DNS of the first entry.
-			siteURL = t.config.ServerScheme + "://" + t.output.Proxies[0].PublicDNS
-		// SiteURL not defined, single app node: we use the app node's public DNS plus port
-		case t.config.ServerURL != "":
-			siteURL = t.config.ServerScheme + "://" + t.config.ServerURL
-		default:
-			siteURL = t.config.ServerScheme + "://" + t.output.Instances[0].PublicDNS + ":8065"
-		}
+		siteURL := t.getSiteURL()
 
 		// Updating the config.json for each instance of app server
 		if err := t.setupAppServers(extAgent, uploadBinary, uploadRelease, uploadPath, siteURL); err != nil {
@@ -868,6 +849,29 @@ func genNginxConfig(deploymentConfig *deployment.Config) (string, error) {
 	return fillConfigTemplate(nginxConfigTmpl, data)
 }
 
+// genNginxSiteConfig generates the nginx site config balancing the load across
+// the given app servers. A larger cache is used if largeCache is true.
+func genNginxSiteConfig(instances []Instance, largeCache bool) (string, error) {
+	backends := ""
+	for _, addr := range instances {
+		backends += "server " + addr.PrivateIP + ":8065 max_fails=0;\n"
+	}
+
+	cacheObjects := "10m"
+	cacheSize := "3g"
+	if largeCache {
+		cacheObjects = "50m"
+		cacheSize = "16g" // Ideally we'd like half of the total server mem. But the mem consumption rarely exceeds 10G
+		// from my tests. So there's no point stretching it further.
+	}
+
+	return fillConfigTemplate(nginxSiteConfigTmpl, map[string]any{
+		"backends":     backends,
+		"cacheObjects": cacheObjects,
+		"cacheSize":    cacheSize,
+	})
+}
+
 func (t *Terraform) getProxyInstanceInfo() (*types.InstanceTypeInfo, error) {
 	cfg, err := t.GetAWSConfig()
 	if err != nil {
@@ -914,37 +918,20 @@ func (t *Terraform) setupProxyServer(extAgent *ssh.ExtAgent, instance Instance)
 		// Upload service file
 		mlog.Info("Uploading nginx config", mlog.String("host", ip))
 
-		backends := ""
-		for _, addr := range t.output.Instances {
-			backends += "server " + addr.PrivateIP + ":8065 max_fails=0;\n"
-		}
-
-		cacheObjects := "10m"
-		cacheSize := "3g"
-
 		info, err := t.getProxyInstanceInfo()
 		if err != nil {
 			mlog.Error("Error while getting proxy info", mlog.Err(err))
 			return
 		}
 
-		if *info.MemoryInfo.SizeInMiB >= 32768 { // 32GiB (Usually of instance classes >=4xlarge)
-			cacheObjects = "50m"
-			cacheSize = "16g" // Ideally we'd like half of the total server mem. But the mem consumption rarely exceeds 10G
-			// from my tests. So there's no point stretching it further.
-		}
-
 		nginxConfig, err := genNginxConfig(t.config)
 		if err != nil {
 			mlog.Error("Failed to generate nginx config", mlog.Err(err))
 			return
 		}
 
-		nginxSiteConfig, err := fillConfigTemplate(nginxSiteConfigTmpl, map[string]any{
-			"backends":     backends,
-			"cacheObjects": cacheObjects,
-			"cacheSize":    cacheSize,
-		})
+		// 32GiB (Usually of instance classes >=4xlarge)
+		nginxSiteConfig, err := genNginxSiteConfig(t.output.Instances, *info.MemoryInfo.SizeInMiB >= 32768)
 		if err != nil {
 			mlog.Error("Failed to generate nginx site config", mlog.Err(err))
 			return
@@ -1056,7 +1043,58 @@ func (t *Terraform) updatePostgresSettings(extAgent *ssh.ExtAgent) error {
 	return nil
 }
 
+// getSiteURL returns the site URL of the Mattermost server, as set in the
+// config of the app servers.
+func (t *Terraform) getSiteURL() string {
+	switch {
+	// SiteURL defined, multiple app nodes: we use SiteURL, since that points to the proxy itself
+	case t.config.SiteURL != "" && t.output.HasProxy():
+		return t.config.ServerScheme + "://" + t.config.SiteURL
+	// SiteURL defined, single app node: we use SiteURL plus the port, since SiteURL points to the app node (which is listening in 8065)
+	case t.config.SiteURL != "":
+		return t.config.ServerScheme + "://" + t.config.SiteURL + ":8065"
+	// SiteURL not defined, multiple app nodes: we use the proxy's public DNS
+	case t.output.HasProxy():
+		// This case will only succeed if siteURL is empty.
+		// And it's an error to have siteURL empty and set multiple proxies. (see (c *Config) validateProxyConfig)
+		// So we can safely take the DNS of the first entry.
+		return t.config.ServerScheme + "://" + t.output.Proxies[0].PublicDNS
+	// SiteURL not defined, single app node: we use the app node's public DNS plus port
+	case t.config.ServerURL != "":
+		return t.config.ServerScheme + "://" + t.config.ServerURL
+	default:
+		return t.config.ServerScheme + "://" + t.output.Instances[0].PublicDNS + ":8065"
+	}
+}
+
 func (t *Terraform) updateAppConfig(siteURL string, sshc *ssh.Client, jobServerEnabled bool) error {
+	cfg, err := t.genAppConfig(siteURL, jobServerEnabled)
+	if err != nil {
+		return err
+	}
+
+	if t.output.HasKeycloak() {
+		if err := t.setupKeycloakAppConfig(sshc, cfg); err != nil {
+			return fmt.Errorf("error setting up Keycloak config: %w", err)
+		}
+	}
+
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error in marshalling config: %w", err)
+	}
+
+	if out, err := sshc.Upload(bytes.NewReader(b), "/opt/mattermost/config/config.json", false); err != nil {
+		return fmt.Errorf("error uploading config.json: output: %s,  error: %w", out, err)
+	}
+
+	return nil
+}
+
+// genAppConfig generates the config of the app servers, with the
+// MattermostConfigPatchFile applied. Keycloak settings are not included since
+// they depend on the running Keycloak server.
+func (t *Terraform) genAppConfig(siteURL string, jobServerEnabled bool) (*model.Config, error) {
 	var clusterDSN, driverName string
 	var readerDSN []string
 
@@ -1068,7 +1106,7 @@ func (t *Terraform) updateAppConfig(siteURL string, sshc *ssh.Client, jobServerE
 		var err error
 		clusterDSN, err = t.getClusterDSN()
 		if err != nil {
-			return fmt.Errorf("could not update config: %w", err)
+			return nil, fmt.Errorf("could not update config: %w", err)
 		}
 
 		switch t.config.TerraformDBSettings.InstanceEngine {
@@ -1204,23 +1242,17 @@ func (t *Terraform) updateAppConfig(siteURL string, sshc *ssh.Client, jobServerE
 	if t.config.MattermostConfigPatchFile != "" {
 		data, err := os.ReadFile(t.config.MattermostConfigPatchFile)
 		if err != nil {
-			return fmt.Errorf("error reading MattermostConfigPatchFile: %w", err)
+			return nil, fmt.Errorf("error reading MattermostConfigPatchFile: %w", err)
 		}
 
 		var patch model.Config
 		if err := json.Unmarshal(data, &patch); err != nil {
-			return fmt.Errorf("error parsing patch config: %w", err)
+			return nil, fmt.Errorf("error parsing patch config: %w", err)
 		}
 
 		cfg, err = config.Merge(cfg, &patch, nil)
 		if err != nil {
-			return fmt.Errorf("error patching config: %w", err)
-		}
-	}
-
-	if t.output.HasKeycloak() {
-		if err := t.setupKeycloakAppConfig(sshc, cfg); err != nil {
-			return fmt.Errorf("error setting up Keycloak config: %w", err)
+			return nil, fmt.Errorf("error patching config: %w", err)
 		}
 	}
 
@@ -1250,16 +1282,7 @@ func (t *Terraform) updateAppConfig(siteURL string, sshc *ssh.Client, jobServerE
 		cfg.LdapSettings.ReAddRemovedMembers = model.NewPointer(true)
 	}
 
-	b, err := json.MarshalIndent(cfg, "", "  ")
-	if err != nil {
-		return fmt.Errorf("error in marshalling config: %w", err)
-	}
-
-	if out, err := sshc.Upload(bytes.NewReader(b), "/opt/mattermost/config/config.json", false); err != nil {
-		return fmt.Errorf("error uploading config.json: output: %s,  error: %w", out, err)
-	}
-
-	return nil
+	return cfg, nil
 }
 
 func (t *Terraform) preFlightCheck() error {
@@ -0,0 +1,300 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-load-test-ng/deployment"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest"
+)
+
+// Names of the files rendered by a plan.
+const (
+	PlanTerraformVarsFile     = "terraform.vars"
+	PlanNginxConfigFile       = "nginx.conf"
+	PlanNginxSiteConfigFile   = "nginx-site.conf"
+	PlanMattermostConfigFile  = "mattermost-config.json"
+	PlanAgentConfigFile       = "agent-config.json"
+	PlanCoordinatorConfigFile = "coordinator-config.json"
+)
+
+// PlanResource describes a group of resources that would be created.
+type PlanResource struct {
+	Name  string
+	Count int
+	Type  string
+}
+
+// Plan holds the artifacts that would be generated when creating a deployment,
+// so that they can be reviewed without provisioning anything.
+type Plan struct {
+	// The resources that would be created.
+	Resources []PlanResource
+	// The placeholder output the artifacts were generated from.
+	Output *Output
+	// The rendered files, keyed by file name.
+	Files map[string][]byte
+}
+
+// NewPlan renders the artifacts generated when creating a deployment with the
+// given config. Neither AWS nor SSH access is needed. Since the addresses
+// of the instances are only known once they are created, placeholder
+// hostnames under the reserved .invalid domain are used in their place.
+func NewPlan(cfg deployment.Config) (*Plan, error) {
+	t := &Terraform{
+		config: &cfg,
+		output: newPlanOutput(&cfg),
+	}
+
+	p := &Plan{
+		Resources: planResources(&cfg),
+		Output:    t.output,
+		Files:     make(map[string][]byte),
+	}
+
+	var vars strings.Builder
+	params := t.getParams()
+	for i := 1; i < len(params); i += 2 {
+		vars.WriteString(params[i] + "\n")
+	}
+	p.Files[PlanTerraformVarsFile] = []byte(vars.String())
+
+	if t.output.HasProxy() {
+		nginxConfig, err := genNginxConfig(t.config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate nginx config: %w", err)
+		}
+		p.Files[PlanNginxConfigFile] = []byte(nginxConfig)
+
+		// The cache size depends on the memory of the proxy instance, which
+		// can't be known without querying AWS, so the default one is used.
+		nginxSiteConfig, err := genNginxSiteConfig(t.output.Instances, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate nginx site config: %w", err)
+		}
+		p.Files[PlanNginxSiteConfigFile] = []byte(nginxSiteConfig)
+	}
+
+	if t.output.HasAppServers() {
+		appConfig, err := t.genAppConfig(t.getSiteURL(), !t.output.HasJobServer())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate Mattermost config: %w", err)
+		}
+		if err := p.addJSON(PlanMattermostConfigFile, appConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.output.HasAgents() {
+		var agentConfig *loadtest.Config
+		var err error
+		if t.output.HasAppServers() {
+			agentConfig, err = t.generateLoadtestAgentConfig()
+		} else {
+			agentConfig, err = loadtest.ReadConfig("")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate agent config: %w", err)
+		}
+		if err := p.addJSON(PlanAgentConfigFile, agentConfig); err != nil {
+			return nil, err
+		}
+
+		coordinatorConfig, err := t.generateCoordinatorConfig(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate coordinator config: %w", err)
+		}
+		if err := p.addJSON(PlanCoordinatorConfigFile, coordinatorConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Plan) addJSON(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	p.Files[name] = data
+	return nil
+}
+
+// FileNames returns the names of the rendered files, sorted.
+func (p *Plan) FileNames() []string {
+	names := make([]string, 0, len(p.Files))
+	for name := range p.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Write writes the rendered files to the given directory, creating it if
+// needed.
+func (p *Plan) Write(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create plan directory: %w", err)
+	}
+	for name, data := range p.Files {
+		// Files may hold credentials, such as the database password.
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Summary returns a human-readable summary of the plan.
+func (p *Plan) Summary() string {
+	var sb strings.Builder
+	sb.WriteString("Resources to create:\n")
+	for _, r := range p.Resources {
+		if r.Type != "" {
+			fmt.Fprintf(&sb, " - %s: %d (%s)\n", r.Name, r.Count, r.Type)
+		} else {
+			fmt.Fprintf(&sb, " - %s: %d\n", r.Name, r.Count)
+		}
+	}
+	sb.WriteString("Rendered files:\n")
+	for _, name := range p.FileNames() {
+		fmt.Fprintf(&sb, " - %s\n", name)
+	}
+	return sb.String()
+}
+
+// planResources returns the resources that would be created with the given
+// config, skipping those not being created at all.
+func planResources(cfg *deployment.Config) []PlanResource {
+	var dbCount int
+	if cfg.AppInstanceCount > 0 && cfg.TerraformDBSettings.ClusterIdentifier == "" {
+		dbCount = cfg.TerraformDBSettings.InstanceCount
+	}
+
+	all := []PlanResource{
+		{Name: "App servers", Count: cfg.AppInstanceCount, Type: cfg.AppInstanceType},
+		{Name: "Agents", Count: cfg.AgentInstanceCount, Type: cfg.AgentInstanceType},
+		{Name: "Browser agents", Count: cfg.BrowserAgentInstanceCount, Type: cfg.BrowserAgentInstanceType},
+		{Name: "Proxies", Count: cfg.ProxyInstanceCount, Type: cfg.ProxyInstanceType},
+		{Name: "Job servers", Count: cfg.JobServerSettings.InstanceCount, Type: cfg.JobServerSettings.InstanceType},
+		{Name: "Metrics servers", Count: boolToInt(cfg.EnableMetricsInstance), Type: cfg.MetricsInstanceType},
+		{Name: "DB instances", Count: dbCount, Type: cfg.TerraformDBSettings.InstanceType},
+		{Name: "Elasticsearch nodes", Count: cfg.ElasticSearchSettings.InstanceCount, Type: cfg.ElasticSearchSettings.InstanceType},
+		{Name: "Redis servers", Count: boolToInt(cfg.RedisSettings.Enabled), Type: cfg.RedisSettings.NodeType},
+		{Name: "Keycloak servers", Count: boolToInt(cfg.ExternalAuthProviderSettings.Enabled), Type: cfg.ExternalAuthProviderSettings.InstanceType},
+		{Name: "OpenLDAP servers", Count: boolToInt(cfg.OpenLDAPSettings.Enabled), Type: cfg.OpenLDAPSettings.InstanceType},
+		{Name: "EFS file systems", Count: boolToInt(cfg.EnableEFS)},
+	}
+
+	var resources []PlanResource
+	for _, r := range all {
+		if r.Count > 0 {
+			resources = append(resources, r)
+		}
+	}
+	return resources
+}
+
+// newPlanOutput returns the output a deployment created with the given config
+// would have, with placeholder values for anything only known once created.
+func newPlanOutput(cfg *deployment.Config) *Output {
+	instance := func(name string) Instance {
+		inst := Instance{
+			PrivateIP:  name + ".private.invalid",
+			PublicIP:   name + ".public.invalid",
+			PrivateDNS: name + ".private.invalid",
+			PublicDNS:  name + ".public.invalid",
+			Tags:       Tags{Name: name},
+		}
+		inst.SetConnectionType(cfg.ConnectionType)
+		return inst
+	}
+	instances := func(role string, count int) []Instance {
+		var insts []Instance
+		for i := 0; i < count; i++ {
+			insts = append(insts, instance(fmt.Sprintf("%s-%s-%d", cfg.ClusterName, role, i)))
+		}
+		return insts
+	}
+
+	o := &Output{
+		ClusterName:   cfg.ClusterName,
+		AMIUser:       cfg.AWSAMIUser,
+		Proxies:       instances("proxy", cfg.ProxyInstanceCount),
+		Instances:     instances("app", cfg.AppInstanceCount),
+		Agents:        instances("agent", cfg.AgentInstanceCount),
+		BrowserAgents: instances("browser-agent", cfg.BrowserAgentInstanceCount),
+		JobServers:    instances("job-server", cfg.JobServerSettings.InstanceCount),
+	}
+
+	if cfg.EnableMetricsInstance {
+		o.MetricsServer = instance(cfg.ClusterName + "-metrics")
+	}
+
+	if cfg.AppInstanceCount > 0 && cfg.TerraformDBSettings.InstanceCount > 0 {
+		o.DBCluster.ClusterIdentifier = cfg.ClusterName + "-db-cluster"
+		if cfg.TerraformDBSettings.ClusterIdentifier != "" {
+			o.DBCluster.ClusterIdentifier = cfg.TerraformDBSettings.ClusterIdentifier
+		}
+		for i := 0; i < cfg.TerraformDBSettings.InstanceCount; i++ {
+			id := fmt.Sprintf("%s-db-%d", cfg.ClusterName, i)
+			o.DBCluster.Instances = append(o.DBCluster.Instances, DBInstance{
+				DBIdentifier: id,
+				Endpoint:     id + ".invalid",
+				IsWriter:     i == 0,
+			})
+		}
+	}
+
+	if cfg.ElasticSearchSettings.InstanceCount > 0 {
+		o.ElasticSearchServer = ElasticSearchDomain{
+			Endpoint: cfg.ClusterName + "-es_server.invalid",
+			Tags:     Tags{Name: cfg.ClusterName + "-es_server"},
+		}
+	}
+
+	if cfg.RedisSettings.Enabled {
+		o.RedisServer = RedisInstance{
+			Address: cfg.ClusterName + "-redis.invalid",
+			Port:    6379,
+		}
+	}
+
+	if cfg.ExternalAuthProviderSettings.Enabled {
+		o.KeycloakServer = instance(cfg.ClusterName + "-keycloak")
+	}
+
+	if cfg.OpenLDAPSettings.Enabled {
+		o.OpenLDAPServer = instance(cfg.ClusterName + "-openldap")
+	}
+
+	if cfg.EnableEFS {
+		o.EFSAccessPoint = EFSAccessPoint{
+			Id:           cfg.ClusterName + "-shared-dir",
+			FileSystemId: cfg.ClusterName + "-shared-fs",
+		}
+	}
+
+	// Mirrors the create_s3_bucket condition in cluster.tf.
+	if cfg.AppInstanceCount > 1 && cfg.ExternalBucketSettings.AmazonS3Bucket == "" && !cfg.EnableEFS {
+		o.S3Bucket = S3Bucket{Id: cfg.ClusterName + ".s3bucket", Region: cfg.AWSRegion}
+		o.S3Key = IAMAccess{Id: "placeholder", Secret: "placeholder"}
+	}
+
+	return o
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
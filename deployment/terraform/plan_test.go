@@ -0,0 +1,92 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package terraform
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattermost/mattermost-load-test-ng/coordinator"
+	"github.com/mattermost/mattermost-load-test-ng/defaults"
+	"github.com/mattermost/mattermost-load-test-ng/deployment"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan(t *testing.T) {
+	var cfg deployment.Config
+	require.NoError(t, defaults.Set(&cfg))
+	cfg.ClusterName = "lt"
+	cfg.AppInstanceCount = 2
+	cfg.ProxyInstanceCount = 1
+	cfg.AgentInstanceCount = 2
+	cfg.TerraformDBSettings.InstanceCount = 2
+	cfg.TerraformDBSettings.InstanceEngine = "aurora-postgresql"
+	cfg.SiteURL = "ltserver"
+
+	t.Run("full deployment", func(t *testing.T) {
+		p, err := NewPlan(cfg)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{
+			PlanAgentConfigFile,
+			PlanCoordinatorConfigFile,
+			PlanMattermostConfigFile,
+			PlanNginxSiteConfigFile,
+			PlanNginxConfigFile,
+			PlanTerraformVarsFile,
+		}, p.FileNames())
+
+		require.Contains(t, string(p.Files[PlanTerraformVarsFile]), "app_instance_count=2\n")
+		require.Contains(t, string(p.Files[PlanNginxSiteConfigFile]), "server lt-app-1.private.invalid:8065 max_fails=0;")
+
+		var appConfig model.Config
+		require.NoError(t, json.Unmarshal(p.Files[PlanMattermostConfigFile], &appConfig))
+		require.Equal(t, "http://ltserver", *appConfig.ServiceSettings.SiteURL)
+		require.Contains(t, *appConfig.SqlSettings.DataSource, "@lt-db-0.invalid/")
+		require.Len(t, appConfig.SqlSettings.DataSourceReplicas, 1)
+		require.Equal(t, "amazons3", *appConfig.FileSettings.DriverName)
+
+		var coordConfig coordinator.Config
+		require.NoError(t, json.Unmarshal(p.Files[PlanCoordinatorConfigFile], &coordConfig))
+		require.Len(t, coordConfig.ClusterConfig.Agents, 2)
+		require.Equal(t, "lt-agent-1", coordConfig.ClusterConfig.Agents[1].Id)
+
+		require.Contains(t, p.Summary(), " - App servers: 2 ("+cfg.AppInstanceType+")\n")
+		require.Contains(t, p.Summary(), " - "+PlanMattermostConfigFile+"\n")
+
+		dir := filepath.Join(t.TempDir(), "plan")
+		require.NoError(t, p.Write(dir))
+		data, err := os.ReadFile(filepath.Join(dir, PlanNginxConfigFile))
+		require.NoError(t, err)
+		require.Equal(t, p.Files[PlanNginxConfigFile], data)
+	})
+
+	t.Run("agents only", func(t *testing.T) {
+		cfg := cfg
+		cfg.AppInstanceCount = 0
+		cfg.ProxyInstanceCount = 0
+		cfg.ServerURL = "mm.example.com"
+
+		p, err := NewPlan(cfg)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			PlanAgentConfigFile,
+			PlanCoordinatorConfigFile,
+			PlanTerraformVarsFile,
+		}, p.FileNames())
+		require.NotContains(t, p.Summary(), "DB instances")
+	})
+
+	t.Run("missing config patch", func(t *testing.T) {
+		cfg := cfg
+		cfg.MattermostConfigPatchFile = filepath.Join(t.TempDir(), "missing.json")
+
+		_, err := NewPlan(cfg)
+		require.ErrorContains(t, err, "error reading MattermostConfigPatchFile")
+	})
+}
@@ -5,7 +5,9 @@ package loadtest
 
 import (
 	"errors"
+	"fmt"
 	"math"
+	"net"
 
 	"github.com/mattermost/mattermost-load-test-ng/defaults"
 	"github.com/mattermost/mattermost-load-test-ng/logger"
@@ -21,18 +23,126 @@ type ConnectionConfiguration struct {
 	AdminEmail string `default:"sysadmin@sample.mattermost.com" validate:"email"`
 	// Password of the system admin.
 	AdminPassword string `default:"Sys@dmin-sample1" validate:"notempty"`
+	// Settings of the HTTP client used by the users.
+	Transport TransportConfiguration
+	// An optional list of local IP addresses to make the connections of the
+	// users from. Users are spread evenly across them.
+	SourceAddresses []string
+	// An optional list of instance URLs to connect the users to, in place of
+	// ServerURL and WebSocketURL, e.g. to reach the app nodes directly instead
+	// of going through the proxy. Users are spread evenly across them.
+	Targets []TargetConfiguration
+}
+
+// TargetConfiguration holds the URLs of an instance users connect to.
+type TargetConfiguration struct {
+	// URL of the instance to connect to.
+	ServerURL string `validate:"url"`
+	// WebSocket URL of the instance to connect to.
+	WebSocketURL string `validate:"url"`
+}
+
+// IsValid reports whether a given ConnectionConfiguration is valid or not.
+// Returns an error if the validation fails.
+func (c *ConnectionConfiguration) IsValid() error {
+	for _, addr := range c.SourceAddresses {
+		if net.ParseIP(addr) == nil {
+			return fmt.Errorf("invalid source address %q", addr)
+		}
+	}
+	return c.Transport.IsValid()
+}
+
+// SourceAddress returns the local IP address the connections of the user with
+// the given id should be made from. An empty string is returned if no source
+// addresses are configured.
+func (c *ConnectionConfiguration) SourceAddress(id int) string {
+	if len(c.SourceAddresses) == 0 {
+		return ""
+	}
+	return c.SourceAddresses[id%len(c.SourceAddresses)]
+}
+
+// Target returns the URLs of the instance the user with the given id should
+// connect to.
+func (c *ConnectionConfiguration) Target(id int) TargetConfiguration {
+	if len(c.Targets) == 0 {
+		return TargetConfiguration{
+			ServerURL:    c.ServerURL,
+			WebSocketURL: c.WebSocketURL,
+		}
+	}
+	return c.Targets[id%len(c.Targets)]
+}
+
+// Possible connection pools of the HTTP client.
+const (
+	// All users share the same pool of connections.
+	ConnectionPoolShared = "shared"
+	// Each user has its own pool of connections.
+	ConnectionPoolUser = "user"
+)
+
+// TransportConfiguration holds information about the HTTP client used by the
+// users to connect to the instance.
+type TransportConfiguration struct {
+	// The maximum amount of time (in milliseconds) to wait for a connection
+	// to be established.
+	DialTimeoutMs int `default:"1000" validate:"range:(0,]"`
+	// The maximum amount of time (in milliseconds) to wait for a TLS
+	// handshake.
+	TLSHandshakeTimeoutMs int `default:"1000" validate:"range:(0,]"`
+	// The maximum amount of time (in milliseconds) for a request to complete,
+	// including reading the response body.
+	RequestTimeoutMs int `default:"5000" validate:"range:(0,]"`
+	// The maximum amount of time (in milliseconds) an idle connection is kept
+	// open for. Zero means no limit.
+	IdleConnTimeoutMs int `default:"90000" validate:"range:[0,]"`
+	// Whether to use HTTP/2 when the server supports it.
+	EnableHTTP2 bool `default:"false"`
+	// The path to a PEM-encoded bundle of CA certificates used to verify the
+	// server, in addition to the system ones.
+	CACertFile string `default:"" validate:"empty|file"`
+	// The paths to a PEM-encoded client certificate and its key, presented
+	// to the server for mutual TLS.
+	ClientCertFile string `default:"" validate:"empty|file"`
+	ClientKeyFile  string `default:"" validate:"empty|file"`
+	// Whether to skip the verification of the server certificate.
+	InsecureSkipVerify bool `default:"false"`
+	// Whether users share the same pool of connections or each has its own.
+	// Possible values:
+	//   ConnectionPoolShared
+	//   ConnectionPoolUser
+	ConnectionPool string `default:"shared" validate:"oneof:{shared,user}"`
+	// Whether users back off when rate limited by the server, as instructed
+	// by the Retry-After and X-Ratelimit-* response headers.
+	HonorRateLimits bool `default:"false"`
+	// The maximum amount of time (in milliseconds) users back off for when
+	// rate limited.
+	MaxRateLimitBackoffMs int `default:"60000" validate:"range:(0,]"`
+}
+
+// IsValid reports whether a given TransportConfiguration is valid or not.
+// Returns an error if the validation fails.
+func (c *TransportConfiguration) IsValid() error {
+	if (c.ClientCertFile == "") != (c.ClientKeyFile == "") {
+		return errors.New("ClientCertFile and ClientKeyFile should be set together")
+	}
+	return nil
 }
 
 // userControllerType describes the type of a UserController.
 type userControllerType string
 
-// Available UserController implementations.
+// Built-in UserController implementations. Additional ones can be registered
+// through control.RegisterController.
 const (
 	UserControllerSimple     userControllerType = "simple"
 	UserControllerSimulative                    = "simulative"
 	UserControllerNoop                          = "noop"
 	UserControllerGenerative                    = "generative"
 	UserControllerCluster                       = "cluster"
+	UserControllerBot                           = "bot"
 )
 
 // RatesDistribution maps a rate to a percentage of controllers that should run
@@ -45,25 +155,74 @@ type RatesDistribution struct {
 // UserControllerConfiguration holds information about the UserController to
 // run during a load-test.
 type UserControllerConfiguration struct {
-	// The type of the UserController to run.
-	// Possible values:
+	// The type of the UserController to run, as registered in the control
+	// package. Built-in values:
 	//   UserControllerSimple - A simple version of a controller.
 	//   UserControllerSimulative - A more realistic controller.
 	//   UserControllerNoop
 	//   UserControllerGenerative - A controller used to generate data.
-	Type userControllerType `default:"simulative" validate:"oneof:{simple,simulative,noop,cluster,generative,browser}"`
+	//   UserControllerBot - A controller simulating bots and integrations.
+	Type userControllerType `default:"simulative" validate:"notempty"`
 	// A distribution of rate multipliers that will affect the speed at which user actions are
 	// executed by the UserController.
 	// A Rate of < 1.0 will run actions at a faster pace.
 	// A Rate of 1.0 will run actions at the default pace.
 	// A Rate > 1.0 will run actions at a slower pace.
 	RatesDistribution []RatesDistribution `default_len:"1"`
+	// An optional weighted list of populations of users, each run by its own
+	// type of UserController. If set, Type is ignored and each new user is
+	// assigned to one of the populations.
+	Populations []ControllerPopulation
 	// An optional MM server version to use when running actions (e.g. `5.30.0`).
 	// This value overrides the actual server version. If left empty,
 	// the one returned by the server is used instead.
 	ServerVersion string
 }
 
+// ControllerPopulation describes a group of users run by the same type of
+// UserController.
+type ControllerPopulation struct {
+	// A friendly name for the population, used when reporting its status.
+	// Defaults to the type of the UserController.
+	Name string
+	// The type of the UserController to run for the users of the population.
+	Type userControllerType `validate:"notempty"`
+	// The percentage of users assigned to the population.
+	Percentage float64 `validate:"range:(0,1]"`
+	// Optional overrides of the config of the UserController, with the same
+	// structure as its config file. Settings which are not overridden are
+	// taken from the default config of the UserController.
+	ControllerConfig map[string]any
+}
+
+// GetName returns the name of the population, defaulting to the type of its
+// UserController.
+func (p ControllerPopulation) GetName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return string(p.Type)
+}
+
+// goldenRatioConjugate is used to spread users across populations.
+const goldenRatioConjugate = 0.6180339887498949
+
+// PickPopulation returns the index of the population the user with the given
+// id is assigned to. The same id is always assigned to the same population.
+// Consecutive ids are spread through a low-discrepancy sequence so that the
+// size of each population matches its percentage even with few users.
+func (ucc *UserControllerConfiguration) PickPopulation(id int) int {
+	x := math.Mod(float64(id)*goldenRatioConjugate, 1)
+	var sum float64
+	for i, p := range ucc.Populations {
+		sum += p.Percentage
+		if x < sum {
+			return i
+		}
+	}
+	return len(ucc.Populations) - 1
+}
+
 // IsValid reports whether a given UserControllerConfiguration is valid or not.
 // Returns an error if the validation fails.
 func (ucc *UserControllerConfiguration) IsValid() error {
@@ -74,6 +233,20 @@ func (ucc *UserControllerConfiguration) IsValid() error {
 	if len(ucc.RatesDistribution) > 0 && sum != 1 {
 		return errors.New("Percentages in RatesDistribution should sum to 1")
 	}
+
+	sum = 0
+	names := make(map[string]bool, len(ucc.Populations))
+	for _, p := range ucc.Populations {
+		sum += p.Percentage
+		if names[p.GetName()] {
+			return fmt.Errorf("duplicate population name %q", p.GetName())
+		}
+		names[p.GetName()] = true
+	}
+	if len(ucc.Populations) > 0 && (math.Round(sum*100)/100) != 1 {
+		return errors.New("Percentages in Populations should sum to 1")
+	}
+
 	return nil
 }
 
@@ -128,6 +301,15 @@ type UsersConfiguration struct {
 	// wants to login using a different set of credentials. This is helpful during
 	// LDAP logins.
 	UsersFilePath string
+	// The format of the file at UsersFilePath.
+	// Possible values:
+	//   UsersFileFormatText
+	//   UsersFileFormatCSV
+	//   UsersFileFormatJSONL
+	UsersFileFormat string `default:"text" validate:"oneof:{text,csv,jsonl}"`
+	// The policy of the passwords given to the users whose credentials are
+	// generated rather than read from UsersFilePath.
+	PasswordPolicy PasswordPolicyConfiguration
 	// The number of initial users the load-test should start with.
 	InitialActiveUsers int `default:"0" validate:"range:[0,$MaxActiveUsers]"`
 	// The maximum number of users that can be simulated by a single load-test
@@ -139,6 +321,76 @@ type UsersConfiguration struct {
 	AvgSessionsPerUser int `default:"1" validate:"range:[1,]"`
 	// The percentage of users generated that will be system admins
 	PercentOfUsersAreAdmin float64 `default:"0.0005" validate:"range:[0,1]"`
+	// The maximum number of items of each type kept in memory for each user.
+	StoreLimits StoreLimitsConfiguration
+}
+
+// Possible formats of the file at UsersFilePath.
+const (
+	// One user per line, with the email and the password separated by a
+	// space. The email can be prefixed by the type of authentication, e.g.
+	// "openid:user@example.com".
+	UsersFileFormatText = "text"
+	// Comma separated values, with a header naming the columns.
+	UsersFileFormatCSV = "csv"
+	// One JSON object per line.
+	UsersFileFormatJSONL = "jsonl"
+)
+
+// PasswordPolicyConfiguration holds the policy of the passwords given to the
+// generated users.
+type PasswordPolicyConfiguration struct {
+	// A password shared by all the generated users. When empty, each user is
+	// given its own password, derived from its username, according to the
+	// rest of the policy.
+	FixedPassword string `default:"testPass123$"`
+	// The length of the passwords.
+	Length int `default:"12" validate:"range:[5,72]"`
+	// Whether the passwords include uppercase letters, numbers and symbols,
+	// in addition to lowercase letters.
+	RequireUppercase bool `default:"true"`
+	RequireNumber    bool `default:"true"`
+	RequireSymbol    bool `default:"true"`
+}
+
+// StoreLimitsConfiguration holds the maximum number of items of each type kept
+// in the store of a user. Once a limit is reached, the oldest items are
+// evicted to make room for the new ones, trading the memory used by the agent
+// against how much of the instance the users know about.
+type StoreLimitsConfiguration struct {
+	MaxStoredPosts          int `default:"250" validate:"range:(0,]"`
+	MaxStoredUsers          int `default:"500" validate:"range:(0,]"`
+	MaxStoredChannelMembers int `default:"500" validate:"range:(0,]"`
+	MaxStoredStatuses       int `default:"500" validate:"range:(0,]"`
+	MaxStoredThreads        int `default:"250" validate:"range:(0,]"`
+	MaxStoredReactions      int `default:"10" validate:"range:(0,]"`
+	MaxStoredChannels       int `default:"2000" validate:"range:(0,]"`
+	MaxStoredDrafts         int `default:"100" validate:"range:(0,]"`
+	MaxStoredBookmarks      int `default:"100" validate:"range:(0,]"`
+	MaxStoredScheduledPosts int `default:"100" validate:"range:(0,]"`
+}
+
+// WebhooksConfiguration holds information about the receiver of the outgoing
+// webhooks registered by the users.
+type WebhooksConfiguration struct {
+	// The local address the agent listens on for outgoing webhooks delivered
+	// by the server, e.g. ":4010". Leaving it empty disables the receiver,
+	// and with it the creation of outgoing webhooks.
+	ReceiverListenAddress string `default:""`
+	// The URL the server delivers the outgoing webhooks to. It should reach
+	// the receiver from the server, e.g. "http://10.0.0.10:4010".
+	// Agents created by the coordinator share the same value, so all
+	// deliveries go to the receiver of a single agent.
+	ReceiverURL string `default:"" validate:"empty|url"`
+}
+
+// IsValid reports whether a given WebhooksConfiguration is valid or not.
+// Returns an error if the validation fails.
+func (c *WebhooksConfiguration) IsValid() error {
+	if c.ReceiverListenAddress != "" && c.ReceiverURL == "" {
+		return errors.New("ReceiverURL should be set when ReceiverListenAddress is set")
+	}
+	return nil
 }
 
 // Config holds information needed to create and initialize a new load-test
@@ -148,6 +400,7 @@ type Config struct {
 	UserControllerConfiguration UserControllerConfiguration
 	InstanceConfiguration       InstanceConfiguration
 	UsersConfiguration          UsersConfiguration
+	WebhooksConfiguration       WebhooksConfiguration
 	LogSettings                 logger.Settings
 }
 
@@ -160,6 +413,12 @@ func (c *Config) IsValid() error {
 	if err := c.InstanceConfiguration.IsValid(); err != nil {
 		return err
 	}
+	if err := c.ConnectionConfiguration.IsValid(); err != nil {
+		return err
+	}
+	if err := c.WebhooksConfiguration.IsValid(); err != nil {
+		return err
+	}
 	return nil
 }
 
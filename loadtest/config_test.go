@@ -0,0 +1,120 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package loadtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickPopulation(t *testing.T) {
+	ucc := UserControllerConfiguration{
+		Populations: []ControllerPopulation{
+			{Type: UserControllerSimulative, Percentage: 0.95},
+			{Type: UserControllerCluster, Percentage: 0.05},
+		},
+	}
+
+	counts := make([]int, len(ucc.Populations))
+	for id := 1; id <= 1000; id++ {
+		p := ucc.PickPopulation(id)
+		require.Equal(t, p, ucc.PickPopulation(id), "assignment should be deterministic")
+		counts[p]++
+	}
+	require.InDelta(t, 950, counts[0], 2)
+	require.InDelta(t, 50, counts[1], 2)
+
+	// Fewer users are still spread according to the percentages.
+	counts = make([]int, len(ucc.Populations))
+	for id := 1; id <= 100; id++ {
+		counts[ucc.PickPopulation(id)]++
+	}
+	require.InDelta(t, 95, counts[0], 1)
+	require.InDelta(t, 5, counts[1], 1)
+}
+
+func TestPopulationsIsValid(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		populations []ControllerPopulation
+		err         string
+	}{
+		{
+			name: "valid",
+			populations: []ControllerPopulation{
+				{Type: UserControllerSimulative, Percentage: 0.7},
+				{Name: "heavy", Type: UserControllerSimulative, Percentage: 0.2},
+				{Type: UserControllerNoop, Percentage: 0.1},
+			},
+		},
+		{
+			name: "wrong sum",
+			populations: []ControllerPopulation{
+				{Type: UserControllerSimulative, Percentage: 0.7},
+				{Type: UserControllerNoop, Percentage: 0.2},
+			},
+			err: "Percentages in Populations should sum to 1",
+		},
+		{
+			name: "duplicate names",
+			populations: []ControllerPopulation{
+				{Type: UserControllerSimulative, Percentage: 0.5},
+				{Type: UserControllerSimulative, Percentage: 0.5},
+			},
+			err: `duplicate population name "simulative"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ucc := UserControllerConfiguration{Populations: tc.populations}
+			err := ucc.IsValid()
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestConnectionSpreading(t *testing.T) {
+	cc := ConnectionConfiguration{
+		ServerURL:    "http://proxy:8065",
+		WebSocketURL: "ws://proxy:8065",
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		require.NoError(t, cc.IsValid())
+		require.Empty(t, cc.SourceAddress(3))
+		require.Equal(t, TargetConfiguration{
+			ServerURL:    "http://proxy:8065",
+			WebSocketURL: "ws://proxy:8065",
+		}, cc.Target(3))
+	})
+
+	t.Run("spreading", func(t *testing.T) {
+		cc := cc
+		cc.SourceAddresses = []string{"10.0.0.1", "10.0.0.2", "fd00::1"}
+		cc.Targets = []TargetConfiguration{
+			{ServerURL: "http://app0:8065", WebSocketURL: "ws://app0:8065"},
+			{ServerURL: "http://app1:8065", WebSocketURL: "ws://app1:8065"},
+		}
+		require.NoError(t, cc.IsValid())
+
+		addrs := map[string]int{}
+		targets := map[string]int{}
+		for id := 0; id < 60; id++ {
+			addrs[cc.SourceAddress(id)]++
+			targets[cc.Target(id).ServerURL]++
+		}
+		require.Equal(t, map[string]int{"10.0.0.1": 20, "10.0.0.2": 20, "fd00::1": 20}, addrs)
+		require.Equal(t, map[string]int{"http://app0:8065": 30, "http://app1:8065": 30}, targets)
+	})
+
+	t.Run("invalid source address", func(t *testing.T) {
+		cc := cc
+		cc.SourceAddresses = []string{"10.0.0.1", "app0"}
+		require.EqualError(t, cc.IsValid(), `invalid source address "app0"`)
+	})
+}
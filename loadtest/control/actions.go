@@ -1061,6 +1061,24 @@ func ScheduledPostsEnabled(u user.User) (bool, UserActionResponse) {
 	return allow, UserActionResponse{}
 }
 
+func IncomingWebhooksEnabled(u user.User) (bool, UserActionResponse) {
+	allow, err := strconv.ParseBool(u.Store().ClientConfig()["EnableIncomingWebhooks"])
+	if err != nil {
+		return false, UserActionResponse{Err: NewUserError(err)}
+	}
+
+	return allow, UserActionResponse{}
+}
+
+func OutgoingWebhooksEnabled(u user.User) (bool, UserActionResponse) {
+	allow, err := strconv.ParseBool(u.Store().ClientConfig()["EnableOutgoingWebhooks"])
+	if err != nil {
+		return false, UserActionResponse{Err: NewUserError(err)}
+	}
+
+	return allow, UserActionResponse{}
+}
+
 // MessageExport simulates the given user performing
 // a compliance message export
 func MessageExport(u user.User) UserActionResponse {
@@ -0,0 +1,178 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package botcontroller
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/control"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/store"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/store/memstore"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/user"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+func (c *BotController) connect() error {
+	if !atomic.CompareAndSwapInt32(&c.connectedFlag, 0, 1) {
+		return errors.New("already connected")
+	}
+	errChan, err := c.user.Connect()
+	if err != nil {
+		atomic.StoreInt32(&c.connectedFlag, 0)
+		return fmt.Errorf("connect failed %w", err)
+	}
+	c.wg.Add(2)
+	go func() {
+		defer c.wg.Done()
+		for err := range errChan {
+			c.status <- c.newErrorStatus(err)
+		}
+	}()
+	go func() {
+		defer c.wg.Done()
+		c.wsEventHandler()
+	}()
+	return nil
+}
+
+func (c *BotController) disconnect() error {
+	if !atomic.CompareAndSwapInt32(&c.connectedFlag, 1, 0) {
+		return errors.New("not connected")
+	}
+
+	err := c.user.Disconnect()
+	if err != nil {
+		return fmt.Errorf("disconnect failed %w", err)
+	}
+
+	c.wg.Wait()
+
+	return nil
+}
+
+func (c *BotController) login(u user.User) control.UserActionResponse {
+	for {
+		resp := control.Login(u)
+		if resp.Err != nil {
+			c.status <- c.newErrorStatus(resp.Err)
+		} else if err := c.connect(); err != nil {
+			c.status <- c.newErrorStatus(err)
+		} else {
+			return resp
+		}
+
+		select {
+		case <-c.stopChan:
+			return control.UserActionResponse{Info: "login canceled"}
+		case <-time.After(control.PickIdleTimeMs(1000, 20000, 1.0)):
+		}
+	}
+}
+
+// loadChannels fetches the channels of the teams the bot is a member of, along
+// with its own channel memberships, so that it knows where it can post.
+func (c *BotController) loadChannels(u user.User) control.UserActionResponse {
+	userID := u.Store().Id()
+	teams, err := u.Store().Teams()
+	if err != nil {
+		return control.UserActionResponse{Err: control.NewUserError(err)}
+	}
+
+	for _, team := range teams {
+		if err := u.GetChannelsForTeam(team.Id, false); err != nil {
+			return control.UserActionResponse{Err: control.NewUserError(err)}
+		}
+		if err := u.GetChannelMembersForUser(userID, team.Id); err != nil {
+			return control.UserActionResponse{Err: control.NewUserError(err)}
+		}
+	}
+
+	return control.UserActionResponse{Info: fmt.Sprintf("loaded channels of %d teams", len(teams))}
+}
+
+// createPost creates a new post in a random channel the bot is a member of.
+// Depending on the config, the post is either a plain message or an
+// incoming-webhook-style one, carrying message attachments.
+func (c *BotController) createPost(u user.User) control.UserActionResponse {
+	channel, err := randomChannel(u)
+	if errors.Is(err, memstore.ErrTeamStoreEmpty) || errors.Is(err, memstore.ErrChannelStoreEmpty) {
+		return control.UserActionResponse{Info: "no channels in store"}
+	} else if err != nil {
+		return control.UserActionResponse{Err: control.NewUserError(err)}
+	}
+
+	post := &model.Post{
+		ChannelId: channel.Id,
+		CreateAt:  time.Now().Unix() * 1000,
+	}
+	if rand.Float64() < c.config.PercentAttachmentPosts {
+		post.AddProp(model.PostPropsAttachments, []*model.MessageAttachment{control.RandomMessageAttachment()})
+	} else {
+		post.Message = control.GenerateRandomSentences(rand.Intn(10) + 1)
+	}
+
+	postID, err := u.CreatePost(post)
+	if err != nil {
+		return control.UserActionResponse{Err: control.NewUserError(err)}
+	}
+
+	return control.UserActionResponse{Info: fmt.Sprintf("post created, id %v", postID)}
+}
+
+// runCommand runs a random slash command from the config in a random channel
+// the bot is a member of.
+func (c *BotController) runCommand(u user.User) control.UserActionResponse {
+	if len(c.config.Commands) == 0 {
+		return control.UserActionResponse{Info: "no commands to run"}
+	}
+
+	channel, err := randomChannel(u)
+	if errors.Is(err, memstore.ErrTeamStoreEmpty) || errors.Is(err, memstore.ErrChannelStoreEmpty) {
+		return control.UserActionResponse{Info: "no channels in store"}
+	} else if err != nil {
+		return control.UserActionResponse{Err: control.NewUserError(err)}
+	}
+
+	command := c.config.Commands[rand.Intn(len(c.config.Commands))].Command
+	if err := u.ExecuteCommand(channel.Id, command+" "+control.GenerateRandomSentences(rand.Intn(5)+1)); err != nil {
+		return control.UserActionResponse{Err: control.NewUserError(err)}
+	}
+
+	return control.UserActionResponse{Info: fmt.Sprintf("command %s run in channel %s", command, channel.Id)}
+}
+
+// replyToPost replies to the given post in its thread.
+func (c *BotController) replyToPost(u user.User, post *model.Post) control.UserActionResponse {
+	postID, err := u.CreatePost(&model.Post{
+		ChannelId: post.ChannelId,
+		RootId:    post.Id,
+		Message:   control.GenerateRandomSentences(rand.Intn(10) + 1),
+		CreateAt:  time.Now().Unix() * 1000,
+	})
+	if err != nil {
+		return control.UserActionResponse{Err: control.NewUserError(err)}
+	}
+
+	return control.UserActionResponse{Info: fmt.Sprintf("replied to post %s, id %v", post.Id, postID)}
+}
+
+func randomChannel(u user.User) (model.Channel, error) {
+	team, err := u.Store().RandomTeam(store.SelectMemberOf)
+	if err != nil {
+		return model.Channel{}, err
+	}
+	return u.Store().RandomChannel(team.Id, store.SelectMemberOf)
+}
+
+// pickIdleTime returns the time to wait for until the next occurrence of an
+// action run perMinute times a minute on average, with the occurrences
+// following a Poisson process. The time is scaled by the given rate.
+func pickIdleTime(perMinute, rate float64) time.Duration {
+	return time.Duration(rand.ExpFloat64() / perMinute * rate * float64(time.Minute))
+}
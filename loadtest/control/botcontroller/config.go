@@ -0,0 +1,42 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package botcontroller
+
+import (
+	"github.com/mattermost/mattermost-load-test-ng/defaults"
+)
+
+// Config holds the rates and behaviours of the bots run by the BotController.
+type Config struct {
+	// The average number of posts made by a bot every minute.
+	PostsPerMinute float64 `default:"2" validate:"range:(0,]"`
+	// The percentage of posts made in the style of an incoming webhook, that
+	// is with message attachments in place of a plain message.
+	PercentAttachmentPosts float64 `default:"0.5" validate:"range:[0,1]"`
+	// The average number of slash commands run by a bot every minute.
+	CommandsPerMinute float64 `default:"0.5" validate:"range:[0,]"`
+	// The slash commands run by the bots, picked at random. Some random text
+	// is appended to each of them as arguments.
+	Commands []commandDefinition `default_size:"1"`
+	// Whether bots reply to the posts mentioning them or sent to them in a
+	// direct message, as received through the WebSocket.
+	ReplyToMentions bool `default:"true"`
+}
+
+type commandDefinition struct {
+	// Command is the slash command to run, including the leading slash.
+	Command string `default:"/shrug" validate:"notempty"`
+}
+
+// ReadConfig reads the configuration file from the given string. If the string
+// is empty, it will return a config with default values.
+func ReadConfig(configFilePath string) (*Config, error) {
+	var cfg Config
+
+	if err := defaults.ReadFrom(configFilePath, "./config/botcontroller.json", &cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
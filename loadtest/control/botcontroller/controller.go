@@ -0,0 +1,205 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package botcontroller
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/control"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/user"
+	"github.com/mattermost/mattermost/server/public/shared/mlog"
+)
+
+func init() {
+	control.RegisterConfigurableController("bot", func(params control.ControllerParams, config *Config) (control.UserController, error) {
+		return New(params.Id, params.User, config, params.Status)
+	}, ReadConfig)
+}
+
+// BotController is an implementation of a controller simulating a bot or an
+// integration. Bots usually authenticate with a personal access token. Once
+// connected, they post at configurable rates, both plain messages and
+// incoming-webhook-style messages with attachments, run slash commands and
+// reply to the posts mentioning them as received through the WebSocket, like
+// bot frameworks do.
+type BotController struct {
+	id                 int
+	user               user.User
+	status             chan<- control.UserStatus
+	rate               float64
+	config             *Config
+	actionMap          map[string]control.UserAction
+	injectedActionChan chan control.UserAction
+	stopChan           chan struct{}   // this channel coordinates the stop sequence of the controller
+	stoppedChan        chan struct{}   // blocks until controller cleans up everything
+	connectedFlag      int32           // indicates that the controller is connected
+	wg                 *sync.WaitGroup // to keep the track of every goroutine created by the controller
+}
+
+// New creates and initializes a new BotController with given parameters.
+// An id is provided to identify the controller, a User is passed as the entity to be controlled and
+// a UserStatus channel is passed to communicate errors and information about the user's status.
+func New(id int, user user.User, config *Config, status chan<- control.UserStatus) (*BotController, error) {
+	if config == nil || user == nil {
+		return nil, errors.New("nil params passed")
+	}
+
+	c := &BotController{
+		id:                 id,
+		user:               user,
+		status:             status,
+		rate:               1.0,
+		config:             config,
+		injectedActionChan: make(chan control.UserAction, 10),
+		stopChan:           make(chan struct{}),
+		stoppedChan:        make(chan struct{}),
+		wg:                 &sync.WaitGroup{},
+	}
+
+	c.actionMap = map[string]control.UserAction{
+		"CreatePost":  c.createPost,
+		"RunCommand":  c.runCommand,
+		"JoinTeam":    control.JoinTeam,
+		"JoinChannel": control.JoinChannel,
+		"Reload":      control.Reload,
+	}
+
+	return c, nil
+}
+
+// Run begins performing the actions of the bot until Stop is invoked: posts
+// and slash commands are run at the configured rates, while the posts
+// mentioning the bot are replied to as they come.
+// This is also a blocking function, so it is recommended to invoke it
+// inside a goroutine.
+func (c *BotController) Run() {
+	if c.user == nil {
+		c.sendFailStatus("controller was not initialized")
+		return
+	}
+
+	c.status <- control.UserStatus{ControllerId: c.id, User: c.user, Info: "user started", Code: control.USER_STATUS_STARTED}
+
+	defer func() {
+		if err := c.disconnect(); err != nil {
+			c.status <- c.newErrorStatus(control.NewUserError(err))
+		}
+		c.user.ClearUserData()
+		c.sendStopStatus()
+		close(c.stoppedChan)
+	}()
+
+	initActions := []control.UserAction{
+		control.SignUp,
+		c.login,
+		control.JoinTeam,
+		c.loadChannels,
+		control.JoinChannel,
+	}
+
+	// run init actions, retrying the failed ones
+	for i := 0; i < len(initActions); i++ {
+		idleTime := time.Duration(math.Round(float64(1000) * c.rate))
+
+		select {
+		case <-c.stopChan:
+			return
+		case <-time.After(time.Millisecond * idleTime):
+		}
+
+		if resp := initActions[i](c.user); resp.Err != nil {
+			c.status <- c.newErrorStatus(resp.Err)
+			i--
+		} else {
+			c.status <- c.newInfoStatus(resp.Info)
+		}
+	}
+
+	postTimer := time.NewTimer(pickIdleTime(c.config.PostsPerMinute, c.rate))
+	defer postTimer.Stop()
+
+	// Commands are only run if there are any to pick from.
+	var commandTimer *time.Timer
+	var commandChan <-chan time.Time
+	if c.config.CommandsPerMinute > 0 && len(c.config.Commands) > 0 {
+		commandTimer = time.NewTimer(pickIdleTime(c.config.CommandsPerMinute, c.rate))
+		defer commandTimer.Stop()
+		commandChan = commandTimer.C
+	}
+
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case <-postTimer.C:
+			c.runAction(c.createPost)
+			postTimer.Reset(pickIdleTime(c.config.PostsPerMinute, c.rate))
+		case <-commandChan:
+			c.runAction(c.runCommand)
+			commandTimer.Reset(pickIdleTime(c.config.CommandsPerMinute, c.rate))
+		case ia := <-c.injectedActionChan: // run injected actions immediately
+			c.runAction(ia)
+		}
+	}
+}
+
+func (c *BotController) runAction(action control.UserAction) {
+	if resp := action(c.user); resp.Err != nil {
+		c.status <- c.newErrorStatus(resp.Err)
+	} else {
+		c.status <- c.newInfoStatus(resp.Info)
+	}
+}
+
+// SetRate sets the relative speed of execution of actions by the user.
+func (c *BotController) SetRate(rate float64) error {
+	if rate < 0 {
+		return errors.New("rate should be a positive value")
+	}
+	c.rate = rate
+	return nil
+}
+
+// Stop stops the controller.
+func (c *BotController) Stop() {
+	close(c.stopChan)
+	<-c.stoppedChan
+	// re-initialize for the next use
+	c.injectedActionChan = make(chan control.UserAction, 10)
+	c.stopChan = make(chan struct{})
+	c.stoppedChan = make(chan struct{})
+}
+
+func (c *BotController) sendFailStatus(reason string) {
+	c.status <- control.UserStatus{ControllerId: c.id, User: c.user, Code: control.USER_STATUS_FAILED, Err: errors.New(reason)}
+}
+
+func (c *BotController) sendStopStatus() {
+	c.status <- control.UserStatus{ControllerId: c.id, User: c.user, Info: "user stopped", Code: control.USER_STATUS_STOPPED}
+}
+
+// InjectAction allows a named UserAction to be injected that is run once, at the next
+// available opportunity. These actions can be injected via the coordinator via
+// CLI or Rest API.
+func (c *BotController) InjectAction(actionID string) error {
+	action, ok := c.actionMap[actionID]
+	if !ok {
+		mlog.Debug("Could not inject action for BotController", mlog.String("action", actionID))
+		return nil
+	}
+
+	select {
+	case c.injectedActionChan <- action:
+		return nil
+	default:
+		return fmt.Errorf("action %s could not be queued: %w", actionID, control.ErrInjectActionQueueFull)
+	}
+}
+
+// ensure BotController implements UserController interface
+var _ control.UserController = (*BotController)(nil)
@@ -0,0 +1,201 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package botcontroller
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-load-test-ng/defaults"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/control"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/store"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/store/memstore"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/user"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/user/userentity"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadConfig(t *testing.T) {
+	cfg, err := ReadConfig("")
+	require.NoError(t, err)
+	require.NoError(t, defaults.Validate(cfg))
+	require.Len(t, cfg.Commands, 1)
+	require.Equal(t, "/shrug", cfg.Commands[0].Command)
+
+	cfg, err = ReadConfig("../../../config/botcontroller.sample.json")
+	require.NoError(t, err)
+	require.NoError(t, defaults.Validate(cfg))
+}
+
+func TestSetRate(t *testing.T) {
+	var cfg Config
+	err := defaults.Set(&cfg)
+	require.Nil(t, err)
+
+	c, err := New(1, &userentity.UserEntity{}, &cfg, make(chan control.UserStatus))
+	require.Nil(t, err)
+
+	require.Equal(t, 1.0, c.rate)
+
+	err = c.SetRate(-1.0)
+	require.NotNil(t, err)
+	require.Equal(t, 1.0, c.rate)
+
+	err = c.SetRate(1.5)
+	require.Nil(t, err)
+	require.Equal(t, 1.5, c.rate)
+}
+
+func TestPickIdleTime(t *testing.T) {
+	var total time.Duration
+	n := 10000
+	for range n {
+		d := pickIdleTime(6, 1.0)
+		require.GreaterOrEqual(t, d, time.Duration(0))
+		total += d
+	}
+	// Six times a minute means once every ten seconds on average.
+	require.InDelta(t, 10*time.Second, total/time.Duration(n), float64(time.Second))
+
+	// A slower rate means a longer wait.
+	total = 0
+	for range n {
+		total += pickIdleTime(6, 2.0)
+	}
+	require.InDelta(t, 20*time.Second, total/time.Duration(n), float64(2*time.Second))
+}
+
+func TestShouldReply(t *testing.T) {
+	s, err := memstore.New(nil)
+	require.NoError(t, err)
+	botID := model.NewId()
+	require.NoError(t, s.SetUser(&model.User{Id: botID}))
+
+	var cfg Config
+	require.NoError(t, defaults.Set(&cfg))
+	status := make(chan control.UserStatus, 10)
+	c, err := New(1, userentity.New(userentity.Setup{Store: s}, userentity.Config{}), &cfg, status)
+	require.NoError(t, err)
+
+	newEvent := func(post *model.Post, channelType model.ChannelType, mentions []string) *model.WebSocketEvent {
+		ev := model.NewWebSocketEvent(model.WebsocketEventPosted, "", post.ChannelId, "", nil, "")
+		postData, err := json.Marshal(post)
+		require.NoError(t, err)
+		ev = ev.SetData(map[string]any{
+			"post":         string(postData),
+			"channel_type": string(channelType),
+		})
+		if mentions != nil {
+			mentionsData, err := json.Marshal(mentions)
+			require.NoError(t, err)
+			ev.GetData()["mentions"] = string(mentionsData)
+		}
+		return ev
+	}
+
+	otherID := model.NewId()
+	for _, tc := range []struct {
+		name        string
+		post        *model.Post
+		channelType model.ChannelType
+		mentions    []string
+		expected    bool
+	}{
+		{
+			name:        "mention",
+			post:        &model.Post{Id: model.NewId(), UserId: otherID},
+			channelType: model.ChannelTypeOpen,
+			mentions:    []string{otherID, botID},
+			expected:    true,
+		},
+		{
+			name:        "direct message",
+			post:        &model.Post{Id: model.NewId(), UserId: otherID},
+			channelType: model.ChannelTypeDirect,
+			expected:    true,
+		},
+		{
+			name:        "no mention",
+			post:        &model.Post{Id: model.NewId(), UserId: otherID},
+			channelType: model.ChannelTypeOpen,
+			mentions:    []string{otherID},
+		},
+		{
+			name:        "own post",
+			post:        &model.Post{Id: model.NewId(), UserId: botID},
+			channelType: model.ChannelTypeDirect,
+		},
+		{
+			name:        "reply",
+			post:        &model.Post{Id: model.NewId(), UserId: otherID, RootId: model.NewId()},
+			channelType: model.ChannelTypeOpen,
+			mentions:    []string{botID},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			post, ok := c.shouldReply(newEvent(tc.post, tc.channelType, tc.mentions))
+			require.Equal(t, tc.expected, ok)
+			if ok {
+				require.Equal(t, tc.post.Id, post.Id)
+			}
+		})
+	}
+	require.Empty(t, status)
+}
+
+// loginUser is a user that logs in successfully but fails to connect.
+type loginUser struct {
+	user.User
+	store store.UserStore
+}
+
+func (u *loginUser) Store() store.UserStore                          { return u.store }
+func (u *loginUser) Login() error                                    { return nil }
+func (u *loginUser) GetClientConfig() error                          { return nil }
+func (u *loginUser) GetAllTeams(page, perPage int) ([]string, error) { return nil, nil }
+func (u *loginUser) GetTeamMembersForUser(userId string) error       { return nil }
+func (u *loginUser) Connect() (<-chan error, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestLoginConnectFailure(t *testing.T) {
+	s, err := memstore.New(nil)
+	require.NoError(t, err)
+	require.NoError(t, s.SetUser(&model.User{Id: model.NewId()}))
+	u := &loginUser{store: s}
+
+	var cfg Config
+	require.NoError(t, defaults.Set(&cfg))
+	status := make(chan control.UserStatus, 10)
+	c, err := New(1, u, &cfg, status)
+	require.NoError(t, err)
+
+	respChan := make(chan control.UserActionResponse, 1)
+	go func() {
+		respChan <- c.login(u)
+	}()
+
+	select {
+	case st := <-status:
+		require.Equal(t, control.USER_STATUS_ERROR, st.Code)
+		require.ErrorContains(t, st.Err, "connection refused")
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for the connect error")
+	}
+
+	close(c.stopChan)
+	select {
+	case resp := <-respChan:
+		require.Equal(t, "login canceled", resp.Info)
+	case <-time.After(30 * time.Second):
+		require.Fail(t, "timed out waiting for login to return")
+	}
+
+	// Only the connect error is reported.
+	require.Empty(t, status)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package botcontroller
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// wsEventHandler listens for WebSocket events to be handled.
+// This is used to model bot behaviour by responding to certain events with
+// the appropriate actions. It differs from userentity.wsEventHandler which is
+// instead used to manage the internal user state.
+func (c *BotController) wsEventHandler() {
+	for ev := range c.user.Events() {
+		switch ev.EventType() {
+		case model.WebsocketEventPosted:
+			if !c.config.ReplyToMentions {
+				continue
+			}
+			post, ok := c.shouldReply(ev)
+			if !ok {
+				continue
+			}
+			if resp := c.replyToPost(c.user, post); resp.Err != nil {
+				c.status <- c.newErrorStatus(resp.Err)
+			} else {
+				c.status <- c.newInfoStatus(resp.Info)
+			}
+		default:
+			// add other handlers as necessary.
+		}
+	}
+}
+
+// shouldReply returns the post carried by the given posted event, and whether
+// the bot should reply to it. Bots reply to the root posts from others which
+// mention them or are sent to them in a direct message. Replies are ignored so
+// that bots don't keep replying to each other.
+func (c *BotController) shouldReply(ev *model.WebSocketEvent) (*model.Post, bool) {
+	data := ev.GetData()
+	postData, ok := data["post"].(string)
+	if !ok {
+		return nil, false
+	}
+	var post model.Post
+	if err := json.Unmarshal([]byte(postData), &post); err != nil {
+		c.status <- c.newErrorStatus(err)
+		return nil, false
+	}
+
+	userID := c.user.Store().Id()
+	if post.UserId == userID || post.RootId != "" {
+		return nil, false
+	}
+
+	if channelType, _ := data["channel_type"].(string); channelType == string(model.ChannelTypeDirect) {
+		return &post, true
+	}
+
+	var mentions []string
+	if mentionsData, ok := data["mentions"].(string); ok {
+		if err := json.Unmarshal([]byte(mentionsData), &mentions); err != nil {
+			c.status <- c.newErrorStatus(err)
+			return nil, false
+		}
+	}
+
+	return &post, slices.Contains(mentions, userID)
+}
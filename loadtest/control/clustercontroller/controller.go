@@ -13,6 +13,18 @@ import (
 	"github.com/mattermost/mattermost/server/public/shared/mlog"
 )
 
+func init() {
+	control.RegisterController("cluster", func(params control.ControllerParams) (control.UserController, error) {
+		// Only the sysadmin is used, since the controller just tests
+		// system console APIs.
+		admin, err := params.NewSysAdmin()
+		if err != nil {
+			return nil, err
+		}
+		return New(params.Id, admin, params.Status)
+	})
+}
+
 type ClusterController struct {
 	id      int
 	user    user.User
@@ -19,6 +19,19 @@ import (
 	_ "github.com/mattermost/mattermost-plugin-playbooks/loadtest"
 )
 
+func init() {
+	control.RegisterConfigurableController("generative", func(params control.ControllerParams, config *Config) (control.UserController, error) {
+		sysadmin, err := params.NewSysAdmin()
+		if err != nil {
+			return nil, err
+		}
+		if err := sysadmin.Login(); err != nil {
+			return nil, err
+		}
+		return New(params.Id, params.User, sysadmin, config, params.Status, params.InitialActiveUsers)
+	}, ReadConfig)
+}
+
 // GenController is an implementation of a UserController used to generate
 // realistic initial data.
 type GenController struct {
@@ -15,6 +15,12 @@ import (
 	"github.com/mattermost/mattermost/server/public/shared/mlog"
 )
 
+func init() {
+	control.RegisterController("noop", func(params control.ControllerParams) (control.UserController, error) {
+		return New(params.Id, params.User, params.Status)
+	})
+}
+
 func getActionList(c *NoopController) []userAction {
 	return []userAction{
 		{
@@ -0,0 +1,206 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package control
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/user"
+	"github.com/mattermost/mattermost-load-test-ng/performance"
+)
+
+// ErrUnknownController is returned when a type of UserController is not
+// registered.
+var ErrUnknownController = errors.New("unknown controller type")
+
+// ErrNilControllerConfig is returned when a UserController requiring a config
+// is not given any.
+var ErrNilControllerConfig = errors.New("controller config should not be nil")
+
+// ErrWrongControllerConfigType is returned when the config given to a
+// UserController doesn't have the type it expects.
+var ErrWrongControllerConfigType = errors.New("controller config has the wrong type")
+
+// ControllerParams holds the parameters passed to the function creating a
+// new UserController.
+type ControllerParams struct {
+	// The id of the controller.
+	Id int
+	// The user to be controlled.
+	User user.User
+	// The channel used to communicate the status of the user.
+	Status chan<- UserStatus
+	// NewSysAdmin returns a new user, not logged in yet, with the credentials
+	// of the system admin, for controllers needing to run privileged actions.
+	NewSysAdmin func() (user.User, error)
+	// The number of users initially active in the load-test.
+	InitialActiveUsers int
+	// Optional metrics to record the actions run by the user into.
+	ActionMetrics *performance.ActionMetrics
+	// The URL outgoing webhooks created by the user should be delivered to.
+	// Empty if the agent isn't receiving outgoing webhooks.
+	WebhookCallbackURL string
+}
+
+// registeredController holds the functions registered for a type of
+// UserController.
+type registeredController struct {
+	new        func(params ControllerParams, config any) (UserController, error)
+	readConfig func(path string) (any, error)
+	configType reflect.Type
+}
+
+var (
+	// The global lock to protect access to registeredControllers.
+	controllersLock sync.RWMutex
+	// The global map of registered controllers, keyed by type.
+	registeredControllers = map[string]registeredController{}
+)
+
+// RegisterController registers a type of UserController with no config under
+// the given name. Controller packages usually call it from their init
+// function. It panics if the name is already registered.
+func RegisterController(name string, newFn func(params ControllerParams) (UserController, error)) {
+	register(name, registeredController{
+		new: func(params ControllerParams, _ any) (UserController, error) {
+			return newFn(params)
+		},
+	})
+}
+
+// RegisterConfigurableController registers a type of UserController with a
+// config of type C under the given name. readConfig must read the config from
+// the given file, returning the default one if the path is empty. It panics if
+// the name is already registered.
+func RegisterConfigurableController[C any](name string, newFn func(params ControllerParams, config *C) (UserController, error), readConfig func(path string) (*C, error)) {
+	register(name, registeredController{
+		new: func(params ControllerParams, config any) (UserController, error) {
+			c, ok := config.(*C)
+			if !ok || c == nil {
+				return nil, fmt.Errorf("invalid config for controller type %q: %T", name, config)
+			}
+			return newFn(params, c)
+		},
+		readConfig: func(path string) (any, error) {
+			return readConfig(path)
+		},
+		configType: reflect.TypeOf((*C)(nil)),
+	})
+}
+
+func register(name string, rc registeredController) {
+	controllersLock.Lock()
+	defer controllersLock.Unlock()
+
+	if _, ok := registeredControllers[name]; ok {
+		panic(fmt.Sprintf("controller type %q is already registered", name))
+	}
+	registeredControllers[name] = rc
+}
+
+func getController(name string) (registeredController, error) {
+	controllersLock.RLock()
+	defer controllersLock.RUnlock()
+
+	rc, ok := registeredControllers[name]
+	if !ok {
+		return rc, fmt.Errorf("%w: %q", ErrUnknownController, name)
+	}
+	return rc, nil
+}
+
+// IsControllerRegistered returns whether a type of UserController is
+// registered under the given name.
+func IsControllerRegistered(name string) bool {
+	_, err := getController(name)
+	return err == nil
+}
+
+// RegisteredControllers returns the sorted names of the registered types of
+// UserController.
+func RegisteredControllers() []string {
+	controllersLock.RLock()
+	defer controllersLock.RUnlock()
+
+	names := make([]string, 0, len(registeredControllers))
+	for name := range registeredControllers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewController creates a new UserController of the given type. The config
+// is ignored by types of UserController with no config.
+func NewController(name string, params ControllerParams, config any) (UserController, error) {
+	rc, err := getController(name)
+	if err != nil {
+		return nil, err
+	}
+	return rc.new(params, config)
+}
+
+// ReadControllerConfig reads the config of the given type of UserController
+// from the given file, returning the default one if the path is empty. A nil
+// config is returned for types of UserController with no config.
+func ReadControllerConfig(name, path string) (any, error) {
+	rc, err := getController(name)
+	if err != nil {
+		return nil, err
+	}
+	if rc.readConfig == nil {
+		return nil, nil
+	}
+	return rc.readConfig(path)
+}
+
+// DecodeControllerConfig decodes the given JSON-encoded config of the given
+// type of UserController on top of its default one, failing on unknown
+// fields. The default config is returned if data is empty.
+func DecodeControllerConfig(name string, data []byte) (any, error) {
+	config, err := ReadControllerConfig(name, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return config, nil
+	}
+	if config == nil {
+		return nil, fmt.Errorf("controller type %q has no config", name)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(config); err != nil {
+		return nil, fmt.Errorf("failed to decode config of controller type %q: %w", name, err)
+	}
+
+	return config, nil
+}
+
+// CheckControllerConfig checks that the given config matches the type of
+// UserController. Types which are not registered are not checked, since they
+// may only be known to the agents.
+func CheckControllerConfig(name string, config any) error {
+	rc, err := getController(name)
+	if err != nil {
+		return nil
+	}
+	if rc.configType == nil {
+		return nil
+	}
+	if config == nil {
+		return ErrNilControllerConfig
+	}
+	if reflect.TypeOf(config) != rc.configType {
+		return fmt.Errorf("%w %T", ErrWrongControllerConfigType, config)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package control
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testControllerConfig struct {
+	Value int `default:"1"`
+	Name  string
+}
+
+func TestControllerRegistry(t *testing.T) {
+	var gotParams ControllerParams
+	var gotConfig *testControllerConfig
+	RegisterConfigurableController("test-configurable", func(params ControllerParams, config *testControllerConfig) (UserController, error) {
+		gotParams, gotConfig = params, config
+		return nil, nil
+	}, func(path string) (*testControllerConfig, error) {
+		if path != "" {
+			return nil, errors.New("not found")
+		}
+		return &testControllerConfig{Value: 1}, nil
+	})
+	RegisterController("test-plain", func(params ControllerParams) (UserController, error) {
+		gotParams = params
+		return nil, nil
+	})
+
+	t.Run("registered", func(t *testing.T) {
+		require.True(t, IsControllerRegistered("test-configurable"))
+		require.True(t, IsControllerRegistered("test-plain"))
+		require.False(t, IsControllerRegistered("test-unknown"))
+		require.Subset(t, RegisteredControllers(), []string{"test-configurable", "test-plain"})
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		require.Panics(t, func() {
+			RegisterController("test-plain", func(params ControllerParams) (UserController, error) {
+				return nil, nil
+			})
+		})
+	})
+
+	t.Run("new controller", func(t *testing.T) {
+		config := &testControllerConfig{Value: 2}
+		_, err := NewController("test-configurable", ControllerParams{Id: 42}, config)
+		require.NoError(t, err)
+		require.Equal(t, 42, gotParams.Id)
+		require.Same(t, config, gotConfig)
+
+		_, err = NewController("test-configurable", ControllerParams{Id: 42}, "invalid")
+		require.Error(t, err)
+
+		_, err = NewController("test-plain", ControllerParams{Id: 43}, nil)
+		require.NoError(t, err)
+		require.Equal(t, 43, gotParams.Id)
+
+		_, err = NewController("test-unknown", ControllerParams{}, nil)
+		require.ErrorIs(t, err, ErrUnknownController)
+	})
+
+	t.Run("read config", func(t *testing.T) {
+		config, err := ReadControllerConfig("test-configurable", "")
+		require.NoError(t, err)
+		require.Equal(t, &testControllerConfig{Value: 1}, config)
+
+		_, err = ReadControllerConfig("test-configurable", "missing.json")
+		require.Error(t, err)
+
+		config, err = ReadControllerConfig("test-plain", "")
+		require.NoError(t, err)
+		require.Nil(t, config)
+
+		_, err = ReadControllerConfig("test-unknown", "")
+		require.ErrorIs(t, err, ErrUnknownController)
+	})
+
+	t.Run("decode config", func(t *testing.T) {
+		config, err := DecodeControllerConfig("test-configurable", nil)
+		require.NoError(t, err)
+		require.Equal(t, &testControllerConfig{Value: 1}, config)
+
+		config, err = DecodeControllerConfig("test-configurable", []byte(`{"Name": "name"}`))
+		require.NoError(t, err)
+		require.Equal(t, &testControllerConfig{Value: 1, Name: "name"}, config)
+
+		_, err = DecodeControllerConfig("test-configurable", []byte(`{"Unknown": true}`))
+		require.Error(t, err)
+
+		_, err = DecodeControllerConfig("test-plain", []byte(`{"Name": "name"}`))
+		require.EqualError(t, err, `controller type "test-plain" has no config`)
+	})
+
+	t.Run("check config", func(t *testing.T) {
+		require.NoError(t, CheckControllerConfig("test-configurable", &testControllerConfig{}))
+		require.EqualError(t, CheckControllerConfig("test-configurable", nil), "controller config should not be nil")
+		require.EqualError(t, CheckControllerConfig("test-configurable", testControllerConfig{}), "controller config has the wrong type control.testControllerConfig")
+		require.NoError(t, CheckControllerConfig("test-plain", nil))
+		require.NoError(t, CheckControllerConfig("test-unknown", "anything"))
+	})
+}
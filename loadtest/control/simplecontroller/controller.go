@@ -15,6 +15,12 @@ import (
 	"github.com/mattermost/mattermost/server/public/shared/mlog"
 )
 
+func init() {
+	control.RegisterConfigurableController("simple", func(params control.ControllerParams, config *Config) (control.UserController, error) {
+		return New(params.Id, params.User, config, params.Status)
+	}, ReadConfig)
+}
+
 // SimpleController is a very basic implementation of a controller.
 // Currently, it just performs a pre-defined set of actions in a loop.
 type SimpleController struct {
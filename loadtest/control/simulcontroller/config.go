@@ -4,6 +4,10 @@
 package simulcontroller
 
 import (
+	"errors"
+	"fmt"
+	"math"
+
 	"github.com/mattermost/mattermost-load-test-ng/defaults"
 )
 
@@ -21,8 +25,105 @@ type Config struct {
 	// The percentage of all posts that are replies
 	PercentReplies float64 `default:"0.18" validate:"range:[0,1]"`
 
+	// The frequencies of the webhook actions, relative to the ones of the
+	// other actions. Webhook actions are disabled when zero.
+	CreateIncomingWebhookFrequency float64 `default:"0" validate:"range:[0,]"`
+	PostToIncomingWebhookFrequency float64 `default:"0" validate:"range:[0,]"`
+	CreateOutgoingWebhookFrequency float64 `default:"0" validate:"range:[0,]"`
+
 	// The IDs of the enabled plugins.
 	EnabledPlugins []string
+
+	// An optional list of personas the controlled users are assigned to.
+	// When set, each persona overrides the settings above it sets for its
+	// users.
+	Personas []Persona
+}
+
+// Persona describes the behaviour of a share of the controlled users.
+type Persona struct {
+	// The name of the persona, used to label the action metrics.
+	Name string `validate:"notempty"`
+	// The percentage of users assigned to the persona.
+	Percentage float64 `validate:"range:(0,1]"`
+
+	// The settings below are optional. When not set, the users of the
+	// persona keep the ones of the Config.
+
+	// The minium amount of time (in milliseconds) the users of the persona
+	// will wait between actions.
+	MinIdleTimeMs *int
+	// The average amount of time (in milliseconds) the users of the persona
+	// will wait between actions.
+	AvgIdleTimeMs *int
+
+	// The percentage of root posts that are marked as urgent
+	PercentUrgentPosts *float64
+	// The percentage of all posts that are replies
+	PercentReplies *float64
+
+	// Overrides of the frequencies of the actions, keyed by action name.
+	// Actions not listed keep their default frequency.
+	ActionFrequencies map[string]float64
+}
+
+// apply returns a copy of the given config with the settings of the persona
+// applied to it.
+func (p *Persona) apply(config *Config) *Config {
+	cfg := *config
+	if p.MinIdleTimeMs != nil {
+		cfg.MinIdleTimeMs = *p.MinIdleTimeMs
+	}
+	if p.AvgIdleTimeMs != nil {
+		cfg.AvgIdleTimeMs = *p.AvgIdleTimeMs
+	}
+	if p.PercentUrgentPosts != nil {
+		cfg.PercentUrgentPosts = *p.PercentUrgentPosts
+	}
+	if p.PercentReplies != nil {
+		cfg.PercentReplies = *p.PercentReplies
+	}
+	return &cfg
+}
+
+// IsValid reports whether a given Config is valid or not.
+func (c *Config) IsValid() error {
+	var sum float64
+	names := make(map[string]bool, len(c.Personas))
+	for _, p := range c.Personas {
+		sum += p.Percentage
+		if names[p.Name] {
+			return fmt.Errorf("duplicate persona name %q", p.Name)
+		}
+		names[p.Name] = true
+
+		// The settings of the persona are checked along with the ones
+		// they are combined with.
+		cfg := p.apply(c)
+		if cfg.MinIdleTimeMs < 0 {
+			return fmt.Errorf("MinIdleTimeMs of persona %q should not be negative", p.Name)
+		}
+		if cfg.AvgIdleTimeMs <= cfg.MinIdleTimeMs {
+			return fmt.Errorf("AvgIdleTimeMs of persona %q should be greater than MinIdleTimeMs", p.Name)
+		}
+		if cfg.PercentUrgentPosts < 0 || cfg.PercentUrgentPosts > 1 {
+			return fmt.Errorf("PercentUrgentPosts of persona %q should be within [0,1]", p.Name)
+		}
+		if cfg.PercentReplies < 0 || cfg.PercentReplies > 1 {
+			return fmt.Errorf("PercentReplies of persona %q should be within [0,1]", p.Name)
+		}
+
+		for action, freq := range p.ActionFrequencies {
+			if freq < 0 {
+				return fmt.Errorf("frequency of action %q of persona %q should not be negative", action, p.Name)
+			}
+		}
+	}
+	if len(c.Personas) > 0 && (math.Round(sum*100)/100) != 1 {
+		return errors.New("Percentages in Personas should sum to 1")
+	}
+
+	return nil
 }
 
 // ReadConfig reads the configuration file from the given string. If the string
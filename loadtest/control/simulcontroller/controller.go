@@ -15,6 +15,7 @@ import (
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/control"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/plugins"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/user"
+	"github.com/mattermost/mattermost-load-test-ng/performance"
 	"github.com/mattermost/mattermost/server/public/shared/mlog"
 	"github.com/wiggin77/merror"
 
@@ -24,8 +25,22 @@ import (
 
 const (
 	probabilityAttachFileToPost = 0.02
+	// defaultPersona labels the actions of users not assigned to any persona.
+	defaultPersona = "default"
 )
 
+func init() {
+	control.RegisterConfigurableController("simulative", func(params control.ControllerParams, config *Config) (control.UserController, error) {
+		c, err := New(params.Id, params.User, config, params.Status)
+		if err != nil {
+			return nil, err
+		}
+		c.SetActionMetrics(params.ActionMetrics)
+		c.SetWebhookCallbackURL(params.WebhookCallbackURL)
+		return c, nil
+	}, ReadConfig)
+}
+
 func getActionList(c *SimulController) []userAction {
 	actions := []userAction{
 		{
@@ -334,6 +349,26 @@ func getActionList(c *SimulController) []userAction {
 			frequency:        0.001,
 			minServerVersion: semver.MustParse("10.3.0"),
 		},
+		// The webhook actions are disabled by default, so that the baseline
+		// load is unchanged.
+		{
+			name:             "CreateIncomingWebhook",
+			run:              c.createIncomingWebhook,
+			frequency:        c.config.CreateIncomingWebhookFrequency,
+			minServerVersion: control.MinSupportedVersion,
+		},
+		{
+			name:             "PostToIncomingWebhook",
+			run:              c.postToIncomingWebhook,
+			frequency:        c.config.PostToIncomingWebhookFrequency,
+			minServerVersion: control.MinSupportedVersion,
+		},
+		{
+			name:             "CreateOutgoingWebhook",
+			run:              c.createOutgoingWebhook,
+			frequency:        c.config.CreateOutgoingWebhookFrequency,
+			minServerVersion: control.MinSupportedVersion,
+		},
 		// All actions are required to contain a valid minServerVersion:
 		//   - If the action is present in server versions equal or older than
 		//     control.MinSupportedVersion, use control.MinSupportedVersion.
@@ -381,6 +416,9 @@ type SimulController struct {
 	wg                 *sync.WaitGroup // to keep the track of every goroutine created by the controller
 	serverVersion      semver.Version  // stores the current server version
 	plugins            []plugins.SimulController
+	persona            string                     // name of the persona the user is assigned to
+	metrics            *performance.ActionMetrics // optional metrics of the actions run
+	webhookCallbackURL string                     // where outgoing webhooks are delivered to, if any
 }
 
 // New creates and initializes a new SimulController with given parameters.
@@ -395,6 +433,21 @@ func New(id int, user user.User, config *Config, status chan<- control.UserStatu
 		return nil, fmt.Errorf("could not validate configuration: %w", err)
 	}
 
+	personaName := defaultPersona
+	var persona *Persona
+	if len(config.Personas) > 0 {
+		var err error
+		persona, err = pickPersona(config.Personas)
+		if err != nil {
+			return nil, err
+		}
+		personaName = persona.Name
+
+		// The settings of the persona are applied to a copy, since the
+		// config is shared by all the controllers.
+		config = persona.apply(config)
+	}
+
 	controller := &SimulController{
 		id:                 id,
 		user:               user,
@@ -406,6 +459,7 @@ func New(id int, user user.User, config *Config, status chan<- control.UserStatu
 		stopChan:           make(chan struct{}),
 		stoppedChan:        make(chan struct{}),
 		wg:                 &sync.WaitGroup{},
+		persona:            personaName,
 	}
 
 	plugins.SpawnPluginControllers(plugins.TypeSimulController, func(p plugins.Controller) {
@@ -421,6 +475,11 @@ func New(id int, user user.User, config *Config, status chan<- control.UserStatu
 	})
 
 	controller.actionList = getActionList(controller)
+	if persona != nil {
+		if err := applyActionFrequencies(controller.actionList, persona); err != nil {
+			return nil, err
+		}
+	}
 	controller.actionMap = getActionMap(controller.actionList)
 
 	return controller, nil
@@ -545,13 +604,39 @@ func (c *SimulController) runAction(action *userAction) {
 		return
 	}
 
-	if resp := action.run(c.user); resp.Err != nil {
+	start := time.Now()
+	resp := action.run(c.user)
+	c.observeAction(action.name, time.Since(start).Seconds(), resp.Err != nil)
+
+	if resp.Err != nil {
 		c.status <- c.newErrorStatus(resp.Err)
 	} else if resp.Info != "" {
 		c.status <- c.newInfoStatus(resp.Info)
 	}
 }
 
+// SetActionMetrics sets the metrics the actions run by the user are recorded
+// into, labeled by the persona of the user.
+func (c *SimulController) SetActionMetrics(metrics *performance.ActionMetrics) {
+	c.metrics = metrics
+}
+
+// SetWebhookCallbackURL sets the URL the outgoing webhooks created by the user
+// are delivered to. Outgoing webhooks aren't created if it's empty.
+func (c *SimulController) SetWebhookCallbackURL(url string) {
+	c.webhookCallbackURL = url
+}
+
+func (c *SimulController) observeAction(name string, elapsed float64, failed bool) {
+	if c.metrics == nil || name == "" {
+		return
+	}
+	c.metrics.ActionTimes.WithLabelValues(name, c.persona).Observe(elapsed)
+	if failed {
+		c.metrics.ActionErrors.WithLabelValues(name, c.persona).Inc()
+	}
+}
+
 // SetRate sets the relative speed of execution of actions by the user.
 func (c *SimulController) SetRate(rate float64) error {
 	if rate < 0 {
@@ -12,6 +12,10 @@ import (
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/plugins"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/store/memstore"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/user/userentity"
+	"github.com/mattermost/mattermost-load-test-ng/performance"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -163,3 +167,197 @@ func TestAgentsActionsInActionMapWhenEnabled(t *testing.T) {
 	_, hasBareDM := c.actionMap["AskAgentDM"]
 	require.False(t, hasBareDM, "Agents actions must be prefixed by plugin ID in maps and logs")
 }
+
+func TestPersonas(t *testing.T) {
+	newPersonaController := func(t *testing.T, personas []Persona) (*SimulController, *Config, error) {
+		t.Helper()
+
+		config, err := ReadConfig("")
+		require.NoError(t, err)
+		config.Personas = personas
+
+		store, err := memstore.New(nil)
+		require.NoError(t, err)
+		user := userentity.New(userentity.Setup{Store: store}, userentity.Config{
+			ServerURL:    "http://localhost:8065",
+			WebSocketURL: "ws://localhost:8065",
+		})
+
+		c, err := New(1, user, config, make(chan control.UserStatus))
+		return c, config, err
+	}
+
+	lurker := Persona{
+		Name:               "lurker",
+		Percentage:         1,
+		MinIdleTimeMs:      model.NewPointer(5000),
+		AvgIdleTimeMs:      model.NewPointer(60000),
+		PercentUrgentPosts: model.NewPointer(0.0),
+		PercentReplies:     model.NewPointer(0.5),
+		ActionFrequencies: map[string]float64{
+			"CreatePost":    0,
+			"SwitchChannel": 10,
+		},
+	}
+
+	t.Run("no personas", func(t *testing.T) {
+		c, _, err := newPersonaController(t, nil)
+		require.NoError(t, err)
+		require.Equal(t, defaultPersona, c.persona)
+		require.Equal(t, 1.0, c.actionMap["CreatePost"].frequency)
+		// Webhook actions are disabled by default.
+		require.Zero(t, c.actionMap["PostToIncomingWebhook"].frequency)
+	})
+
+	t.Run("settings applied", func(t *testing.T) {
+		c, config, err := newPersonaController(t, []Persona{lurker})
+		require.NoError(t, err)
+		require.Equal(t, "lurker", c.persona)
+		require.Equal(t, 5000, c.config.MinIdleTimeMs)
+		require.Equal(t, 60000, c.config.AvgIdleTimeMs)
+		require.Equal(t, 0.0, c.config.PercentUrgentPosts)
+		require.Equal(t, 0.5, c.config.PercentReplies)
+		require.Equal(t, 0.0, c.actionMap["CreatePost"].frequency)
+		require.Equal(t, 10.0, c.actionMap["SwitchChannel"].frequency)
+		require.Equal(t, 0.1306, c.actionMap["AddReaction"].frequency)
+
+		// The shared config is left untouched.
+		require.Equal(t, 1000, config.MinIdleTimeMs)
+		require.Equal(t, 0.18, config.PercentReplies)
+	})
+
+	t.Run("unset settings inherited", func(t *testing.T) {
+		persona := Persona{
+			Name:           "replier",
+			Percentage:     1,
+			PercentReplies: model.NewPointer(0.9),
+		}
+		c, _, err := newPersonaController(t, []Persona{persona})
+		require.NoError(t, err)
+		require.Equal(t, "replier", c.persona)
+		require.Equal(t, 1000, c.config.MinIdleTimeMs)
+		require.Equal(t, 20000, c.config.AvgIdleTimeMs)
+		require.Equal(t, 0.001, c.config.PercentUrgentPosts)
+		require.Equal(t, 0.9, c.config.PercentReplies)
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		persona := lurker
+		persona.ActionFrequencies = map[string]float64{"Unknown": 1}
+		_, _, err := newPersonaController(t, []Persona{persona})
+		require.EqualError(t, err, `unknown action "Unknown" in persona "lurker"`)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		other := lurker
+		other.Name = "other"
+		_, _, err := newPersonaController(t, []Persona{lurker, other})
+		require.EqualError(t, err, "could not validate configuration: Percentages in Personas should sum to 1")
+
+		other.Percentage = 0.5
+		persona := lurker
+		persona.Percentage = 0.5
+		persona.Name = "other"
+		_, _, err = newPersonaController(t, []Persona{persona, other})
+		require.EqualError(t, err, `could not validate configuration: duplicate persona name "other"`)
+
+		persona = lurker
+		persona.AvgIdleTimeMs = model.NewPointer(0)
+		_, _, err = newPersonaController(t, []Persona{persona})
+		require.EqualError(t, err, `could not validate configuration: AvgIdleTimeMs of persona "lurker" should be greater than MinIdleTimeMs`)
+
+		// The settings of the persona are checked against the inherited ones.
+		persona = Persona{Name: "lurker", Percentage: 1, AvgIdleTimeMs: model.NewPointer(500)}
+		_, _, err = newPersonaController(t, []Persona{persona})
+		require.EqualError(t, err, `could not validate configuration: AvgIdleTimeMs of persona "lurker" should be greater than MinIdleTimeMs`)
+
+		persona = lurker
+		persona.PercentReplies = model.NewPointer(1.5)
+		_, _, err = newPersonaController(t, []Persona{persona})
+		require.EqualError(t, err, `could not validate configuration: PercentReplies of persona "lurker" should be within [0,1]`)
+	})
+}
+
+func TestObserveAction(t *testing.T) {
+	c, statusChan := newController(t)
+	close(statusChan) // not used
+
+	// Without metrics, nothing is recorded.
+	c.observeAction("CreatePost", 0.1, true)
+
+	metrics := performance.NewMetrics().ActionMetrics()
+	c.SetActionMetrics(metrics)
+	c.persona = "lurker"
+	c.observeAction("CreatePost", 0.1, false)
+	c.observeAction("CreatePost", 0.2, true)
+	c.observeAction("", 0.2, true)
+
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.ActionTimes))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.ActionErrors.WithLabelValues("CreatePost", "lurker")))
+}
+
+func TestWebhookActions(t *testing.T) {
+	c, statusChan := newController(t)
+	close(statusChan) // not used
+
+	s, err := memstore.New(nil)
+	require.NoError(t, err)
+	u := userentity.New(userentity.Setup{Store: s}, userentity.Config{})
+
+	s.SetClientConfig(map[string]string{
+		"EnableIncomingWebhooks": "false",
+		"EnableOutgoingWebhooks": "false",
+	})
+	require.Equal(t, "incoming webhooks not enabled", c.createIncomingWebhook(u).Info)
+	require.Equal(t, "incoming webhooks not enabled", c.postToIncomingWebhook(u).Info)
+	require.Equal(t, "no webhook receiver configured", c.createOutgoingWebhook(u).Info)
+
+	c.SetWebhookCallbackURL("http://localhost:4010")
+	require.Equal(t, "outgoing webhooks not enabled", c.createOutgoingWebhook(u).Info)
+
+	s.SetClientConfig(map[string]string{
+		"EnableIncomingWebhooks": "true",
+		"EnableOutgoingWebhooks": "true",
+	})
+	require.Equal(t, "no incoming webhooks in store", c.postToIncomingWebhook(u).Info)
+}
+
+func TestWebhookActionFrequencies(t *testing.T) {
+	newWebhookController := func(t *testing.T, personas []Persona) *SimulController {
+		t.Helper()
+
+		config, err := ReadConfig("")
+		require.NoError(t, err)
+		config.CreateIncomingWebhookFrequency = 0.0005
+		config.PostToIncomingWebhookFrequency = 0.05
+		config.CreateOutgoingWebhookFrequency = 0.0002
+		config.Personas = personas
+		require.NoError(t, config.IsValid())
+
+		store, err := memstore.New(nil)
+		require.NoError(t, err)
+		user := userentity.New(userentity.Setup{Store: store}, userentity.Config{})
+
+		c, err := New(1, user, config, make(chan control.UserStatus))
+		require.NoError(t, err)
+		return c
+	}
+
+	t.Run("from config", func(t *testing.T) {
+		c := newWebhookController(t, nil)
+		require.Equal(t, 0.0005, c.actionMap["CreateIncomingWebhook"].frequency)
+		require.Equal(t, 0.05, c.actionMap["PostToIncomingWebhook"].frequency)
+		require.Equal(t, 0.0002, c.actionMap["CreateOutgoingWebhook"].frequency)
+	})
+
+	t.Run("overridden by persona", func(t *testing.T) {
+		c := newWebhookController(t, []Persona{{
+			Name:              "integrator",
+			Percentage:        1,
+			ActionFrequencies: map[string]float64{"PostToIncomingWebhook": 0.5},
+		}})
+		require.Equal(t, 0.0005, c.actionMap["CreateIncomingWebhook"].frequency)
+		require.Equal(t, 0.5, c.actionMap["PostToIncomingWebhook"].frequency)
+		require.Equal(t, 0.0002, c.actionMap["CreateOutgoingWebhook"].frequency)
+	})
+}
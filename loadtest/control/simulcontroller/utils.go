@@ -10,6 +10,7 @@ import (
 	"math"
 	"math/rand"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/control"
@@ -21,6 +22,40 @@ import (
 var errNoMatch = errors.New("could not match username")
 var userMentionRe = regexp.MustCompile(`@[a-z0-9_.-]+`)
 
+// pickPersona randomly selects a persona with probability proportional to its
+// percentage.
+func pickPersona(personas []Persona) (*Persona, error) {
+	weights := make([]int, len(personas))
+	for i := range personas {
+		weights[i] = int(math.Round(personas[i].Percentage * 100))
+	}
+
+	idx, err := control.SelectWeighted(weights)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pick persona: %w", err)
+	}
+
+	return &personas[idx], nil
+}
+
+// applyActionFrequencies overrides the frequencies of the given actions with
+// those of the persona.
+func applyActionFrequencies(actions []userAction, persona *Persona) error {
+	for name, freq := range persona.ActionFrequencies {
+		idx := slices.IndexFunc(actions, func(a userAction) bool { return a.name == name })
+		if idx == -1 {
+			return fmt.Errorf("unknown action %q in persona %q", name, persona.Name)
+		}
+		actions[idx].frequency = freq
+	}
+
+	if !slices.ContainsFunc(actions, func(a userAction) bool { return a.frequency > 0 }) {
+		return fmt.Errorf("all actions of persona %q have zero frequency", persona.Name)
+	}
+
+	return nil
+}
+
 // pickAction randomly selects an action from a slice of userAction with
 // probability proportional to the action's frequency.
 func pickAction(actions []userAction) (*userAction, error) {
@@ -0,0 +1,141 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package simulcontroller
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/control"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/store"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/store/memstore"
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/user"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// probabilityWebhookAttachments is the probability of a request to an
+// incoming webhook carrying message attachments rather than plain text.
+const probabilityWebhookAttachments = 0.5
+
+func (c *SimulController) createIncomingWebhook(u user.User) control.UserActionResponse {
+	if ok, resp := control.IncomingWebhooksEnabled(u); resp.Err != nil {
+		return resp
+	} else if !ok {
+		return control.UserActionResponse{Info: "incoming webhooks not enabled"}
+	}
+
+	channel, resp := webhookChannel(u, store.SelectMemberOf|store.SelectNotDirect|store.SelectNotGroup)
+	if channel == nil {
+		return resp
+	}
+
+	hookID, err := u.CreateIncomingWebhook(&model.IncomingWebhook{
+		ChannelId:   channel.Id,
+		DisplayName: control.PickRandomWord(),
+		Description: control.GenerateRandomSentences(1),
+	})
+	if isForbidden(err) {
+		return control.UserActionResponse{Info: "user lacks permission to create incoming webhooks"}
+	} else if err != nil {
+		return control.UserActionResponse{Err: control.NewUserError(err)}
+	}
+
+	return control.UserActionResponse{Info: fmt.Sprintf("incoming webhook created, id %s", hookID)}
+}
+
+func (c *SimulController) postToIncomingWebhook(u user.User) control.UserActionResponse {
+	if ok, resp := control.IncomingWebhooksEnabled(u); resp.Err != nil {
+		return resp
+	} else if !ok {
+		return control.UserActionResponse{Info: "incoming webhooks not enabled"}
+	}
+
+	hook, err := u.Store().RandomIncomingWebhook()
+	if errors.Is(err, memstore.ErrWebhookStoreEmpty) {
+		return control.UserActionResponse{Info: "no incoming webhooks in store"}
+	} else if err != nil {
+		return control.UserActionResponse{Err: control.NewUserError(err)}
+	}
+
+	request := &model.IncomingWebhookRequest{}
+	if rand.Float64() < probabilityWebhookAttachments {
+		request.Attachments = []*model.MessageAttachment{control.RandomMessageAttachment()}
+	} else {
+		request.Text = control.GenerateRandomSentences(rand.Intn(10) + 1)
+	}
+
+	if err := u.PostToIncomingWebhook(hook.Id, request); err != nil {
+		return control.UserActionResponse{Err: control.NewUserError(err)}
+	}
+
+	return control.UserActionResponse{Info: fmt.Sprintf("posted to incoming webhook %s", hook.Id)}
+}
+
+// createOutgoingWebhook creates an outgoing webhook triggered by every post in
+// a public channel, delivered to the receiver of the agent. Posts made by the
+// users in the channel afterwards are then delivered to it.
+func (c *SimulController) createOutgoingWebhook(u user.User) control.UserActionResponse {
+	if c.webhookCallbackURL == "" {
+		return control.UserActionResponse{Info: "no webhook receiver configured"}
+	}
+
+	if ok, resp := control.OutgoingWebhooksEnabled(u); resp.Err != nil {
+		return resp
+	} else if !ok {
+		return control.UserActionResponse{Info: "outgoing webhooks not enabled"}
+	}
+
+	// Outgoing webhooks can only be set on public channels.
+	channel, resp := webhookChannel(u, store.SelectMemberOf|store.SelectNotPrivate|store.SelectNotDirect|store.SelectNotGroup)
+	if channel == nil {
+		return resp
+	}
+
+	hookID, err := u.CreateOutgoingWebhook(&model.OutgoingWebhook{
+		ChannelId:    channel.Id,
+		TeamId:       channel.TeamId,
+		DisplayName:  control.PickRandomWord(),
+		CallbackURLs: []string{c.webhookCallbackURL},
+		ContentType:  "application/json",
+	})
+	if isForbidden(err) {
+		return control.UserActionResponse{Info: "user lacks permission to create outgoing webhooks"}
+	} else if err != nil {
+		return control.UserActionResponse{Err: control.NewUserError(err)}
+	}
+
+	return control.UserActionResponse{Info: fmt.Sprintf("outgoing webhook created, id %s", hookID)}
+}
+
+// webhookChannel returns a random channel of the current team, matching the
+// given selection. If none could be picked, the response to return is given
+// instead.
+func webhookChannel(u user.User, sel store.SelectionType) (*model.Channel, control.UserActionResponse) {
+	team, err := u.Store().CurrentTeam()
+	if err != nil {
+		return nil, control.UserActionResponse{Err: control.NewUserError(err)}
+	} else if team == nil {
+		return nil, control.UserActionResponse{Err: control.NewUserError(errors.New("current team should be set"))}
+	}
+
+	channel, err := u.Store().RandomChannel(team.Id, sel)
+	if errors.Is(err, memstore.ErrChannelStoreEmpty) {
+		return nil, control.UserActionResponse{Info: "no channels in store"}
+	} else if err != nil {
+		return nil, control.UserActionResponse{Err: control.NewUserError(err)}
+	}
+
+	return &channel, control.UserActionResponse{}
+}
+
+// isForbidden reports whether the given error is the server denying the user
+// the permission to run the request, as is the case for most users when it
+// comes to managing integrations.
+func isForbidden(err error) bool {
+	var appErr *model.AppError
+	return errors.As(err, &appErr) && appErr.StatusCode == http.StatusForbidden
+}
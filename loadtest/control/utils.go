@@ -224,6 +224,22 @@ func PickRandomString(strings []string) string {
 	return strings[rand.Intn(len(strings))]
 }
 
+// RandomMessageAttachment returns a message attachment with random content,
+// similar to the ones posted by integrations.
+func RandomMessageAttachment() *model.MessageAttachment {
+	title := GenerateRandomSentences(rand.Intn(4) + 1)
+	return &model.MessageAttachment{
+		Fallback: title,
+		Color:    PickRandomString([]string{"good", "warning", "danger"}),
+		Title:    title,
+		Text:     GenerateRandomSentences(rand.Intn(20) + 1),
+		Fields: []*model.MessageAttachmentField{
+			{Title: "Status", Value: PickRandomWord(), Short: true},
+			{Title: "Reference", Value: model.NewId(), Short: true},
+		},
+	}
+}
+
 // GeneratePostsSearchTerm generates a posts search term from the given
 // words and options.
 func GeneratePostsSearchTerm(words []string, opts PostsSearchOpts) string {
@@ -14,6 +14,7 @@ import (
 
 	"github.com/blang/semver"
 	"github.com/mattermost/mattermost-load-test-ng/loadtest/store/memstore"
+	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -96,6 +97,18 @@ func TestAddLink(t *testing.T) {
 	assert.Contains(t, links, words[2])
 }
 
+func TestRandomMessageAttachment(t *testing.T) {
+	attachment := RandomMessageAttachment()
+	require.NotEmpty(t, attachment.Title)
+	require.Equal(t, attachment.Title, attachment.Fallback)
+	require.NotEmpty(t, attachment.Text)
+	require.Contains(t, []string{"good", "warning", "danger"}, attachment.Color)
+	require.Len(t, attachment.Fields, 2)
+	require.Equal(t, "Status", attachment.Fields[0].Title)
+	require.Equal(t, "Reference", attachment.Fields[1].Title)
+	require.True(t, model.IsValidId(attachment.Fields[1].Value.(string)))
+}
+
 func TestSelectWeighted(t *testing.T) {
 	t.Run("empty weights", func(t *testing.T) {
 		idx, err := SelectWeighted([]int{})
@@ -0,0 +1,348 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package loadtest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strings"
+
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/user/userentity"
+)
+
+// Credentials holds the information needed by a user to authenticate.
+type Credentials struct {
+	Username string
+	Email    string
+	Password string
+	// The type of authentication, one of the userentity.AuthenticationType*
+	// values.
+	AuthenticationType string
+	// The personal access token used in place of the password when
+	// authenticating with a token.
+	Token string
+}
+
+// CredentialsProvider provides the credentials of the users.
+type CredentialsProvider interface {
+	// Credentials returns the credentials of the user with the given id, and
+	// whether the provider has any for that user.
+	Credentials(id int) (Credentials, bool)
+}
+
+// CredentialsList is a CredentialsProvider returning the credentials at the
+// index given by the id of the user.
+type CredentialsList []Credentials
+
+// Credentials implements the CredentialsProvider interface.
+func (l CredentialsList) Credentials(id int) (Credentials, bool) {
+	if id < 0 || id >= len(l) {
+		return Credentials{}, false
+	}
+	return l[id], true
+}
+
+// CredentialsProviders is a CredentialsProvider returning the credentials of
+// the first provider having any for the user.
+type CredentialsProviders []CredentialsProvider
+
+// Credentials implements the CredentialsProvider interface.
+func (p CredentialsProviders) Credentials(id int) (Credentials, bool) {
+	for _, provider := range p {
+		if creds, ok := provider.Credentials(id); ok {
+			return creds, true
+		}
+	}
+	return Credentials{}, false
+}
+
+// CredentialsGenerator is a CredentialsProvider generating the credentials of
+// any user from a name prefix and its id.
+type CredentialsGenerator struct {
+	NamePrefix     string
+	PasswordPolicy PasswordPolicyConfiguration
+}
+
+// Credentials implements the CredentialsProvider interface.
+func (g *CredentialsGenerator) Credentials(id int) (Credentials, bool) {
+	username := fmt.Sprintf("%s-%d", g.NamePrefix, id)
+	return Credentials{
+		Username:           username,
+		Email:              username + "@example.com",
+		Password:           g.password(username),
+		AuthenticationType: userentity.AuthenticationTypeMattermost,
+	}, true
+}
+
+const (
+	passwordLowercase = "abcdefghijklmnopqrstuvwxyz"
+	passwordUppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	passwordNumbers   = "0123456789"
+	passwordSymbols   = "!#$%&*+-.:;=?@^_~"
+)
+
+// password returns the password of the user with the given username. Unless
+// the policy sets a fixed password, it's derived from the username, so that
+// the user gets the same one across runs.
+func (g *CredentialsGenerator) password(username string) string {
+	policy := g.PasswordPolicy
+	if policy.FixedPassword != "" {
+		return policy.FixedPassword
+	}
+
+	rnd := rand.New(rand.NewChaCha8(sha256.Sum256([]byte(username))))
+	pick := func(chars string) byte {
+		return chars[rnd.IntN(len(chars))]
+	}
+
+	// At least one character of each required class is included.
+	chars := passwordLowercase
+	password := []byte{pick(passwordLowercase)}
+	if policy.RequireUppercase {
+		chars += passwordUppercase
+		password = append(password, pick(passwordUppercase))
+	}
+	if policy.RequireNumber {
+		chars += passwordNumbers
+		password = append(password, pick(passwordNumbers))
+	}
+	if policy.RequireSymbol {
+		chars += passwordSymbols
+		password = append(password, pick(passwordSymbols))
+	}
+	for len(password) < policy.Length {
+		password = append(password, pick(chars))
+	}
+	rnd.Shuffle(len(password), func(i, j int) {
+		password[i], password[j] = password[j], password[i]
+	})
+
+	return string(password)
+}
+
+// NewCredentialsProvider returns the provider of the credentials of the users
+// described by the given config. The credentials are read from UsersFilePath,
+// if set, and generated for the remaining users.
+func NewCredentialsProvider(config *UsersConfiguration, namePrefix string) (CredentialsProvider, error) {
+	generator := &CredentialsGenerator{
+		NamePrefix:     namePrefix,
+		PasswordPolicy: config.PasswordPolicy,
+	}
+	if config.UsersFilePath == "" {
+		return generator, nil
+	}
+
+	creds, err := ReadCredentials(config.UsersFilePath, config.UsersFileFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	return CredentialsProviders{creds, generator}, nil
+}
+
+// ReadCredentials reads the list of credentials from the file at the given
+// path, in the given format.
+func ReadCredentials(path, format string) (CredentialsList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", path, err)
+	}
+	defer f.Close()
+
+	var creds CredentialsList
+	switch format {
+	case UsersFileFormatText, "":
+		creds, err = parseTextCredentials(f)
+	case UsersFileFormatCSV:
+		creds, err = parseCSVCredentials(f)
+	case UsersFileFormatJSONL:
+		creds, err = parseJSONLCredentials(f)
+	default:
+		return nil, fmt.Errorf("unsupported credentials format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials from %q: %w", path, err)
+	}
+
+	return creds, nil
+}
+
+func parseTextCredentials(r io.Reader) (CredentialsList, error) {
+	var creds CredentialsList
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Emails and passwords are separated by space.
+		split := strings.Split(line, " ")
+		if len(split) < 2 {
+			return nil, fmt.Errorf("user credential %q does not have space in between", line)
+		}
+		c := Credentials{
+			Email:              split[0],
+			Password:           split[1],
+			AuthenticationType: userentity.AuthenticationTypeMattermost,
+		}
+
+		// Check if the user has a custom authentication type. Custom authentication types are
+		// specified by prepending the email with the authentication type followed by a colon.
+		// Example: "openid:email1@xample.com"
+		if strings.Contains(c.Email, ":") {
+			split := strings.Split(c.Email, ":")
+			if len(split) != 2 {
+				return nil, fmt.Errorf("invalid custom authentication found in %q", c.Email)
+			}
+			c.AuthenticationType = split[0]
+			c.Email = split[1]
+		}
+
+		// The password field holds the token of users authenticating with a
+		// token.
+		if c.AuthenticationType == userentity.AuthenticationTypeToken {
+			c.Token, c.Password = c.Password, ""
+		}
+
+		if err := c.complete(); err != nil {
+			return nil, err
+		}
+		creds = append(creds, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return creds, nil
+}
+
+func parseCSVCredentials(r io.Reader) (CredentialsList, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var creds CredentialsList
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		var c Credentials
+		for i, column := range header {
+			switch strings.ToLower(strings.TrimSpace(column)) {
+			case "username":
+				c.Username = record[i]
+			case "email":
+				c.Email = record[i]
+			case "password":
+				c.Password = record[i]
+			case "auth_service":
+				c.AuthenticationType = record[i]
+			case "token":
+				c.Token = record[i]
+			default:
+				return nil, fmt.Errorf("unknown column %q", column)
+			}
+		}
+
+		if err := c.complete(); err != nil {
+			return nil, err
+		}
+		creds = append(creds, c)
+	}
+
+	return creds, nil
+}
+
+func parseJSONLCredentials(r io.Reader) (CredentialsList, error) {
+	var creds CredentialsList
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry struct {
+			Username    string `json:"username"`
+			Email       string `json:"email"`
+			Password    string `json:"password"`
+			AuthService string `json:"auth_service"`
+			Token       string `json:"token"`
+		}
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("invalid user credential %q: %w", line, err)
+		}
+
+		c := Credentials{
+			Username:           entry.Username,
+			Email:              entry.Email,
+			Password:           entry.Password,
+			AuthenticationType: entry.AuthService,
+			Token:              entry.Token,
+		}
+		if err := c.complete(); err != nil {
+			return nil, err
+		}
+		creds = append(creds, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return creds, nil
+}
+
+// complete checks the credentials read from a file, filling in the missing
+// fields which can be inferred.
+func (c *Credentials) complete() error {
+	if c.AuthenticationType == "" {
+		c.AuthenticationType = userentity.AuthenticationTypeMattermost
+		if c.Token != "" {
+			c.AuthenticationType = userentity.AuthenticationTypeToken
+		}
+	}
+
+	switch c.AuthenticationType {
+	case userentity.AuthenticationTypeMattermost, userentity.AuthenticationTypeOpenID, userentity.AuthenticationTypeSAML:
+		if c.Password == "" {
+			return fmt.Errorf("missing password for user %q", c.Email)
+		}
+	case userentity.AuthenticationTypeToken:
+		if c.Token == "" {
+			return fmt.Errorf("missing token for user %q", c.Email)
+		}
+	default:
+		return fmt.Errorf("invalid custom authentication type %q", c.AuthenticationType)
+	}
+
+	emailParts := strings.Split(c.Email, "@")
+	if len(emailParts) != 2 {
+		return fmt.Errorf("invalid email %q", c.Email)
+	}
+
+	if c.Username == "" {
+		// Quick and dirty hack to extract username from email.
+		// This is not terribly important to be correct.
+		c.Username = strings.Replace(emailParts[0], "+", "-", -1)
+	}
+
+	return nil
+}
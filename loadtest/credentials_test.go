@@ -0,0 +1,258 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package loadtest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/user/userentity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCredentials(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		format        string
+		fileContents  []string
+		expectedCreds CredentialsList
+		expectErr     bool
+	}{
+		{
+			name:   "get simple credentials",
+			format: UsersFileFormatText,
+			expectedCreds: CredentialsList{{
+				Email:              "email1@sample.mattermost.com",
+				Password:           "password",
+				Username:           "email1",
+				AuthenticationType: userentity.AuthenticationTypeMattermost,
+			}, {
+				Email:              "email2@sample.mattermost.com",
+				Password:           "password",
+				Username:           "email2",
+				AuthenticationType: userentity.AuthenticationTypeMattermost,
+			}},
+			fileContents: []string{
+				"email1@sample.mattermost.com password",
+				"email2@sample.mattermost.com password",
+			},
+		}, {
+			name:   "get credentials with custom auth provider",
+			format: UsersFileFormatText,
+			expectedCreds: CredentialsList{{
+				Email:              "email1@sample.mattermost.com",
+				Password:           "password",
+				Username:           "email1",
+				AuthenticationType: userentity.AuthenticationTypeOpenID,
+			}},
+			fileContents: []string{
+				"openid:email1@sample.mattermost.com password",
+			},
+		}, {
+			name:   "get credentials with token",
+			format: UsersFileFormatText,
+			expectedCreds: CredentialsList{{
+				Email:              "email1+bot@sample.mattermost.com",
+				Username:           "email1-bot",
+				AuthenticationType: userentity.AuthenticationTypeToken,
+				Token:              "sometoken",
+			}},
+			fileContents: []string{
+				"token:email1+bot@sample.mattermost.com sometoken",
+			},
+		}, {
+			name:      "incorrect auth provider",
+			format:    UsersFileFormatText,
+			expectErr: true,
+			fileContents: []string{
+				"incorrect:email@sample.mattermost.com password",
+			},
+		}, {
+			name:      "incorrect number of fields",
+			format:    UsersFileFormatText,
+			expectErr: true,
+			fileContents: []string{
+				"bogus",
+			},
+		}, {
+			name:   "csv",
+			format: UsersFileFormatCSV,
+			expectedCreds: CredentialsList{{
+				Email:              "email1@sample.mattermost.com",
+				Password:           "password",
+				Username:           "user1",
+				AuthenticationType: userentity.AuthenticationTypeMattermost,
+			}, {
+				Email:              "email2@sample.mattermost.com",
+				Password:           "password",
+				Username:           "email2",
+				AuthenticationType: userentity.AuthenticationTypeSAML,
+			}, {
+				Email:              "email3@sample.mattermost.com",
+				Username:           "email3",
+				AuthenticationType: userentity.AuthenticationTypeToken,
+				Token:              "sometoken",
+			}},
+			fileContents: []string{
+				"Email,Username,Password,Auth_Service,Token",
+				"email1@sample.mattermost.com,user1,password,,",
+				"email2@sample.mattermost.com,,password,saml,",
+				"email3@sample.mattermost.com,,,,sometoken",
+			},
+		}, {
+			name:      "csv with unknown column",
+			format:    UsersFileFormatCSV,
+			expectErr: true,
+			fileContents: []string{
+				"email,password,bogus",
+				"email1@sample.mattermost.com,password,bogus",
+			},
+		}, {
+			name:      "csv with missing password",
+			format:    UsersFileFormatCSV,
+			expectErr: true,
+			fileContents: []string{
+				"email,password",
+				"email1@sample.mattermost.com,",
+			},
+		}, {
+			name:   "jsonl",
+			format: UsersFileFormatJSONL,
+			expectedCreds: CredentialsList{{
+				Email:              "email1@sample.mattermost.com",
+				Password:           "password",
+				Username:           "user1",
+				AuthenticationType: userentity.AuthenticationTypeMattermost,
+			}, {
+				Email:              "email2@sample.mattermost.com",
+				Username:           "email2",
+				AuthenticationType: userentity.AuthenticationTypeToken,
+				Token:              "sometoken",
+			}},
+			fileContents: []string{
+				`{"email": "email1@sample.mattermost.com", "username": "user1", "password": "password"}`,
+				``,
+				`{"email": "email2@sample.mattermost.com", "token": "sometoken"}`,
+			},
+		}, {
+			name:      "jsonl with unknown field",
+			format:    UsersFileFormatJSONL,
+			expectErr: true,
+			fileContents: []string{
+				`{"email": "email1@sample.mattermost.com", "password": "password", "bogus": true}`,
+			},
+		}, {
+			name:      "jsonl with invalid email",
+			format:    UsersFileFormatJSONL,
+			expectErr: true,
+			fileContents: []string{
+				`{"email": "bogus", "password": "password"}`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "credentials")
+			err := os.WriteFile(path, []byte(strings.Join(tc.fileContents, "\n")), 0600)
+			require.NoError(t, err)
+
+			creds, err := ReadCredentials(path, tc.format)
+
+			if tc.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedCreds, creds)
+			}
+		})
+	}
+}
+
+func TestCredentialsGenerator(t *testing.T) {
+	t.Run("fixed password", func(t *testing.T) {
+		g := &CredentialsGenerator{
+			NamePrefix:     "testuser",
+			PasswordPolicy: PasswordPolicyConfiguration{FixedPassword: "testPass123$"},
+		}
+		creds, ok := g.Credentials(42)
+		require.True(t, ok)
+		require.Equal(t, Credentials{
+			Username:           "testuser-42",
+			Email:              "testuser-42@example.com",
+			Password:           "testPass123$",
+			AuthenticationType: userentity.AuthenticationTypeMattermost,
+		}, creds)
+	})
+
+	t.Run("password policy", func(t *testing.T) {
+		g := &CredentialsGenerator{
+			NamePrefix: "testuser",
+			PasswordPolicy: PasswordPolicyConfiguration{
+				Length:           16,
+				RequireUppercase: true,
+				RequireNumber:    true,
+				RequireSymbol:    true,
+			},
+		}
+
+		creds, ok := g.Credentials(1)
+		require.True(t, ok)
+		password := creds.Password
+		require.Len(t, password, 16)
+		require.True(t, strings.ContainsFunc(password, unicode.IsLower))
+		require.True(t, strings.ContainsFunc(password, unicode.IsUpper))
+		require.True(t, strings.ContainsFunc(password, unicode.IsDigit))
+		require.True(t, strings.ContainsAny(password, passwordSymbols))
+
+		// The same user always gets the same password.
+		creds, _ = g.Credentials(1)
+		require.Equal(t, password, creds.Password)
+		creds, _ = g.Credentials(2)
+		require.NotEqual(t, password, creds.Password)
+	})
+
+	t.Run("no required classes", func(t *testing.T) {
+		g := &CredentialsGenerator{
+			NamePrefix:     "testuser",
+			PasswordPolicy: PasswordPolicyConfiguration{Length: 8},
+		}
+		creds, _ := g.Credentials(1)
+		require.Len(t, creds.Password, 8)
+		require.Equal(t, strings.ToLower(creds.Password), creds.Password)
+		require.False(t, strings.ContainsFunc(creds.Password, unicode.IsDigit))
+	})
+}
+
+func TestNewCredentialsProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	err := os.WriteFile(path, []byte("email1@sample.mattermost.com password\n"), 0600)
+	require.NoError(t, err)
+
+	config := &UsersConfiguration{
+		UsersFilePath:   path,
+		UsersFileFormat: UsersFileFormatText,
+		PasswordPolicy:  PasswordPolicyConfiguration{FixedPassword: "testPass123$"},
+	}
+	provider, err := NewCredentialsProvider(config, "testuser")
+	require.NoError(t, err)
+
+	// The credentials from the file come first.
+	creds, ok := provider.Credentials(0)
+	require.True(t, ok)
+	require.Equal(t, "email1", creds.Username)
+	require.Equal(t, "password", creds.Password)
+
+	// The remaining users get generated ones.
+	creds, ok = provider.Credentials(1)
+	require.True(t, ok)
+	require.Equal(t, "testuser-1", creds.Username)
+	require.Equal(t, "testPass123$", creds.Password)
+
+	config.UsersFilePath = filepath.Join(t.TempDir(), "missing")
+	_, err = NewCredentialsProvider(config, "testuser")
+	require.Error(t, err)
+}
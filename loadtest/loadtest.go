@@ -35,8 +35,20 @@ type LoadTester struct {
 	activeControllers []control.UserController
 	idleControllers   []control.UserController
 
+	// The status channels of the controller populations, if any. Statuses
+	// are accounted for each population before being forwarded to statusChan.
+	populationChans  []chan control.UserStatus
+	populationWg     sync.WaitGroup
+	populationStatus []PopulationStatus
+	// The index of the population each controller belongs to.
+	controllerPopulation map[control.UserController]int
+
 	isBrowserAgent bool
 
+	// The optional receiver of the outgoing webhooks registered by the users,
+	// running along with the load-test.
+	webhookReceiver *WebhookReceiver
+
 	log *mlog.Logger
 }
 
@@ -71,6 +83,28 @@ func (lt *LoadTester) handleStatus(startedChan chan struct{}) {
 	}
 }
 
+// forwardPopulationStatus accounts for the statuses of the controllers of the
+// given population and forwards them to statusChan.
+func (lt *LoadTester) forwardPopulationStatus(population int, ch chan control.UserStatus) {
+	defer lt.populationWg.Done()
+	ps := &lt.populationStatus[population]
+	for st := range ch {
+		switch st.Code {
+		case control.USER_STATUS_STOPPED, control.USER_STATUS_FAILED:
+			atomic.AddInt64(&ps.NumUsersStopped, 1)
+		case control.USER_STATUS_ERROR:
+			atomic.AddInt64(&ps.NumErrors, 1)
+		}
+		lt.statusChan <- st
+	}
+}
+
+// hasPopulations returns whether users are split in controller populations.
+// Browser agents only run browser controllers.
+func (lt *LoadTester) hasPopulations() bool {
+	return len(lt.config.UserControllerConfiguration.Populations) > 0 && !lt.isBrowserAgent
+}
+
 // AddUsers attempts to increment by numUsers the number of concurrently active users.
 // Returns the number of users successfully added.
 func (lt *LoadTester) AddUsers(numUsers int) (int, error) {
@@ -117,11 +151,20 @@ func (lt *LoadTester) addUser() error {
 		if activeUsers != 0 && rand.Int()%lt.config.UsersConfiguration.AvgSessionsPerUser != 0 {
 			userId = rand.Intn(activeUsers)
 		}
+		statusChan := lt.statusChan
+		population := 0
+		if lt.hasPopulations() {
+			population = lt.config.UserControllerConfiguration.PickPopulation(userId)
+			statusChan = lt.populationChans[population]
+		}
 		var err error
-		controller, err = lt.newController(userId, lt.statusChan)
+		controller, err = lt.newController(userId, statusChan)
 		if err != nil {
 			return err
 		}
+		if lt.hasPopulations() {
+			lt.controllerPopulation[controller] = population
+		}
 	}
 
 	rate, err := pickRate(lt.config.UserControllerConfiguration)
@@ -134,6 +177,11 @@ func (lt *LoadTester) addUser() error {
 
 	lt.status.NumUsers++
 	lt.status.NumUsersAdded++
+	if lt.hasPopulations() {
+		ps := &lt.populationStatus[lt.controllerPopulation[controller]]
+		ps.NumUsers++
+		ps.NumUsersAdded++
+	}
 	lt.activeControllers = append(lt.activeControllers, controller)
 
 	lt.wg.Add(1)
@@ -184,6 +232,13 @@ func (lt *LoadTester) removeUsers(numUsers int) (int, error) {
 	lt.activeControllers = lt.activeControllers[:activeUsers-numUsers]
 	lt.status.NumUsers -= int64(numUsers)
 	lt.status.NumUsersRemoved += int64(numUsers)
+	if lt.hasPopulations() {
+		for _, controller := range lt.idleControllers[len(lt.idleControllers)-numUsers:] {
+			ps := &lt.populationStatus[lt.controllerPopulation[controller]]
+			ps.NumUsers--
+			ps.NumUsersRemoved++
+		}
+	}
 
 	return numUsers, err
 }
@@ -209,10 +264,28 @@ func (lt *LoadTester) Run() error {
 	} else {
 		lt.statusChan = make(chan control.UserStatus, lt.config.UsersConfiguration.MaxActiveUsers)
 	}
+	if lt.webhookReceiver != nil {
+		if err := lt.webhookReceiver.Start(); err != nil {
+			lt.status.State = Stopped
+			return fmt.Errorf("failed to start webhook receiver: %w", err)
+		}
+	}
+
 	startedChan := make(chan struct{})
 	go lt.handleStatus(startedChan)
 	<-startedChan
 
+	if lt.hasPopulations() {
+		populations := lt.config.UserControllerConfiguration.Populations
+		lt.populationStatus = make([]PopulationStatus, len(populations))
+		lt.populationChans = make([]chan control.UserStatus, len(populations))
+		for i := range populations {
+			lt.populationChans[i] = make(chan control.UserStatus, cap(lt.statusChan))
+			lt.populationWg.Add(1)
+			go lt.forwardPopulationStatus(i, lt.populationChans[i])
+		}
+	}
+
 	// Do not add initial users if the agent is a browser agent.
 	if !lt.isBrowserAgent {
 		for i := 0; i < lt.config.UsersConfiguration.InitialActiveUsers; i++ {
@@ -241,9 +314,23 @@ func (lt *LoadTester) Stop() error {
 	}
 
 	lt.wg.Wait()
+	for _, ch := range lt.populationChans {
+		close(ch)
+	}
+	lt.populationWg.Wait()
+	lt.populationChans = nil
 	close(lt.statusChan)
+	if lt.webhookReceiver != nil {
+		if err := lt.webhookReceiver.Stop(); err != nil {
+			lt.log.Error(err.Error())
+		}
+	}
 	lt.idleControllers = make([]control.UserController, 0)
+	lt.controllerPopulation = make(map[control.UserController]int)
 	lt.status.NumUsers = 0
+	for i := range lt.populationStatus {
+		lt.populationStatus[i].NumUsers = 0
+	}
 	lt.status.State = Stopped
 	return nil
 }
@@ -257,7 +344,7 @@ func (lt *LoadTester) Status() *Status {
 	numErrors := atomic.LoadInt64(&lt.status.NumErrors)
 	numStopped := atomic.LoadInt64(&lt.status.NumUsersStopped)
 
-	return &Status{
+	status := &Status{
 		State:           lt.status.State,
 		NumUsers:        lt.status.NumUsers,
 		NumUsersAdded:   lt.status.NumUsersAdded,
@@ -266,6 +353,26 @@ func (lt *LoadTester) Status() *Status {
 		NumErrors:       numErrors,
 		StartTime:       lt.status.StartTime,
 	}
+
+	if lt.hasPopulations() {
+		status.Populations = make(map[string]PopulationStatus, len(lt.populationStatus))
+		for i, p := range lt.config.UserControllerConfiguration.Populations {
+			var ps PopulationStatus
+			if i < len(lt.populationStatus) {
+				cur := &lt.populationStatus[i]
+				ps = PopulationStatus{
+					NumUsers:        cur.NumUsers,
+					NumUsersAdded:   cur.NumUsersAdded,
+					NumUsersRemoved: cur.NumUsersRemoved,
+					NumUsersStopped: atomic.LoadInt64(&cur.NumUsersStopped),
+					NumErrors:       atomic.LoadInt64(&cur.NumErrors),
+				}
+			}
+			status.Populations[p.GetName()] = ps
+		}
+	}
+
+	return status
 }
 
 func (lt *LoadTester) InjectAction(action string) error {
@@ -291,6 +398,20 @@ func MaxHTTPConns(maxUsers int) int {
 	return maxUsers / connFactor
 }
 
+// SetWebhookReceiver sets the receiver of the outgoing webhooks, started and
+// stopped along with the load-test.
+// It returns an error if called while the load-test is running.
+func (lt *LoadTester) SetWebhookReceiver(r *WebhookReceiver) error {
+	lt.mut.Lock()
+	defer lt.mut.Unlock()
+
+	if lt.status.State != Stopped {
+		return ErrNotStopped
+	}
+	lt.webhookReceiver = r
+	return nil
+}
+
 // New creates and initializes a new LoadTester with given config. A factory
 // function is also given to enable the creation of UserController values from within the
 // loadtest package.
@@ -326,5 +447,7 @@ func New(config *Config, nc NewController, log *mlog.Logger, isBrowserAgent bool
 		idleControllers:   make([]control.UserController, 0),
 		isBrowserAgent:    isBrowserAgent,
 		log:               log,
+
+		controllerPopulation: make(map[control.UserController]int),
 	}, nil
 }
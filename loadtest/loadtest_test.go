@@ -23,6 +23,14 @@ var ltConfig = Config{
 		WebSocketURL:  "ws://localhost:8065",
 		AdminEmail:    "user@example.com",
 		AdminPassword: "str0ngPassword##",
+		Transport: TransportConfiguration{
+			DialTimeoutMs:         1000,
+			TLSHandshakeTimeoutMs: 1000,
+			RequestTimeoutMs:      5000,
+			IdleConnTimeoutMs:     90000,
+			ConnectionPool:        ConnectionPoolShared,
+			MaxRateLimitBackoffMs: 60000,
+		},
 	},
 	UserControllerConfiguration: UserControllerConfiguration{
 		Type: "simple",
@@ -31,6 +39,23 @@ var ltConfig = Config{
 		MaxActiveUsers:     8,
 		InitialActiveUsers: 0,
 		AvgSessionsPerUser: 1,
+		UsersFileFormat:    UsersFileFormatText,
+		PasswordPolicy: PasswordPolicyConfiguration{
+			FixedPassword: "testPass123$",
+			Length:        12,
+		},
+		StoreLimits: StoreLimitsConfiguration{
+			MaxStoredPosts:          250,
+			MaxStoredUsers:          500,
+			MaxStoredChannelMembers: 500,
+			MaxStoredStatuses:       500,
+			MaxStoredThreads:        250,
+			MaxStoredReactions:      10,
+			MaxStoredChannels:       2000,
+			MaxStoredDrafts:         100,
+			MaxStoredBookmarks:      100,
+			MaxStoredScheduledPosts: 100,
+		},
 	},
 	InstanceConfiguration: InstanceConfiguration{
 		NumTeams:                    1,
@@ -277,3 +302,56 @@ func TestStatus(t *testing.T) {
 	assert.True(t, startTime.Before(st.StartTime))
 	assert.Equal(t, Running, st.State)
 }
+
+func TestPopulations(t *testing.T) {
+	config := ltConfig
+	config.UserControllerConfiguration.Populations = []ControllerPopulation{
+		{Name: "active", Type: UserControllerSimple, Percentage: 0.75},
+		{Name: "idle", Type: UserControllerNoop, Percentage: 0.25},
+	}
+	log := logger.New(&config.LogSettings)
+	lt, err := New(&config, newController, log, false)
+	require.NoError(t, err)
+
+	require.NoError(t, lt.Run())
+
+	n, err := lt.AddUsers(8)
+	require.NoError(t, err)
+	require.Equal(t, 8, n)
+
+	expected := map[string]PopulationStatus{"active": {}, "idle": {}}
+	for id := 1; id <= 8; id++ {
+		name := config.UserControllerConfiguration.Populations[config.UserControllerConfiguration.PickPopulation(id)].Name
+		ps := expected[name]
+		ps.NumUsers++
+		ps.NumUsersAdded++
+		expected[name] = ps
+	}
+
+	status := lt.Status()
+	require.Len(t, status.Populations, 2)
+	for name, ps := range status.Populations {
+		require.Equal(t, expected[name].NumUsers, ps.NumUsers)
+		require.Equal(t, expected[name].NumUsersAdded, ps.NumUsersAdded)
+	}
+
+	n, err = lt.RemoveUsers(8)
+	require.NoError(t, err)
+	require.Equal(t, 8, n)
+
+	status = lt.Status()
+	var removed int64
+	for _, ps := range status.Populations {
+		require.Zero(t, ps.NumUsers)
+		removed += ps.NumUsersRemoved
+	}
+	require.Equal(t, int64(8), removed)
+
+	require.NoError(t, lt.Stop())
+	status = lt.Status()
+	var stopped int64
+	for _, ps := range status.Populations {
+		stopped += ps.NumUsersStopped
+	}
+	require.Equal(t, status.NumUsersStopped, stopped)
+}
@@ -0,0 +1,305 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package report
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-load-test-ng/coordinator/performance/prometheus"
+)
+
+// BundleVersion is the version of the bundle format written by WriteBundle.
+// It must be increased whenever the layout of a bundle changes in a way
+// older versions can't read.
+const BundleVersion = 1
+
+// Names of the files of a bundle.
+const (
+	bundleManifestFile   = "manifest.json"
+	bundleReportFile     = "report.json"
+	bundleStatusFile     = "status.json"
+	bundleDeploymentFile = "deployment.json"
+	bundleMetricsFile    = "metrics.json"
+	bundleConfigDir      = "config/"
+)
+
+// gzipMagic is the magic number bundles start with, since they are gzipped.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// BundleManifest describes the content of a bundle.
+type BundleManifest struct {
+	// The version of the bundle format.
+	Version   int
+	CreatedAt time.Time
+	// The label of the report.
+	Label string
+	// The version of the Mattermost server the load-test ran against, if
+	// known.
+	ServerVersion string `json:",omitempty"`
+	// The names of the config files included in the bundle.
+	ConfigFiles []string
+}
+
+// Bundle is an archive holding a report along with everything needed to
+// understand how it was produced and to generate it again.
+type Bundle struct {
+	Manifest BundleManifest
+	Report   Report
+	// The config files used by the load-test, keyed by file name. These are
+	// the local config files the deployment was created with, not the ones
+	// generated from them for the agents and the coordinator (e.g. with the
+	// addresses of the instances filled in).
+	Configs map[string][]byte
+	// Information about the deployment the load-test ran in, as JSON.
+	DeploymentInfo json.RawMessage
+	// The status of the coordinator once the load-test was done, as JSON.
+	Status json.RawMessage
+	// The raw results of the queries used to generate the report. They can
+	// be replayed through a prometheus.FileSource.
+	Metrics []prometheus.RecordedQuery
+}
+
+// WriteBundle writes the given bundle to w as a gzipped tarball.
+func WriteBundle(w io.Writer, b Bundle) error {
+	b.Manifest.Version = BundleVersion
+	b.Manifest.Label = b.Report.Label
+	if b.Manifest.CreatedAt.IsZero() {
+		b.Manifest.CreatedAt = time.Now()
+	}
+	b.Manifest.ConfigFiles = make([]string, 0, len(b.Configs))
+	for name := range b.Configs {
+		b.Manifest.ConfigFiles = append(b.Manifest.ConfigFiles, name)
+	}
+	sort.Strings(b.Manifest.ConfigFiles)
+
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+
+	writeFile := func(name string, data []byte) error {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: b.Manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write header for %s: %w", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		return nil
+	}
+	writeJSON := func(name string, v any) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		return writeFile(name, data)
+	}
+
+	// The manifest is always written first so that it can be checked before
+	// reading anything else.
+	if err := writeJSON(bundleManifestFile, b.Manifest); err != nil {
+		return err
+	}
+	if err := writeJSON(bundleReportFile, b.Report); err != nil {
+		return err
+	}
+	if len(b.Status) > 0 {
+		if err := writeFile(bundleStatusFile, b.Status); err != nil {
+			return err
+		}
+	}
+	if len(b.DeploymentInfo) > 0 {
+		if err := writeFile(bundleDeploymentFile, b.DeploymentInfo); err != nil {
+			return err
+		}
+	}
+	if len(b.Metrics) > 0 {
+		if err := writeJSON(bundleMetricsFile, b.Metrics); err != nil {
+			return err
+		}
+	}
+	for _, name := range b.Manifest.ConfigFiles {
+		if err := writeFile(bundleConfigDir+name, b.Configs[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to close tar writer: %w", err)
+	}
+	if err := gzw.Close(); err != nil {
+		return fmt.Errorf("failed to close gzip writer: %w", err)
+	}
+	return nil
+}
+
+// ReadBundle reads a bundle, as written by WriteBundle, from r.
+func ReadBundle(r io.Reader) (Bundle, error) {
+	var b Bundle
+
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return b, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	defer gzr.Close()
+
+	var hasManifest, hasReport bool
+	tr := tar.NewReader(gzr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return b, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return b, fmt.Errorf("failed to read %s: %w", hdr.Name, err)
+		}
+
+		name := path.Clean(hdr.Name)
+		if !hasManifest && name != bundleManifestFile {
+			return b, errors.New("invalid bundle: the manifest should be the first file")
+		}
+
+		switch {
+		case name == bundleManifestFile:
+			if err := json.Unmarshal(data, &b.Manifest); err != nil {
+				return b, fmt.Errorf("failed to decode manifest: %w", err)
+			}
+			if b.Manifest.Version < 1 || b.Manifest.Version > BundleVersion {
+				return b, fmt.Errorf("unsupported bundle version %d, the latest supported version is %d", b.Manifest.Version, BundleVersion)
+			}
+			hasManifest = true
+		case name == bundleReportFile:
+			if err := json.Unmarshal(data, &b.Report); err != nil {
+				return b, fmt.Errorf("failed to decode report: %w", err)
+			}
+			hasReport = true
+		case name == bundleStatusFile:
+			b.Status = json.RawMessage(data)
+		case name == bundleDeploymentFile:
+			b.DeploymentInfo = json.RawMessage(data)
+		case name == bundleMetricsFile:
+			if err := json.Unmarshal(data, &b.Metrics); err != nil {
+				return b, fmt.Errorf("failed to decode metrics: %w", err)
+			}
+		case strings.HasPrefix(name, bundleConfigDir):
+			if b.Configs == nil {
+				b.Configs = make(map[string][]byte)
+			}
+			b.Configs[strings.TrimPrefix(name, bundleConfigDir)] = data
+		}
+	}
+
+	if !hasManifest || !hasReport {
+		return b, errors.New("invalid bundle: missing manifest or report")
+	}
+
+	return b, nil
+}
+
+// LoadBundle loads a bundle from the given reader. Plain JSON reports, as
+// written before bundles existed, are loaded as a bundle holding just the
+// report, with a zero manifest version.
+func LoadBundle(r io.Reader) (Bundle, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Bundle{}, err
+	}
+	if bytes.Equal(magic, gzipMagic) {
+		return ReadBundle(br)
+	}
+
+	var b Bundle
+	if err := json.NewDecoder(br).Decode(&b.Report); err != nil {
+		return b, err
+	}
+	b.Manifest.Label = b.Report.Label
+	return b, nil
+}
+
+// DefaultConfigFiles are the paths of the load-test config files which are
+// included in bundles by default.
+var DefaultConfigFiles = []string{
+	"config/config.json",
+	"config/coordinator.json",
+	"config/simplecontroller.json",
+	"config/simulcontroller.json",
+}
+
+// ReadConfigFiles reads the given config files, keyed by file name, to be
+// included in a bundle. Missing files are skipped.
+func ReadConfigFiles(paths []string) (map[string][]byte, error) {
+	configs := make(map[string][]byte, len(paths))
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		configs[filepath.Base(p)] = data
+	}
+	return configs, nil
+}
+
+// NewOfflineSource returns a MetricsSource serving the metrics recorded in
+// the file at the given path, so that reports can be generated without
+// access to the original metrics backend. The file can either be a bundle,
+// which is also returned, or a recording as read by prometheus.NewFileSource,
+// such as the ones written by `ltctl report export`.
+func NewOfflineSource(path string) (prometheus.MetricsSource, *Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open metrics file: %w", err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(f, magic); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("failed to read metrics file: %w", err)
+	}
+	if !bytes.Equal(magic, gzipMagic) {
+		source, err := prometheus.NewFileSource(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return source, nil, nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, fmt.Errorf("failed to read metrics file: %w", err)
+	}
+	b, err := ReadBundle(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(b.Metrics) == 0 {
+		return nil, nil, fmt.Errorf("bundle %q has no recorded metrics", path)
+	}
+
+	return prometheus.NewFileSourceFromRecording(b.Metrics), &b, nil
+}
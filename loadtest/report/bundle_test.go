@@ -0,0 +1,192 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package report
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-load-test-ng/coordinator/performance/prometheus"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundle(t *testing.T) {
+	r := Report{
+		Label:         "base",
+		StartTime:     time.Unix(1000, 0).UTC(),
+		EndTime:       time.Unix(2000, 0).UTC(),
+		AvgStoreTimes: map[model.LabelValue]model.SampleValue{"Post.Get": 0.1},
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		b := Bundle{
+			Manifest: BundleManifest{ServerVersion: "10.5.0"},
+			Report:   r,
+			Configs: map[string][]byte{
+				"config.json":      []byte(`{"UsersConfiguration":{}}`),
+				"coordinator.json": []byte(`{}`),
+			},
+			DeploymentInfo: json.RawMessage(`{"AppInstanceCount":2}`),
+			Status:         json.RawMessage(`{"SupportedUsers":1000,"NumErrors":5}`),
+			Metrics: []prometheus.RecordedQuery{
+				{
+					Query: "up",
+					Result: model.Matrix{
+						&model.SampleStream{
+							Metric: model.Metric{},
+							Values: []model.SamplePair{{Timestamp: model.TimeFromUnix(1000), Value: 1}},
+						},
+					},
+				},
+			},
+		}
+
+		path := filepath.Join(t.TempDir(), "report.tar.gz")
+		f, err := os.Create(path)
+		require.NoError(t, err)
+		require.NoError(t, WriteBundle(f, b))
+		require.NoError(t, f.Close())
+
+		f, err = os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		loaded, err := LoadBundle(f)
+		require.NoError(t, err)
+
+		require.Equal(t, BundleVersion, loaded.Manifest.Version)
+		require.Equal(t, "base", loaded.Manifest.Label)
+		require.Equal(t, "10.5.0", loaded.Manifest.ServerVersion)
+		require.Equal(t, []string{"config.json", "coordinator.json"}, loaded.Manifest.ConfigFiles)
+		require.Equal(t, r, loaded.Report)
+		require.Equal(t, b.Configs, loaded.Configs)
+		require.JSONEq(t, string(b.DeploymentInfo), string(loaded.DeploymentInfo))
+		require.Equal(t, b.Status, loaded.Status)
+		require.Equal(t, b.Metrics, loaded.Metrics)
+
+		// Load reads bundles as well.
+		loadedReport, err := Load(path)
+		require.NoError(t, err)
+		require.Equal(t, r, loadedReport)
+	})
+
+	t.Run("plain report", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "report.json")
+		data, err := json.Marshal(r)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0600))
+
+		loaded, err := Load(path)
+		require.NoError(t, err)
+		require.Equal(t, r, loaded)
+	})
+
+	writeTar := func(t *testing.T, files ...string) *bytes.Buffer {
+		t.Helper()
+		var buf bytes.Buffer
+		gzw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gzw)
+		for i := 0; i < len(files); i += 2 {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: files[i], Mode: 0644, Size: int64(len(files[i+1]))}))
+			_, err := tw.Write([]byte(files[i+1]))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, gzw.Close())
+		return &buf
+	}
+
+	t.Run("unsupported version", func(t *testing.T) {
+		_, err := LoadBundle(writeTar(t, "manifest.json", `{"Version":2}`, "report.json", `{}`))
+		require.ErrorContains(t, err, "unsupported bundle version 2")
+	})
+
+	t.Run("missing manifest", func(t *testing.T) {
+		_, err := LoadBundle(writeTar(t, "report.json", `{}`))
+		require.Error(t, err)
+	})
+
+	t.Run("missing report", func(t *testing.T) {
+		_, err := LoadBundle(writeTar(t, "manifest.json", `{"Version":1}`))
+		require.Error(t, err)
+	})
+}
+
+func TestReadConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte("{}"), 0600))
+
+	configs, err := ReadConfigFiles([]string{filepath.Join(dir, "config.json"), filepath.Join(dir, "missing.json")})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"config.json": []byte("{}")}, configs)
+}
+
+func TestNewOfflineSource(t *testing.T) {
+	metrics := []prometheus.RecordedQuery{
+		{
+			Query: "up",
+			Result: model.Matrix{
+				&model.SampleStream{
+					Metric: model.Metric{},
+					Values: []model.SamplePair{{Timestamp: model.TimeFromUnix(1000), Value: 1}},
+				},
+			},
+		},
+	}
+	dir := t.TempDir()
+
+	t.Run("recording", func(t *testing.T) {
+		path := filepath.Join(dir, "metrics.json")
+		data, err := json.Marshal(metrics)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0600))
+
+		source, b, err := NewOfflineSource(path)
+		require.NoError(t, err)
+		require.Nil(t, b)
+
+		m, err := source.Matrix("up", time.Unix(0, 0), time.Unix(2000, 0))
+		require.NoError(t, err)
+		require.Equal(t, metrics[0].Result, m)
+	})
+
+	writeBundle := func(t *testing.T, b Bundle) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "report.tar.gz")
+		f, err := os.Create(path)
+		require.NoError(t, err)
+		require.NoError(t, WriteBundle(f, b))
+		require.NoError(t, f.Close())
+		return path
+	}
+
+	t.Run("bundle", func(t *testing.T) {
+		r := Report{Label: "base", StartTime: time.Unix(1000, 0).UTC(), EndTime: time.Unix(2000, 0).UTC()}
+		source, b, err := NewOfflineSource(writeBundle(t, Bundle{Report: r, Metrics: metrics}))
+		require.NoError(t, err)
+		require.NotNil(t, b)
+		require.Equal(t, r, b.Report)
+
+		value, err := source.VectorFirst("up")
+		require.NoError(t, err)
+		require.Equal(t, 1.0, value)
+	})
+
+	t.Run("bundle without metrics", func(t *testing.T) {
+		_, _, err := NewOfflineSource(writeBundle(t, Bundle{Report: Report{Label: "base"}}))
+		require.ErrorContains(t, err, "has no recorded metrics")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, _, err := NewOfflineSource(filepath.Join(dir, "missing.json"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package report
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// chartColors is the palette used for the series of a chart, in order.
+var chartColors = []string{
+	"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd",
+	"#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf",
+}
+
+// Dimensions, in pixels, of the margins around the plot area of a chart.
+const (
+	chartMarginTop    = 30
+	chartMarginRight  = 20
+	chartMarginBottom = 60
+	chartMarginLeft   = 70
+)
+
+// chart is a line chart comparing a single metric across reports.
+type chart struct {
+	title  string
+	series []labelValues
+}
+
+// chartPoint is a point of a chart, in pixels.
+type chartPoint struct {
+	x, y float64
+}
+
+// chartTick is a labelled tick of an axis.
+type chartTick struct {
+	pos   float64 // The position of the tick, in pixels.
+	label string
+}
+
+// chartLayout holds the geometry of a chart scaled to a given size.
+type chartLayout struct {
+	width, height int
+	// The bounds of the plot area.
+	left, top, right, bottom float64
+	xTicks, yTicks           []chartTick
+	// The scaled points of each series. NaN values split a series in
+	// multiple lines.
+	lines [][][]chartPoint
+}
+
+// newChart returns a chart for the given graph of the base report and the
+// matching graphs of the other reports.
+func newChart(baseLabel string, base graph, others []labelValues) chart {
+	series := make([]labelValues, 0, len(others)+1)
+	series = append(series, labelValues{label: baseLabel, values: base.Values})
+	series = append(series, others...)
+	return chart{title: base.Name, series: series}
+}
+
+// layout scales the chart to the given size. The x axis is the normalized
+// time, that is the index of each sample, so that
+// load-tests that ran at different times can be compared.
+func (ch chart) layout(width, height int) chartLayout {
+	l := chartLayout{
+		width:  width,
+		height: height,
+		left:   chartMarginLeft,
+		top:    chartMarginTop,
+		right:  float64(width - chartMarginRight),
+		bottom: float64(height - chartMarginBottom),
+	}
+
+	var maxX int
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, s := range ch.series {
+		maxX = max(maxX, len(s.values)-1)
+		for _, v := range s.values {
+			value := float64(v.Value)
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				continue
+			}
+			minY = min(minY, value)
+			maxY = max(maxY, value)
+		}
+	}
+	if math.IsInf(minY, 0) {
+		minY, maxY = 0, 1
+	}
+	// We always include zero so that deltas aren't visually exaggerated.
+	minY = min(minY, 0)
+	if maxY <= minY {
+		maxY = minY + 1
+	}
+	maxX = max(maxX, 1)
+
+	yTicks := niceTicks(minY, maxY, 5)
+	minY, maxY = yTicks[0], yTicks[len(yTicks)-1]
+
+	scaleX := func(x float64) float64 {
+		return l.left + x/float64(maxX)*(l.right-l.left)
+	}
+	scaleY := func(y float64) float64 {
+		return l.bottom - (y-minY)/(maxY-minY)*(l.bottom-l.top)
+	}
+
+	for _, tick := range niceTicks(0, float64(maxX), 8) {
+		if tick > float64(maxX) {
+			continue
+		}
+		l.xTicks = append(l.xTicks, chartTick{pos: scaleX(tick), label: formatTick(tick)})
+	}
+	for _, tick := range yTicks {
+		l.yTicks = append(l.yTicks, chartTick{pos: scaleY(tick), label: formatTick(tick)})
+	}
+
+	for _, s := range ch.series {
+		var lines [][]chartPoint
+		var line []chartPoint
+		for i, v := range s.values {
+			value := float64(v.Value)
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				if len(line) > 0 {
+					lines = append(lines, line)
+					line = nil
+				}
+				continue
+			}
+			line = append(line, chartPoint{x: scaleX(float64(i)), y: scaleY(value)})
+		}
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+		l.lines = append(l.lines, lines)
+	}
+
+	return l
+}
+
+// svg renders the chart as an inline SVG element of the given size.
+func (ch chart) svg(width, height int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" class="chart" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`, width, height, width, height)
+	fmt.Fprintf(&sb, `<title>%s</title>`, html.EscapeString(ch.title))
+	ch.writeSVG(&sb, width, height)
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
+
+// writeSVG writes the SVG elements of the chart, scaled to the given size,
+// to the given builder.
+func (ch chart) writeSVG(sb *strings.Builder, width, height int) {
+	l := ch.layout(width, height)
+
+	fmt.Fprintf(sb, `<rect width="%d" height="%d" fill="#fff"/>`, width, height)
+	fmt.Fprintf(sb, `<text x="%d" y="18" text-anchor="middle" font-size="14" font-weight="bold">%s</text>`, width/2, html.EscapeString(ch.title))
+
+	// Grid and axes.
+	for _, tick := range l.yTicks {
+		fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#e5e5e5"/>`, l.left, tick.pos, l.right, tick.pos)
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="end" dominant-baseline="middle">%s</text>`, l.left-6, tick.pos, tick.label)
+	}
+	for _, tick := range l.xTicks {
+		fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#e5e5e5"/>`, tick.pos, l.top, tick.pos, l.bottom)
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`, tick.pos, l.bottom+16, tick.label)
+	}
+	fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="none" stroke="#333"/>`, l.left, l.top, l.right-l.left, l.bottom-l.top)
+	fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle">time (normalized)</text>`, (l.left+l.right)/2, l.bottom+32)
+
+	// Series.
+	for i, lines := range l.lines {
+		color := chartColors[i%len(chartColors)]
+		fmt.Fprintf(sb, `<g class="series"><title>%s</title>`, html.EscapeString(ch.series[i].label))
+		for _, line := range lines {
+			sb.WriteString(`<polyline fill="none" stroke-width="2" stroke="` + color + `" points="`)
+			for j, p := range line {
+				if j > 0 {
+					sb.WriteByte(' ')
+				}
+				fmt.Fprintf(sb, "%.1f,%.1f", p.x, p.y)
+			}
+			sb.WriteString(`"/>`)
+		}
+		sb.WriteString(`</g>`)
+	}
+
+	// Legend.
+	x := l.left
+	y := float64(height) - 10
+	for i, s := range ch.series {
+		color := chartColors[i%len(chartColors)]
+		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="12" height="4" fill="%s"/>`, x, y-4, color)
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f">%s</text>`, x+16, y, html.EscapeString(s.label))
+		x += 16 + float64(len(s.label))*7 + 20
+	}
+}
+
+// niceTicks returns about n evenly spaced round values covering the
+// [lo, hi] range.
+func niceTicks(lo, hi float64, n int) []float64 {
+	step := niceNum((hi - lo) / float64(max(n-1, 1)))
+	start := math.Floor(lo/step) * step
+	end := math.Ceil(hi/step) * step
+	// Values are rounded to the precision of the step to avoid floating
+	// point artifacts such as 0.30000000000000004.
+	prec := max(0, int(-math.Floor(math.Log10(step))))
+	var ticks []float64
+	for i := 0; start+float64(i)*step <= end+step/2; i++ {
+		v, _ := strconv.ParseFloat(strconv.FormatFloat(start+float64(i)*step, 'f', prec, 64), 64)
+		ticks = append(ticks, v)
+	}
+	return ticks
+}
+
+// niceNum returns a round number (1, 2, 5 or 10 times a power of ten)
+// approximately equal to x.
+func niceNum(x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	exp := math.Floor(math.Log10(x))
+	f := x / math.Pow(10, exp)
+	var nf float64
+	switch {
+	case f < 1.5:
+		nf = 1
+	case f < 3:
+		nf = 2
+	case f < 7:
+		nf = 5
+	default:
+		nf = 10
+	}
+	return nf * math.Pow(10, exp)
+}
+
+// formatTick formats the value of a tick in its shortest form.
+func formatTick(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
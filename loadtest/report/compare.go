@@ -7,8 +7,12 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"slices"
+	"sort"
 	"time"
 
+	"github.com/mattermost/mattermost-load-test-ng/coordinator/capacity"
+
 	"github.com/prometheus/common/model"
 )
 
@@ -18,6 +22,14 @@ type diff struct {
 	actual       time.Duration
 	delta        time.Duration
 	deltaPercent float64
+	// Whether a significance test was performed, which is only the case
+	// when comparing multiple runs.
+	tested      bool
+	pValue      float64
+	significant bool
+	// Whether there were too few runs for any delta to be significant, in
+	// which case no test is performed.
+	untestable bool
 }
 
 // avgp99 is an array of 2 slices.
@@ -27,8 +39,25 @@ type diff struct {
 type avgp99 [2][]diff
 
 type comp struct {
-	store map[model.LabelValue]avgp99
-	api   map[model.LabelValue]avgp99
+	// The labels of the reports compared against the base one, in order.
+	labels []string
+	store  map[model.LabelValue]avgp99
+	api    map[model.LabelValue]avgp99
+	// Comparisons of the additional quantiles and custom histograms.
+	stats []statComp
+	// Warnings about the comparison, displayed before it.
+	warnings []string
+}
+
+// statComp contains the comparison of a single statistic (e.g. avg or p95)
+// of a histogram family.
+type statComp struct {
+	name  string // The name of the histogram family (e.g. "Store times").
+	stat  string // The name of the statistic (e.g. "p95").
+	base  map[model.LabelValue]model.SampleValue
+	diffs map[model.LabelValue][]diff
+	// get returns the values of the statistic from a report.
+	get func(Report) map[model.LabelValue]model.SampleValue
 }
 
 // labelValues is used to compare a single metric from different load tests.
@@ -42,10 +71,25 @@ type gplot struct {
 	graphs []labelValues
 }
 
+// Format is the format in which a comparison is written.
+type Format string
+
+// Available formats for comparisons.
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
 // CompareOpts holds options to customize a comparison.
 type CompareOpts struct {
 	GenGraph     bool   // A boolean indicating whether to generate plotted graphs.
 	GraphsPrefix string // A prefix to prepend to the filename of the generated graphs.
+	// The image format of the generated graphs. Defaults to PNG.
+	GraphsFormat GraphFormat
+	// The significance level used when comparing multiple runs. Defaults to 0.05.
+	Alpha float64
+	// The format of the comparison. Defaults to markdown.
+	Format Format
 }
 
 // Compare compares the given set of reports.
@@ -56,24 +100,224 @@ func Compare(target io.Writer, opts CompareOpts, reports ...Report) error {
 	// Calculate the deltas.
 	c := calculateDeltas(reports...)
 
-	// Now display the comparison in markdown.
-	displayMarkdown(c, target, base, len(reports[1:]))
+	// Now display the comparison.
+	if opts.Format == FormatHTML {
+		if err := displayHTML(target, c, base, reports[1:], nil); err != nil {
+			return err
+		}
+	} else {
+		displayMarkdown(c, target, base, len(reports[1:]))
+		displayCapacity(target, reports...)
+	}
 
-	// TODO: generate a single image combining all the graphs.
 	// Printing the graphs.
 	if opts.GenGraph {
-		gPlots := getPlots(reports[1:]...)
-		for i, plot := range gPlots {
-			if i >= len(base.Graphs) {
-				continue
+		return generateGraphs(getCharts(base, reports[1:]...), opts.GraphsPrefix, opts.GraphsFormat)
+	}
+	return nil
+}
+
+// CompareRuns compares multiple runs of a base load-test against multiple
+// runs of a new one. Values are averaged across the runs of each side and
+// every metric is tested for statistical significance, so that only
+// significant regressions are highlighted.
+func CompareRuns(target io.Writer, opts CompareOpts, base, actual []Report) error {
+	if len(base) == 0 || len(actual) == 0 {
+		return fmt.Errorf("at least one run per side is needed: got %d base and %d actual runs", len(base), len(actual))
+	}
+
+	alpha := opts.Alpha
+	if alpha == 0 {
+		alpha = defaultAlpha
+	}
+
+	mergedBase := Merge(base)
+	mergedActual := Merge(actual)
+	c := calculateDeltas(mergedBase, mergedActual)
+	if !testSignificance(c, base, actual, alpha) {
+		c.warnings = append(c.warnings, fmt.Sprintf("%d base and %d actual runs are too few for any delta to be statistically significant at the %g level: deltas are not tested and are all reported.", len(base), len(actual), alpha))
+	}
+
+	gs := compareGraphs(base, actual, alpha)
+	if opts.Format == FormatHTML {
+		if err := displayHTML(target, c, mergedBase, []Report{mergedActual}, gs); err != nil {
+			return err
+		}
+	} else {
+		displayMarkdown(c, target, mergedBase, 1)
+		displayGraphStats(target, gs)
+		displayCapacity(target, slices.Concat(base, actual)...)
+	}
+
+	if opts.GenGraph {
+		return generateGraphs(getCharts(mergedBase, mergedActual), opts.GraphsPrefix, opts.GraphsFormat)
+	}
+
+	return nil
+}
+
+// Merge returns a report holding the average of the values of the given
+// runs of the same load-test. The graphs, capacity curve and times of the
+// first run are kept as they are.
+func Merge(runs []Report) Report {
+	merged := runs[0]
+	if len(runs) == 1 {
+		return merged
+	}
+
+	merged.AvgStoreTimes = mergeValues(runs, func(r Report) map[model.LabelValue]model.SampleValue { return r.AvgStoreTimes })
+	merged.P99StoreTimes = mergeValues(runs, func(r Report) map[model.LabelValue]model.SampleValue { return r.P99StoreTimes })
+	merged.AvgAPITimes = mergeValues(runs, func(r Report) map[model.LabelValue]model.SampleValue { return r.AvgAPITimes })
+	merged.P99APITimes = mergeValues(runs, func(r Report) map[model.LabelValue]model.SampleValue { return r.P99APITimes })
+
+	if merged.StoreTimeQuantiles != nil {
+		merged.StoreTimeQuantiles = make(map[string]map[model.LabelValue]model.SampleValue)
+		for name := range runs[0].StoreTimeQuantiles {
+			merged.StoreTimeQuantiles[name] = mergeValues(runs, func(r Report) map[model.LabelValue]model.SampleValue { return r.StoreTimeQuantiles[name] })
+		}
+	}
+	if merged.APITimeQuantiles != nil {
+		merged.APITimeQuantiles = make(map[string]map[model.LabelValue]model.SampleValue)
+		for name := range runs[0].APITimeQuantiles {
+			merged.APITimeQuantiles[name] = mergeValues(runs, func(r Report) map[model.LabelValue]model.SampleValue { return r.APITimeQuantiles[name] })
+		}
+	}
+	if merged.Histograms != nil {
+		merged.Histograms = make(map[string]HistogramValues)
+		for hName, h := range runs[0].Histograms {
+			values := HistogramValues{
+				Avg:       mergeValues(runs, func(r Report) map[model.LabelValue]model.SampleValue { return r.Histograms[hName].Avg }),
+				Quantiles: make(map[string]map[model.LabelValue]model.SampleValue),
 			}
-			err := generateGraph(plot.name, opts.GraphsPrefix, base.Label, base.Graphs[i], plot.graphs)
-			if err != nil {
-				return fmt.Errorf("error while generating graph for %s: %w", plot.name, err)
+			for name := range h.Quantiles {
+				values.Quantiles[name] = mergeValues(runs, func(r Report) map[model.LabelValue]model.SampleValue { return r.Histograms[hName].Quantiles[name] })
 			}
+			merged.Histograms[hName] = values
 		}
 	}
-	return nil
+
+	return merged
+}
+
+// mergeValues returns the average value of each label across the given runs.
+// Runs missing a label are not taken into account for it.
+func mergeValues(runs []Report, get func(Report) map[model.LabelValue]model.SampleValue) map[model.LabelValue]model.SampleValue {
+	merged := make(map[model.LabelValue]model.SampleValue)
+	for label, values := range runValues(runs, get) {
+		merged[label] = model.SampleValue(mean(values))
+	}
+	return merged
+}
+
+// runValues returns the values of each label across the given runs.
+func runValues(runs []Report, get func(Report) map[model.LabelValue]model.SampleValue) map[model.LabelValue][]float64 {
+	values := make(map[model.LabelValue][]float64)
+	for _, r := range runs {
+		for label, value := range get(r) {
+			values[label] = append(values[label], float64(value))
+		}
+	}
+	return values
+}
+
+// testSignificance performs a Mann-Whitney U test for every compared value,
+// using the values from each run as samples.
+func testSignificance(c comp, base, actual []Report, alpha float64) bool {
+	// With too few runs, even the most extreme outcome isn't significant,
+	// so testing would hide every delta.
+	testable := minPValue(len(base), len(actual)) < alpha
+
+	annotate := func(diffs []diff, get func(Report) map[model.LabelValue]model.SampleValue, label model.LabelValue) {
+		if len(diffs) == 0 {
+			return
+		}
+		if !testable {
+			diffs[0].untestable = true
+			return
+		}
+		p := mannWhitneyU(runValues(base, get)[label], runValues(actual, get)[label])
+		diffs[0].tested = true
+		diffs[0].pValue = p
+		diffs[0].significant = p < alpha
+	}
+
+	for label, d := range c.store {
+		annotate(d[0], func(r Report) map[model.LabelValue]model.SampleValue { return r.AvgStoreTimes }, label)
+		annotate(d[1], func(r Report) map[model.LabelValue]model.SampleValue { return r.P99StoreTimes }, label)
+	}
+	for label, d := range c.api {
+		annotate(d[0], func(r Report) map[model.LabelValue]model.SampleValue { return r.AvgAPITimes }, label)
+		annotate(d[1], func(r Report) map[model.LabelValue]model.SampleValue { return r.P99APITimes }, label)
+	}
+	for _, sc := range c.stats {
+		for label, d := range sc.diffs {
+			annotate(d, sc.get, label)
+		}
+	}
+
+	return testable
+}
+
+// graphStats contains the comparison of a graph across multiple runs.
+type graphStats struct {
+	name         string
+	base         [3]float64 // The mean and the bounds of its confidence interval.
+	actual       [3]float64
+	deltaPercent float64
+	pValue       float64
+	significant  bool
+	untestable   bool // Whether there were too few runs to perform a test.
+}
+
+// compareGraphs compares each graph of the given runs. The samples of a
+// graph over time are autocorrelated, so they can't be considered as
+// independent observations. The mean of each run is used as a sample
+// instead.
+func compareGraphs(base, actual []Report, alpha float64) []graphStats {
+	samples := func(runs []Report, name string) []float64 {
+		var means []float64
+		for _, r := range runs {
+			for _, g := range r.Graphs {
+				if g.Name != name {
+					continue
+				}
+				var values []float64
+				for _, v := range g.Values {
+					if !math.IsNaN(float64(v.Value)) {
+						values = append(values, float64(v.Value))
+					}
+				}
+				if len(values) > 0 {
+					means = append(means, mean(values))
+				}
+			}
+		}
+		return means
+	}
+
+	var stats []graphStats
+	for _, g := range base[0].Graphs {
+		xs := samples(base, g.Name)
+		ys := samples(actual, g.Name)
+		if len(xs) == 0 || len(ys) == 0 {
+			continue
+		}
+		gs := graphStats{
+			name:       g.Name,
+			pValue:     mannWhitneyU(xs, ys),
+			untestable: minPValue(len(xs), len(ys)) >= alpha,
+		}
+		baseCI := capacity.NewConfidenceInterval(xs)
+		actualCI := capacity.NewConfidenceInterval(ys)
+		gs.base = [3]float64{baseCI.Mean, baseCI.Lower, baseCI.Upper}
+		gs.actual = [3]float64{actualCI.Mean, actualCI.Lower, actualCI.Upper}
+		if gs.base[0] != 0 {
+			gs.deltaPercent = (gs.actual[0] - gs.base[0]) / gs.base[0] * 100
+		}
+		gs.significant = gs.pValue < alpha
+		stats = append(stats, gs)
+	}
+	return stats
 }
 
 // getPlots returns a slice of structs to aggregate graphs of a single type
@@ -119,6 +363,7 @@ func calculateDeltas(reports ...Report) comp {
 		api:   make(map[model.LabelValue]avgp99),
 	}
 	for _, r := range reports[1:] {
+		c.labels = append(c.labels, r.Label)
 		// XXX: This can be somewhat refactored but whether absolute metrics
 		// are useful or not needs to be seen.
 		for label, value := range base.AvgStoreTimes {
@@ -193,5 +438,67 @@ func calculateDeltas(reports ...Report) comp {
 			c.api[label] = diffs
 		}
 	}
+
+	for _, name := range sortedKeys(base.StoreTimeQuantiles) {
+		c.stats = append(c.stats, newStatComp("Store times", name, base.StoreTimeQuantiles[name], reports[1:], func(r Report) map[model.LabelValue]model.SampleValue {
+			return r.StoreTimeQuantiles[name]
+		}))
+	}
+	for _, name := range sortedKeys(base.APITimeQuantiles) {
+		c.stats = append(c.stats, newStatComp("API times", name, base.APITimeQuantiles[name], reports[1:], func(r Report) map[model.LabelValue]model.SampleValue {
+			return r.APITimeQuantiles[name]
+		}))
+	}
+	for _, hName := range sortedKeys(base.Histograms) {
+		h := base.Histograms[hName]
+		c.stats = append(c.stats, newStatComp(hName, "avg", h.Avg, reports[1:], func(r Report) map[model.LabelValue]model.SampleValue {
+			return r.Histograms[hName].Avg
+		}))
+		for _, name := range sortedKeys(h.Quantiles) {
+			c.stats = append(c.stats, newStatComp(hName, name, h.Quantiles[name], reports[1:], func(r Report) map[model.LabelValue]model.SampleValue {
+				return r.Histograms[hName].Quantiles[name]
+			}))
+		}
+	}
+
 	return c
 }
+
+// newStatComp compares the base values of a statistic against the ones
+// returned by get for each of the given reports.
+func newStatComp(name, stat string, base map[model.LabelValue]model.SampleValue, reports []Report, get func(Report) map[model.LabelValue]model.SampleValue) statComp {
+	sc := statComp{
+		name:  name,
+		stat:  stat,
+		base:  base,
+		diffs: make(map[model.LabelValue][]diff),
+		get:   get,
+	}
+	for _, r := range reports {
+		values := get(r)
+		for label, value := range base {
+			actual := getDuration(float64(values[label]))
+			delta := actual - getDuration(float64(value))
+			deltaP := (delta.Seconds() / float64(value)) * 100
+			if math.IsNaN(deltaP) {
+				deltaP = 0
+			}
+			sc.diffs[label] = append(sc.diffs[label], diff{
+				base:         getDuration(float64(value)),
+				actual:       actual,
+				delta:        delta,
+				deltaPercent: deltaP,
+			})
+		}
+	}
+	return sc
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
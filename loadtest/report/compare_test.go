@@ -4,7 +4,11 @@
 package report
 
 import (
+	"bytes"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
@@ -125,3 +129,137 @@ func TestGetPlots(t *testing.T) {
 		}
 	}
 }
+
+func TestCalculateDeltasStats(t *testing.T) {
+	base := Report{
+		StoreTimeQuantiles: map[string]map[model.LabelValue]model.SampleValue{
+			"p50": {"method1": 0.1},
+		},
+		Histograms: map[string]HistogramValues{
+			"WebSocket broadcast": {
+				Avg: map[model.LabelValue]model.SampleValue{"posted": 0.2},
+				Quantiles: map[string]map[model.LabelValue]model.SampleValue{
+					"p99": {"posted": 0.4},
+				},
+			},
+		},
+	}
+	actual := Report{
+		StoreTimeQuantiles: map[string]map[model.LabelValue]model.SampleValue{
+			"p50": {"method1": 0.2},
+		},
+		Histograms: map[string]HistogramValues{
+			"WebSocket broadcast": {
+				Avg: map[model.LabelValue]model.SampleValue{"posted": 0.1},
+				Quantiles: map[string]map[model.LabelValue]model.SampleValue{
+					"p99": {"posted": 0.4},
+				},
+			},
+		},
+	}
+
+	c := calculateDeltas(base, actual)
+	require.Len(t, c.stats, 3)
+
+	assert.Equal(t, "Store times", c.stats[0].name)
+	assert.Equal(t, "p50", c.stats[0].stat)
+	require.Len(t, c.stats[0].diffs["method1"], 1)
+	assert.Equal(t, 100*time.Millisecond, c.stats[0].diffs["method1"][0].delta)
+	assert.InDelta(t, 100, c.stats[0].diffs["method1"][0].deltaPercent, 1e-9)
+
+	assert.Equal(t, "WebSocket broadcast", c.stats[1].name)
+	assert.Equal(t, "avg", c.stats[1].stat)
+	assert.InDelta(t, -50, c.stats[1].diffs["posted"][0].deltaPercent, 1e-9)
+
+	assert.Equal(t, "p99", c.stats[2].stat)
+	assert.Zero(t, c.stats[2].diffs["posted"][0].delta)
+}
+
+func TestCompareRuns(t *testing.T) {
+	run := func(label string, storeTime, cpu model.SampleValue) Report {
+		var values []model.SamplePair
+		for i := 0; i < 10; i++ {
+			values = append(values, model.SamplePair{Value: cpu + model.SampleValue(i)})
+		}
+		return Report{
+			Label:         label,
+			AvgStoreTimes: map[model.LabelValue]model.SampleValue{"method1": storeTime, "method2": 0.1},
+			P99StoreTimes: map[model.LabelValue]model.SampleValue{"method1": storeTime, "method2": 0.1},
+			Graphs:        []graph{{Name: "CPU", Values: values}},
+		}
+	}
+
+	base := []Report{run("base", 0.10, 10), run("base", 0.11, 10), run("base", 0.12, 10), run("base", 0.13, 10)}
+	actual := []Report{run("new", 0.20, 20), run("new", 0.21, 20), run("new", 0.22, 20), run("new", 0.23, 20)}
+
+	t.Run("merge", func(t *testing.T) {
+		merged := Merge(base)
+		require.InDelta(t, 0.115, float64(merged.AvgStoreTimes["method1"]), 1e-9)
+		require.Equal(t, "base", merged.Label)
+	})
+
+	t.Run("significance", func(t *testing.T) {
+		c := calculateDeltas(Merge(base), Merge(actual))
+		testSignificance(c, base, actual, 0.05)
+
+		d := c.store["method1"][0][0]
+		require.True(t, d.tested)
+		require.True(t, d.significant)
+		require.InDelta(t, 2.0/70, d.pValue, 1e-9)
+
+		d = c.store["method2"][0][0]
+		require.True(t, d.tested)
+		require.False(t, d.significant)
+	})
+
+	t.Run("graphs", func(t *testing.T) {
+		stats := compareGraphs(base, actual, 0.05)
+		require.Len(t, stats, 1)
+		require.Equal(t, "CPU", stats[0].name)
+		require.InDelta(t, 14.5, stats[0].base[0], 1e-9)
+		require.InDelta(t, 24.5, stats[0].actual[0], 1e-9)
+		require.True(t, stats[0].significant)
+
+		// The mean of each run is used as a sample, rather than every
+		// value of the graph.
+		base := []Report{run("base", 0.1, 10), run("base", 0.1, 12), run("base", 0.1, 14), run("base", 0.1, 16)}
+		actual := []Report{run("new", 0.1, 11), run("new", 0.1, 13), run("new", 0.1, 15), run("new", 0.1, 17)}
+		stats = compareGraphs(base, actual, 0.05)
+		require.Len(t, stats, 1)
+		require.Equal(t, mannWhitneyU([]float64{14.5, 16.5, 18.5, 20.5}, []float64{15.5, 17.5, 19.5, 21.5}), stats[0].pValue)
+		require.False(t, stats[0].significant)
+	})
+
+	t.Run("too few runs", func(t *testing.T) {
+		// Two runs per side can't reach significance, so the large
+		// regression of method1 must still be reported.
+		c := calculateDeltas(Merge(base[:2]), Merge(actual[:2]))
+		require.False(t, testSignificance(c, base[:2], actual[:2], 0.05))
+		d := c.store["method1"][0][0]
+		require.False(t, d.tested)
+		require.True(t, d.untestable)
+
+		var buf bytes.Buffer
+		require.NoError(t, CompareRuns(&buf, CompareOpts{}, base[:2], actual[:2]))
+		out := buf.String()
+		require.Contains(t, out, "> **Warning:** 2 base and 2 actual runs are too few")
+		worsened := out[strings.Index(out, "### Store times avg (worsened):"):strings.Index(out, "### Store times p99 (worsened):")]
+		require.Contains(t, worsened, "| method1 | avg | 105ms | 205ms | 100ms | 95.24")
+		require.Contains(t, out, "95.238 (not testable)")
+		require.Contains(t, out, "| not testable |")
+	})
+
+	t.Run("no runs", func(t *testing.T) {
+		require.Error(t, CompareRuns(io.Discard, CompareOpts{}, nil, actual))
+	})
+
+	t.Run("output", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, CompareRuns(&buf, CompareOpts{}, base, actual))
+		out := buf.String()
+		require.Contains(t, out, "| method1 | avg | 115ms | 215ms | 100ms | 86.96")
+		require.Contains(t, out, "**86.957 (p=0.029)**")
+		require.Contains(t, out, "0.000 (p=1.000)")
+		require.Contains(t, out, "### Graphs:")
+	})
+}
@@ -4,12 +4,14 @@
 package report
 
 import (
-	"encoding/json"
 	"fmt"
 	"math"
 	"os"
+	"sort"
+	"strconv"
 	"time"
 
+	"github.com/mattermost/mattermost-load-test-ng/coordinator/capacity"
 	"github.com/mattermost/mattermost-load-test-ng/coordinator/performance/prometheus"
 
 	"github.com/prometheus/common/model"
@@ -17,8 +19,37 @@ import (
 
 // Config contains information needed to generate reports.
 type Config struct {
-	Label        string // Label to be used when querying Prometheus.
-	GraphQueries []GraphQuery
+	Label         string // Label to be used when querying Prometheus.
+	GraphQueries  []GraphQuery
+	MetricsSource prometheus.SourceConfig // The source to fetch metrics from.
+	// Additional quantiles (e.g. 0.5, 0.95, 0.999) to compute for store and
+	// API times and for the custom histograms. The 0.99 quantile is always
+	// computed.
+	Quantiles []float64 `validate:"each:range:(0,1)"`
+	// Additional histogram families to include in reports.
+	Histograms []HistogramQuery
+}
+
+// HistogramQuery describes a Prometheus histogram family whose average and
+// quantiles should be included in reports.
+type HistogramQuery struct {
+	Name   string // A friendly name for the histogram.
+	Metric string // The name of the metric, without the _bucket, _sum or _count suffix.
+	By     string // The label to group values by (e.g. "handler").
+}
+
+// IsValid reports whether a given HistogramQuery is valid or not.
+func (hq HistogramQuery) IsValid() error {
+	if hq.Name == "" {
+		return fmt.Errorf("Name should not be empty")
+	}
+	if hq.Metric == "" {
+		return fmt.Errorf("Metric should not be empty for histogram %q", hq.Name)
+	}
+	if hq.By == "" {
+		return fmt.Errorf("By should not be empty for histogram %q", hq.Name)
+	}
+	return nil
 }
 
 // GraphQuery contains the query to be executed against a Prometheus instance
@@ -31,7 +62,7 @@ type GraphQuery struct {
 // Generator is used to generate load test reports.
 type Generator struct {
 	label  string
-	helper *prometheus.Helper
+	source prometheus.MetricsSource
 	cfg    Config
 }
 
@@ -45,7 +76,22 @@ type Report struct {
 	P99StoreTimes map[model.LabelValue]model.SampleValue
 	AvgAPITimes   map[model.LabelValue]model.SampleValue
 	P99APITimes   map[model.LabelValue]model.SampleValue
-	Graphs        []graph
+	// Additional quantiles of store and API times, keyed by quantile name
+	// (e.g. "p95").
+	StoreTimeQuantiles map[string]map[model.LabelValue]model.SampleValue `json:",omitempty"`
+	APITimeQuantiles   map[string]map[model.LabelValue]model.SampleValue `json:",omitempty"`
+	// The values of the custom histograms, keyed by histogram name.
+	Histograms map[string]HistogramValues `json:",omitempty"`
+	Graphs     []graph
+	// The capacity curve computed by the coordinator, if any.
+	CapacityCurve *capacity.Curve `json:",omitempty"`
+}
+
+// HistogramValues contains the values of a histogram family grouped by label.
+type HistogramValues struct {
+	Avg map[model.LabelValue]model.SampleValue
+	// Quantiles are keyed by quantile name (e.g. "p99").
+	Quantiles map[string]map[model.LabelValue]model.SampleValue
 }
 
 // graph contains data for a single metric.
@@ -54,27 +100,29 @@ type graph struct {
 	Values []model.SamplePair
 }
 
-// New returns a new instance of a generator.
-func New(label string, helper *prometheus.Helper, cfg Config) *Generator {
+// New returns a new instance of a generator fetching metrics from the given source.
+func New(label string, source prometheus.MetricsSource, cfg Config) *Generator {
 	return &Generator{
 		label:  label,
-		helper: helper,
+		source: source,
 		cfg:    cfg,
 	}
 }
 
-// Load loads a report from a given file path.
+// Load loads a report from a given file path. The file can either be a
+// plain JSON report or a bundle.
 func Load(path string) (Report, error) {
-	var r Report
-	buf, err := os.ReadFile(path)
+	f, err := os.Open(path)
 	if err != nil {
-		return r, err
+		return Report{}, err
 	}
-	err = json.Unmarshal(buf, &r)
+	defer f.Close()
+
+	b, err := LoadBundle(f)
 	if err != nil {
-		return r, err
+		return Report{}, err
 	}
-	return r, nil
+	return b.Report, nil
 }
 
 // Generate returns a report from a given start time to end time.
@@ -125,8 +173,43 @@ func (g *Generator) Generate(startTime, endTime time.Time) (Report, error) {
 		return data, fmt.Errorf("error while getting p99 API times: %w", err)
 	}
 
+	for _, q := range g.cfg.Quantiles {
+		if q == 0.99 {
+			continue
+		}
+		name := quantileName(q)
+		values, err := g.getQuantile(endTime, q, "mattermost_db_store_time", "method", sec)
+		if err != nil {
+			return data, fmt.Errorf("error while getting %s store times: %w", name, err)
+		}
+		if data.StoreTimeQuantiles == nil {
+			data.StoreTimeQuantiles = make(map[string]map[model.LabelValue]model.SampleValue)
+		}
+		data.StoreTimeQuantiles[name] = values
+
+		values, err = g.getQuantile(endTime, q, "mattermost_api_time", "handler", sec)
+		if err != nil {
+			return data, fmt.Errorf("error while getting %s API times: %w", name, err)
+		}
+		if data.APITimeQuantiles == nil {
+			data.APITimeQuantiles = make(map[string]map[model.LabelValue]model.SampleValue)
+		}
+		data.APITimeQuantiles[name] = values
+	}
+
+	for _, hq := range g.cfg.Histograms {
+		values, err := g.getHistogram(endTime, hq, sec)
+		if err != nil {
+			return data, fmt.Errorf("error while getting %s: %w", hq.Name, err)
+		}
+		if data.Histograms == nil {
+			data.Histograms = make(map[string]HistogramValues)
+		}
+		data.Histograms[hq.Name] = values
+	}
+
 	for _, gq := range g.cfg.GraphQueries {
-		res, err := g.helper.Matrix(gq.Query, startTime, endTime)
+		res, err := g.source.Matrix(gq.Query, startTime, endTime)
 		if err != nil {
 			return data, fmt.Errorf("error while getting %s: %w", gq.Name, err)
 		}
@@ -139,11 +222,63 @@ func (g *Generator) Generate(startTime, endTime time.Time) (Report, error) {
 	return data, nil
 }
 
+// getHistogram returns the average and the configured quantiles of the
+// given histogram family.
+func (g *Generator) getHistogram(endTime time.Time, hq HistogramQuery, sec int) (HistogramValues, error) {
+	var values HistogramValues
+	var err error
+
+	tmpl := `sum(rate(%s_sum%s[%ds])) by (%s) / sum(rate(%s_count%s[%ds])) by (%s)`
+	query := fmt.Sprintf(tmpl, hq.Metric, g.cfg.Label, sec, hq.By, hq.Metric, g.cfg.Label, sec, hq.By)
+	values.Avg, err = g.getValue(endTime, query, hq.By)
+	if err != nil {
+		return values, fmt.Errorf("error while getting avg: %w", err)
+	}
+
+	values.Quantiles = make(map[string]map[model.LabelValue]model.SampleValue)
+	for _, q := range quantiles(g.cfg.Quantiles) {
+		name := quantileName(q)
+		values.Quantiles[name], err = g.getQuantile(endTime, q, hq.Metric, hq.By, sec)
+		if err != nil {
+			return values, fmt.Errorf("error while getting %s: %w", name, err)
+		}
+	}
+
+	return values, nil
+}
+
+// getQuantile returns the q-quantile of the given histogram metric grouped
+// by label.
+func (g *Generator) getQuantile(endTime time.Time, q float64, metric, label string, sec int) (map[model.LabelValue]model.SampleValue, error) {
+	tmpl := `histogram_quantile(%s, sum(rate(%s_bucket%s[%ds])) by (%s,le))`
+	query := fmt.Sprintf(tmpl, strconv.FormatFloat(q, 'f', -1, 64), metric, g.cfg.Label, sec, label)
+	return g.getValue(endTime, query, label)
+}
+
+// quantiles returns the sorted set of configured quantiles, always
+// including 0.99.
+func quantiles(configured []float64) []float64 {
+	qs := []float64{0.99}
+	for _, q := range configured {
+		if q != 0.99 {
+			qs = append(qs, q)
+		}
+	}
+	sort.Float64s(qs)
+	return qs
+}
+
+// quantileName returns a human readable name for the given quantile
+// (e.g. "p99.9" for 0.999).
+func quantileName(q float64) string {
+	return "p" + strconv.FormatFloat(math.Round(q*100000)/1000, 'f', -1, 64)
+}
+
 // getValue returns a map of labels to the values for the last timestamp of a given query.
 func (g *Generator) getValue(endTime time.Time, query, label string) (map[model.LabelValue]model.SampleValue, error) {
 	// We just query from endTime-5s to endTime because the query already computes the values
 	// from startTime to endTime.
-	res, err := g.helper.Matrix(query, endTime.Add(-5*time.Second), endTime)
+	res, err := g.source.Matrix(query, endTime.Add(-5*time.Second), endTime)
 	if err != nil {
 		return nil, err
 	}
@@ -249,3 +249,69 @@ func TestGenerate(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, output, r, "incorrect report generated")
 }
+
+func TestGenerateQuantilesAndHistograms(t *testing.T) {
+	sample := func(label, value string, v model.SampleValue) model.Matrix {
+		return model.Matrix{
+			&model.SampleStream{
+				Metric: model.Metric{model.LabelName(label): model.LabelValue(value)},
+				Values: []model.SamplePair{{Timestamp: model.Now(), Value: v}},
+			},
+		}
+	}
+
+	input := map[string]model.Matrix{
+		"sum(rate(mattermost_db_store_time_sum[10s])) by (method) / sum(rate(mattermost_db_store_time_count[10s])) by (method)":                     sample("method", "method1", 0.01),
+		"histogram_quantile(0.99, sum(rate(mattermost_db_store_time_bucket[10s])) by (le,method))":                                                  sample("method", "method1", 0.02),
+		"sum(rate(mattermost_api_time_sum[10s])) by (handler) / sum(rate(mattermost_api_time_count[10s])) by (handler)":                             sample("handler", "handler1", 0.01),
+		"histogram_quantile(0.99, sum(rate(mattermost_api_time_bucket[10s])) by (handler,le))":                                                      sample("handler", "handler1", 0.02),
+		"histogram_quantile(0.5, sum(rate(mattermost_db_store_time_bucket[10s])) by (method,le))":                                                   sample("method", "method1", 0.005),
+		"histogram_quantile(0.5, sum(rate(mattermost_api_time_bucket[10s])) by (handler,le))":                                                       sample("handler", "handler1", 0.006),
+		"sum(rate(mattermost_websocket_broadcast_time_sum[10s])) by (event) / sum(rate(mattermost_websocket_broadcast_time_count[10s])) by (event)": sample("event", "posted", 0.01),
+		"histogram_quantile(0.5, sum(rate(mattermost_websocket_broadcast_time_bucket[10s])) by (event,le))":                                         sample("event", "posted", 0.008),
+		"histogram_quantile(0.99, sum(rate(mattermost_websocket_broadcast_time_bucket[10s])) by (event,le))":                                        sample("event", "posted", 0.05),
+	}
+
+	cfg := Config{
+		Quantiles: []float64{0.5, 0.99},
+		Histograms: []HistogramQuery{
+			{
+				Name:   "WebSocket broadcast",
+				Metric: "mattermost_websocket_broadcast_time",
+				By:     "event",
+			},
+		},
+	}
+
+	helper := &prometheus.Helper{}
+	helper.SetAPI(mockAPI{
+		dataMap: input,
+	})
+
+	endTime := time.Now()
+	r, err := New("base", helper, cfg).Generate(endTime.Add(-10*time.Second), endTime)
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]map[model.LabelValue]model.SampleValue{
+		"p50": {"method1": 0.005},
+	}, r.StoreTimeQuantiles)
+	require.Equal(t, map[string]map[model.LabelValue]model.SampleValue{
+		"p50": {"handler1": 0.006},
+	}, r.APITimeQuantiles)
+	require.Equal(t, map[string]HistogramValues{
+		"WebSocket broadcast": {
+			Avg: map[model.LabelValue]model.SampleValue{"posted": 0.01},
+			Quantiles: map[string]map[model.LabelValue]model.SampleValue{
+				"p50": {"posted": 0.008},
+				"p99": {"posted": 0.05},
+			},
+		},
+	}, r.Histograms)
+}
+
+func TestQuantileName(t *testing.T) {
+	require.Equal(t, "p50", quantileName(0.5))
+	require.Equal(t, "p95", quantileName(0.95))
+	require.Equal(t, "p99", quantileName(0.99))
+	require.Equal(t, "p99.9", quantileName(0.999))
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// Dimensions, in pixels, of the charts embedded in HTML reports.
+const (
+	htmlChartWidth  = 640
+	htmlChartHeight = 360
+)
+
+// htmlCell is a single cell of a table in an HTML report.
+type htmlCell struct {
+	Text string
+	// The value used to sort the column, if different from the text.
+	Sort  string
+	Class string
+}
+
+// htmlTable is a sortable table in an HTML report.
+type htmlTable struct {
+	Title   string
+	Headers []string
+	Rows    [][]htmlCell
+}
+
+// htmlSection is a group of tables in an HTML report.
+type htmlSection struct {
+	Title  string
+	Tables []htmlTable
+}
+
+// htmlReport holds the data needed to render an HTML report.
+type htmlReport struct {
+	Title    string
+	Base     string
+	Labels   []string
+	Warnings []string
+	Sections []htmlSection
+	Charts   []template.HTML
+}
+
+// displayHTML renders a given comparison as a self-contained HTML page to
+// the given target. Graphs are embedded as inline SVG charts.
+func displayHTML(target io.Writer, c comp, base Report, others []Report, gs []graphStats) error {
+	cols := len(c.labels)
+	data := htmlReport{
+		Title:    "Load-test comparison",
+		Base:     base.Label,
+		Labels:   c.labels,
+		Warnings: c.warnings,
+	}
+
+	// The summary only handles comparisons between 2 reports.
+	if cols == 1 {
+		summary := htmlSection{Title: "Summary"}
+		for _, s := range []struct {
+			name string
+			data map[model.LabelValue]avgp99
+		}{{"Store times", c.store}, {"API times", c.api}} {
+			for _, improved := range []bool{false, true} {
+				for _, metric := range []string{"avg", "p99"} {
+					title := fmt.Sprintf("%s %s (worsened)", s.name, metric)
+					if improved {
+						title = fmt.Sprintf("%s %s (improved)", s.name, metric)
+					}
+					table := htmlTable{Title: title, Headers: timesHeaders(cols)}
+					for _, e := range summaryEntries(s.data, metric, improved) {
+						row := []htmlCell{{Text: string(e.label)}, {Text: e.metric}, durationCell(e.d.base)}
+						table.Rows = append(table.Rows, append(row, diffCells(e.d)...))
+					}
+					summary.Tables = append(summary.Tables, table)
+				}
+			}
+		}
+		data.Sections = append(data.Sections, summary)
+	}
+
+	details := htmlSection{Title: "Details"}
+	details.Tables = append(details.Tables,
+		timesTable("Store times", c.store, base.AvgStoreTimes, base.P99StoreTimes, cols),
+		timesTable("API times", c.api, base.AvgAPITimes, base.P99APITimes, cols),
+	)
+	for _, sc := range c.stats {
+		table := htmlTable{Title: fmt.Sprintf("%s %s", sc.name, sc.stat), Headers: timesHeaders(cols)}
+		labels := make([]model.LabelValue, 0, len(sc.diffs))
+		for label := range sc.diffs {
+			labels = append(labels, label)
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i] < labels[j] })
+		for _, label := range labels {
+			row := []htmlCell{{Text: string(label)}, {Text: strings.ToUpper(sc.stat[:1]) + sc.stat[1:]}, durationCell(getDuration(float64(sc.base[label])))}
+			for _, d := range sc.diffs[label] {
+				row = append(row, diffCells(d)...)
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		details.Tables = append(details.Tables, table)
+	}
+	if len(gs) > 0 {
+		table := htmlTable{
+			Title:   "Graphs",
+			Headers: []string{"Graph", "Base", "Base 95% CI", "Actual", "Actual 95% CI", "Delta %", "p-value"},
+		}
+		for _, g := range gs {
+			deltaCell := numCell(fmt.Sprintf("%.3f", g.deltaPercent), g.deltaPercent)
+			if g.significant {
+				deltaCell.Class += " significant"
+			}
+			pValueCell := numCell(fmt.Sprintf("%.3f", g.pValue), g.pValue)
+			if g.untestable {
+				pValueCell.Text = "not testable"
+			}
+			table.Rows = append(table.Rows, []htmlCell{
+				{Text: g.name},
+				numCell(fmt.Sprintf("%.4f", g.base[0]), g.base[0]),
+				{Text: fmt.Sprintf("%.4f - %.4f", g.base[1], g.base[2])},
+				numCell(fmt.Sprintf("%.4f", g.actual[0]), g.actual[0]),
+				{Text: fmt.Sprintf("%.4f - %.4f", g.actual[1], g.actual[2])},
+				deltaCell,
+				pValueCell,
+			})
+		}
+		details.Tables = append(details.Tables, table)
+	}
+	data.Sections = append(data.Sections, details)
+
+	if capacity := capacitySection(append([]Report{base}, others...)); capacity != nil {
+		data.Sections = append(data.Sections, *capacity)
+	}
+
+	for _, ch := range getCharts(base, others...) {
+		data.Charts = append(data.Charts, template.HTML(ch.svg(htmlChartWidth, htmlChartHeight)))
+	}
+
+	tmpl, err := template.New("").Funcs(template.FuncMap{
+		"inc": func(i int) int { return i + 1 },
+	}).Parse(MustAssetString("report.tmpl.html"))
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	if err := tmpl.Execute(target, data); err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return nil
+}
+
+// getCharts returns a chart for each graph of the base report, comparing it
+// against the same graph of the other reports.
+func getCharts(base Report, others ...Report) []chart {
+	var charts []chart
+	for i, plot := range getPlots(others...) {
+		if i >= len(base.Graphs) {
+			continue
+		}
+		charts = append(charts, newChart(base.Label, base.Graphs[i], plot.graphs))
+	}
+	return charts
+}
+
+// timesTable returns a table with the avg and p99 times of every label.
+func timesTable(title string, data map[model.LabelValue]avgp99, baseAvg, baseP99 map[model.LabelValue]model.SampleValue, cols int) htmlTable {
+	table := htmlTable{Title: title, Headers: timesHeaders(cols)}
+	for _, label := range sortKeys(data, sortByLabel, false) {
+		measurement := data[label]
+		for i, stat := range []string{"Avg", "P99"} {
+			baseValue := baseAvg[label]
+			if i == 1 {
+				baseValue = baseP99[label]
+			}
+			row := []htmlCell{{Text: string(label)}, {Text: stat}, durationCell(getDuration(float64(baseValue)))}
+			for _, d := range measurement[i] {
+				row = append(row, diffCells(d)...)
+			}
+			table.Rows = append(table.Rows, row)
+		}
+	}
+	return table
+}
+
+// timesHeaders returns the headers of a table comparing times across the
+// given number of reports.
+func timesHeaders(cols int) []string {
+	headers := []string{"Name", "Stat", "Base"}
+	for i := 0; i < cols; i++ {
+		if cols > 1 {
+			headers = append(headers, fmt.Sprintf("Actual %d", i+1), fmt.Sprintf("Delta %d", i+1), fmt.Sprintf("Delta %% %d", i+1))
+			continue
+		}
+		headers = append(headers, "Actual", "Delta", "Delta %")
+	}
+	return headers
+}
+
+// diffCells returns the actual, delta and delta percentage cells of a diff.
+func diffCells(d diff) []htmlCell {
+	deltaPercent := numCell(fmt.Sprintf("%.3f", d.deltaPercent), d.deltaPercent)
+	if d.untestable {
+		deltaPercent.Text = fmt.Sprintf("%.3f (not testable)", d.deltaPercent)
+	} else if d.tested {
+		deltaPercent.Text = fmt.Sprintf("%.3f (p=%.3f)", d.deltaPercent, d.pValue)
+	}
+	switch {
+	case d.delta > 0:
+		deltaPercent.Class += " worse"
+	case d.delta < 0:
+		deltaPercent.Class += " better"
+	}
+	if d.significant {
+		deltaPercent.Class += " significant"
+	}
+	return []htmlCell{durationCell(d.actual), durationCell(d.delta), deltaPercent}
+}
+
+// capacitySection returns a section with the capacity curves of the given
+// reports, or nil if none of them has one.
+func capacitySection(reports []Report) *htmlSection {
+	users := htmlTable{Title: "Supported users", Headers: []string{"Report", "Supported users", "95% CI"}}
+	knees := htmlTable{Title: "Capacity knee points", Headers: []string{"Query", "Report", "Active users", "Value"}}
+	var found bool
+	for _, r := range reports {
+		if r.CapacityCurve == nil {
+			continue
+		}
+		found = true
+		if ci := r.CapacityCurve.SupportedUsers; ci != nil {
+			users.Rows = append(users.Rows, []htmlCell{
+				{Text: r.Label},
+				numCell(fmt.Sprintf("%.0f", ci.Mean), ci.Mean),
+				{Text: fmt.Sprintf("%.1f - %.1f", ci.Lower, ci.Upper)},
+			})
+		}
+		for _, k := range r.CapacityCurve.Knees {
+			knees.Rows = append(knees.Rows, []htmlCell{
+				{Text: k.Query},
+				{Text: r.Label},
+				numCell(strconv.Itoa(k.ActiveUsers), float64(k.ActiveUsers)),
+				numCell(fmt.Sprintf("%.4f", k.Value), k.Value),
+			})
+		}
+	}
+	if !found {
+		return nil
+	}
+	return &htmlSection{Title: "Capacity", Tables: []htmlTable{users, knees}}
+}
+
+func numCell(text string, value float64) htmlCell {
+	return htmlCell{Text: text, Sort: strconv.FormatFloat(value, 'g', -1, 64), Class: "num"}
+}
+
+func durationCell(d time.Duration) htmlCell {
+	return numCell(d.String(), d.Seconds())
+}
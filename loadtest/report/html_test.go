@@ -0,0 +1,70 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package report
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareHTML(t *testing.T) {
+	newReport := func(label string, storeTime, cpu model.SampleValue) Report {
+		return Report{
+			Label:         label,
+			AvgStoreTimes: map[model.LabelValue]model.SampleValue{"Post.Get": storeTime},
+			P99StoreTimes: map[model.LabelValue]model.SampleValue{"Post.Get": storeTime * 2},
+			AvgAPITimes:   map[model.LabelValue]model.SampleValue{"getPosts": storeTime * 3},
+			P99APITimes:   map[model.LabelValue]model.SampleValue{"getPosts": storeTime * 4},
+			Graphs: []graph{
+				{
+					Name: "CPU",
+					Values: []model.SamplePair{
+						{Value: cpu},
+						{Value: model.SampleValue(math.NaN())},
+						{Value: cpu * 2},
+					},
+				},
+			},
+		}
+	}
+
+	var buf bytes.Buffer
+	err := Compare(&buf, CompareOpts{Format: FormatHTML}, newReport("base <1>", 0.1, 10), newReport("new", 0.2, 20))
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"))
+	// Labels are escaped.
+	require.Contains(t, out, "base &lt;1&gt;")
+	require.NotContains(t, out, "base <1>")
+	require.Contains(t, out, "<h2>Summary</h2>")
+	require.Contains(t, out, "<h3>Store times avg (worsened)</h3>")
+	require.Contains(t, out, `<td class="num worse" data-sort="100">100.000</td>`)
+	require.Contains(t, out, "<td>Post.Get</td><td>P99</td>")
+	require.Contains(t, out, "<title>CPU</title>")
+	// The NaN value splits the series in two lines.
+	require.Equal(t, 4, strings.Count(out, "<polyline"))
+	// Charts are rendered inline, not escaped.
+	require.NotContains(t, out, "&lt;svg")
+
+	buf.Reset()
+	err = Compare(&buf, CompareOpts{Format: FormatHTML}, newReport("base", 0.1, 10), newReport("fix1", 0.2, 20), newReport("fix2", 0.05, 5))
+	require.NoError(t, err)
+	out = buf.String()
+	require.NotContains(t, out, "<h2>Summary</h2>")
+	require.Contains(t, out, "<th>Delta % 2</th>")
+	require.Contains(t, out, "<th>Report 2</th><td>fix2</td>")
+	require.Contains(t, out, `<td class="num better" data-sort="-50">-50.000</td>`)
+}
+
+func TestNiceTicks(t *testing.T) {
+	require.Equal(t, []float64{0, 0.2, 0.4, 0.6, 0.8, 1}, niceTicks(0, 1, 5))
+	require.Equal(t, []float64{0, 50, 100, 150}, niceTicks(0, 137, 4))
+	require.Equal(t, []float64{-10, -5, 0, 5, 10}, niceTicks(-7, 8, 5))
+}
@@ -0,0 +1,355 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package report
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/shared/mlog"
+)
+
+// GraphFormat is the image format of the generated graphs.
+type GraphFormat string
+
+// Available formats for graphs.
+const (
+	GraphFormatPNG GraphFormat = "png"
+	GraphFormatSVG GraphFormat = "svg"
+)
+
+// Dimensions, in pixels, of the generated graphs.
+const (
+	graphWidth  = 640
+	graphHeight = 480
+	// The number of graphs per row in the image combining all of them.
+	combinedGraphsColumns = 2
+)
+
+// combinedGraphsName is the name of the image combining all the graphs.
+const combinedGraphsName = "all-graphs"
+
+// generateGraphs writes an image for each of the given charts, along with
+// one combining all of them. The name of each image is the lower-cased name
+// of its metric, with spaces replaced by dashes, after the given prefix.
+func generateGraphs(charts []chart, prefix string, format GraphFormat) error {
+	if format == "" {
+		format = GraphFormatPNG
+	}
+	if format != GraphFormatPNG && format != GraphFormatSVG {
+		return fmt.Errorf("unsupported graph format %q", format)
+	}
+	if len(charts) == 0 {
+		return nil
+	}
+
+	for _, ch := range charts {
+		name := prefix + strings.Replace(strings.ToLower(ch.title), " ", "-", -1)
+		if err := writeGraph(name, format, []chart{ch}, 1); err != nil {
+			return fmt.Errorf("error while generating graph for %s: %w", ch.title, err)
+		}
+	}
+
+	if err := writeGraph(prefix+combinedGraphsName, format, charts, combinedGraphsColumns); err != nil {
+		return fmt.Errorf("error while generating combined graph: %w", err)
+	}
+
+	return nil
+}
+
+// writeGraph writes the given charts, laid out in a grid with the given
+// number of columns, as a single image named after the given name.
+func writeGraph(name string, format GraphFormat, charts []chart, cols int) error {
+	cols = min(cols, len(charts))
+	rows := (len(charts) + cols - 1) / cols
+	width, height := cols*graphWidth, rows*graphHeight
+
+	f, err := os.Create(name + "." + string(format))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch format {
+	case GraphFormatSVG:
+		var sb strings.Builder
+		fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`, width, height, width, height)
+		for i, ch := range charts {
+			fmt.Fprintf(&sb, `<g transform="translate(%d,%d)">`, (i%cols)*graphWidth, (i/cols)*graphHeight)
+			ch.writeSVG(&sb, graphWidth, graphHeight)
+			sb.WriteString(`</g>`)
+		}
+		sb.WriteString("</svg>\n")
+		if _, err := f.WriteString(sb.String()); err != nil {
+			return err
+		}
+	default:
+		img := newCanvas(width, height)
+		for i, ch := range charts {
+			at := image.Pt((i%cols)*graphWidth, (i/cols)*graphHeight)
+			draw.Draw(img.RGBA, image.Rectangle{Min: at, Max: at.Add(image.Pt(graphWidth, graphHeight))}, ch.image(graphWidth, graphHeight), image.Point{}, draw.Src)
+		}
+		if err := png.Encode(f, img); err != nil {
+			return err
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+	mlog.Info("Wrote " + f.Name())
+	return nil
+}
+
+// Dimensions, in pixels, of the glyphs of the bitmap font used to render
+// text in images.
+const (
+	glyphWidth   = 5
+	glyphHeight  = 7
+	glyphAdvance = glyphWidth + 1
+)
+
+// glyphs is a 5x7 bitmap font covering the printable ASCII characters.
+// Each glyph is made of one byte per row, top to bottom, with the most
+// significant of the 5 lower bits being the leftmost pixel.
+var glyphs = [95][glyphHeight]uint8{
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // ' '
+	{0x04, 0x04, 0x04, 0x04, 0x00, 0x00, 0x04}, // '!'
+	{0x0a, 0x0a, 0x0a, 0x00, 0x00, 0x00, 0x00}, // '"'
+	{0x0a, 0x0a, 0x1f, 0x0a, 0x1f, 0x0a, 0x0a}, // '#'
+	{0x04, 0x0f, 0x14, 0x0e, 0x05, 0x1e, 0x04}, // '$'
+	{0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03}, // '%'
+	{0x0c, 0x12, 0x14, 0x08, 0x15, 0x12, 0x0d}, // '&'
+	{0x0c, 0x04, 0x08, 0x00, 0x00, 0x00, 0x00}, // '\''
+	{0x02, 0x04, 0x08, 0x08, 0x08, 0x04, 0x02}, // '('
+	{0x08, 0x04, 0x02, 0x02, 0x02, 0x04, 0x08}, // ')'
+	{0x00, 0x04, 0x15, 0x0e, 0x15, 0x04, 0x00}, // '*'
+	{0x00, 0x04, 0x04, 0x1f, 0x04, 0x04, 0x00}, // '+'
+	{0x00, 0x00, 0x00, 0x00, 0x0c, 0x04, 0x08}, // ','
+	{0x00, 0x00, 0x00, 0x1f, 0x00, 0x00, 0x00}, // '-'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x0c, 0x0c}, // '.'
+	{0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00}, // '/'
+	{0x0e, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0e}, // '0'
+	{0x04, 0x0c, 0x04, 0x04, 0x04, 0x04, 0x0e}, // '1'
+	{0x0e, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1f}, // '2'
+	{0x1f, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0e}, // '3'
+	{0x02, 0x06, 0x0a, 0x12, 0x1f, 0x02, 0x02}, // '4'
+	{0x1f, 0x10, 0x1e, 0x01, 0x01, 0x11, 0x0e}, // '5'
+	{0x06, 0x08, 0x10, 0x1e, 0x11, 0x11, 0x0e}, // '6'
+	{0x1f, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08}, // '7'
+	{0x0e, 0x11, 0x11, 0x0e, 0x11, 0x11, 0x0e}, // '8'
+	{0x0e, 0x11, 0x11, 0x0f, 0x01, 0x02, 0x0c}, // '9'
+	{0x00, 0x0c, 0x0c, 0x00, 0x0c, 0x0c, 0x00}, // ':'
+	{0x00, 0x0c, 0x0c, 0x00, 0x0c, 0x04, 0x08}, // ';'
+	{0x02, 0x04, 0x08, 0x10, 0x08, 0x04, 0x02}, // '<'
+	{0x00, 0x00, 0x1f, 0x00, 0x1f, 0x00, 0x00}, // '='
+	{0x08, 0x04, 0x02, 0x01, 0x02, 0x04, 0x08}, // '>'
+	{0x0e, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04}, // '?'
+	{0x0e, 0x11, 0x01, 0x0d, 0x15, 0x15, 0x0e}, // '@'
+	{0x0e, 0x11, 0x11, 0x11, 0x1f, 0x11, 0x11}, // 'A'
+	{0x1e, 0x11, 0x11, 0x1e, 0x11, 0x11, 0x1e}, // 'B'
+	{0x0e, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0e}, // 'C'
+	{0x1c, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1c}, // 'D'
+	{0x1f, 0x10, 0x10, 0x1e, 0x10, 0x10, 0x1f}, // 'E'
+	{0x1f, 0x10, 0x10, 0x1e, 0x10, 0x10, 0x10}, // 'F'
+	{0x0e, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0f}, // 'G'
+	{0x11, 0x11, 0x11, 0x1f, 0x11, 0x11, 0x11}, // 'H'
+	{0x0e, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0e}, // 'I'
+	{0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0c}, // 'J'
+	{0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11}, // 'K'
+	{0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1f}, // 'L'
+	{0x11, 0x1b, 0x15, 0x15, 0x11, 0x11, 0x11}, // 'M'
+	{0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11}, // 'N'
+	{0x0e, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0e}, // 'O'
+	{0x1e, 0x11, 0x11, 0x1e, 0x10, 0x10, 0x10}, // 'P'
+	{0x0e, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0d}, // 'Q'
+	{0x1e, 0x11, 0x11, 0x1e, 0x14, 0x12, 0x11}, // 'R'
+	{0x0f, 0x10, 0x10, 0x0e, 0x01, 0x01, 0x1e}, // 'S'
+	{0x1f, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04}, // 'T'
+	{0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0e}, // 'U'
+	{0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x04}, // 'V'
+	{0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0a}, // 'W'
+	{0x11, 0x11, 0x0a, 0x04, 0x0a, 0x11, 0x11}, // 'X'
+	{0x11, 0x11, 0x11, 0x0a, 0x04, 0x04, 0x04}, // 'Y'
+	{0x1f, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1f}, // 'Z'
+	{0x0e, 0x08, 0x08, 0x08, 0x08, 0x08, 0x0e}, // '['
+	{0x00, 0x10, 0x08, 0x04, 0x02, 0x01, 0x00}, // '\\'
+	{0x0e, 0x02, 0x02, 0x02, 0x02, 0x02, 0x0e}, // ']'
+	{0x04, 0x0a, 0x11, 0x00, 0x00, 0x00, 0x00}, // '^'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1f}, // '_'
+	{0x08, 0x04, 0x02, 0x00, 0x00, 0x00, 0x00}, // '`'
+	{0x00, 0x00, 0x0e, 0x01, 0x0f, 0x11, 0x0f}, // 'a'
+	{0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x1e}, // 'b'
+	{0x00, 0x00, 0x0e, 0x10, 0x10, 0x11, 0x0e}, // 'c'
+	{0x01, 0x01, 0x0d, 0x13, 0x11, 0x11, 0x0f}, // 'd'
+	{0x00, 0x00, 0x0e, 0x11, 0x1f, 0x10, 0x0e}, // 'e'
+	{0x06, 0x09, 0x08, 0x1c, 0x08, 0x08, 0x08}, // 'f'
+	{0x00, 0x0f, 0x11, 0x11, 0x0f, 0x01, 0x0e}, // 'g'
+	{0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x11}, // 'h'
+	{0x04, 0x00, 0x0c, 0x04, 0x04, 0x04, 0x0e}, // 'i'
+	{0x02, 0x00, 0x06, 0x02, 0x02, 0x12, 0x0c}, // 'j'
+	{0x10, 0x10, 0x12, 0x14, 0x18, 0x14, 0x12}, // 'k'
+	{0x0c, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0e}, // 'l'
+	{0x00, 0x00, 0x1a, 0x15, 0x15, 0x11, 0x11}, // 'm'
+	{0x00, 0x00, 0x16, 0x19, 0x11, 0x11, 0x11}, // 'n'
+	{0x00, 0x00, 0x0e, 0x11, 0x11, 0x11, 0x0e}, // 'o'
+	{0x00, 0x00, 0x1e, 0x11, 0x1e, 0x10, 0x10}, // 'p'
+	{0x00, 0x00, 0x0d, 0x13, 0x0f, 0x01, 0x01}, // 'q'
+	{0x00, 0x00, 0x16, 0x19, 0x10, 0x10, 0x10}, // 'r'
+	{0x00, 0x00, 0x0e, 0x10, 0x0e, 0x01, 0x1e}, // 's'
+	{0x08, 0x08, 0x1c, 0x08, 0x08, 0x09, 0x06}, // 't'
+	{0x00, 0x00, 0x11, 0x11, 0x11, 0x13, 0x0d}, // 'u'
+	{0x00, 0x00, 0x11, 0x11, 0x11, 0x0a, 0x04}, // 'v'
+	{0x00, 0x00, 0x11, 0x11, 0x15, 0x15, 0x0a}, // 'w'
+	{0x00, 0x00, 0x11, 0x0a, 0x04, 0x0a, 0x11}, // 'x'
+	{0x00, 0x00, 0x11, 0x11, 0x0f, 0x01, 0x0e}, // 'y'
+	{0x00, 0x00, 0x1f, 0x02, 0x04, 0x08, 0x1f}, // 'z'
+	{0x02, 0x04, 0x04, 0x08, 0x04, 0x04, 0x02}, // '{'
+	{0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04}, // '|'
+	{0x08, 0x04, 0x04, 0x02, 0x04, 0x04, 0x08}, // '}'
+	{0x00, 0x00, 0x08, 0x15, 0x02, 0x00, 0x00}, // '~'
+}
+
+// canvas is an image on which charts are drawn.
+type canvas struct {
+	*image.RGBA
+}
+
+func newCanvas(width, height int) canvas {
+	c := canvas{image.NewRGBA(image.Rect(0, 0, width, height))}
+	draw.Draw(c.RGBA, c.Bounds(), image.White, image.Point{}, draw.Src)
+	return c
+}
+
+// parseColor parses a color in the #rrggbb format.
+func parseColor(s string) color.RGBA {
+	v, _ := strconv.ParseUint(s[1:], 16, 32)
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+}
+
+// fillRect fills the given rectangle.
+func (c canvas) fillRect(r image.Rectangle, col color.Color) {
+	draw.Draw(c.RGBA, r, image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+// line draws a line of the given thickness between two points.
+func (c canvas) line(p0, p1 chartPoint, thickness int, col color.Color) {
+	x0, y0 := int(math.Round(p0.x)), int(math.Round(p0.y))
+	x1, y1 := int(math.Round(p1.x)), int(math.Round(p1.y))
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	// Bresenham's line algorithm.
+	e := dx + dy
+	for {
+		c.fillRect(image.Rect(x0, y0, x0+thickness, y0+thickness), col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x0 += sx
+		} else {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// text draws the given text with its top left corner at the given position.
+// Characters not covered by the font are drawn as a question mark.
+func (c canvas) text(x, y int, s string, scale int, col color.Color) {
+	for _, r := range s {
+		if r < ' ' || r > '~' {
+			r = '?'
+		}
+		for row, bits := range glyphs[r-' '] {
+			for i := 0; i < glyphWidth; i++ {
+				if bits&(1<<(glyphWidth-1-i)) != 0 {
+					c.fillRect(image.Rect(x+i*scale, y+row*scale, x+(i+1)*scale, y+(row+1)*scale), col)
+				}
+			}
+		}
+		x += glyphAdvance * scale
+	}
+}
+
+// textWidth returns the width, in pixels, of the given text.
+func textWidth(s string, scale int) int {
+	return len([]rune(s)) * glyphAdvance * scale
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// image renders the chart as an image of the given size.
+func (ch chart) image(width, height int) *image.RGBA {
+	l := ch.layout(width, height)
+	c := newCanvas(width, height)
+	black := color.RGBA{A: 0xff}
+	grid := parseColor("#e5e5e5")
+	frame := parseColor("#333333")
+
+	c.text((width-textWidth(ch.title, 2))/2, 6, ch.title, 2, black)
+
+	// Grid and axes.
+	left, top, right, bottom := int(l.left), int(l.top), int(l.right), int(l.bottom)
+	for _, tick := range l.yTicks {
+		y := int(math.Round(tick.pos))
+		c.fillRect(image.Rect(left, y, right, y+1), grid)
+		c.text(left-6-textWidth(tick.label, 1), y-glyphHeight/2, tick.label, 1, black)
+	}
+	for _, tick := range l.xTicks {
+		x := int(math.Round(tick.pos))
+		c.fillRect(image.Rect(x, top, x+1, bottom), grid)
+		c.text(x-textWidth(tick.label, 1)/2, bottom+8, tick.label, 1, black)
+	}
+	c.fillRect(image.Rect(left, top, right+1, top+1), frame)
+	c.fillRect(image.Rect(left, bottom, right+1, bottom+1), frame)
+	c.fillRect(image.Rect(left, top, left+1, bottom+1), frame)
+	c.fillRect(image.Rect(right, top, right+1, bottom+1), frame)
+	xLabel := "time (normalized)"
+	c.text((left+right-textWidth(xLabel, 1))/2, bottom+24, xLabel, 1, black)
+
+	// Series.
+	for i, lines := range l.lines {
+		col := parseColor(chartColors[i%len(chartColors)])
+		for _, line := range lines {
+			for j := range line {
+				p0 := line[j]
+				if j > 0 {
+					p0 = line[j-1]
+				}
+				c.line(p0, line[j], 2, col)
+			}
+		}
+	}
+
+	// Legend.
+	x := left
+	y := height - 10 - glyphHeight
+	for i, s := range ch.series {
+		c.fillRect(image.Rect(x, y+2, x+12, y+6), parseColor(chartColors[i%len(chartColors)]))
+		c.text(x+16, y, s.label, 1, black)
+		x += 16 + textWidth(s.label, 1) + 20
+	}
+
+	return c.RGBA
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package report
+
+import (
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateGraphs(t *testing.T) {
+	newReport := func(label string, factor float64) Report {
+		r := Report{Label: label}
+		for _, name := range []string{"CPU", "Memory", "Open Connections"} {
+			g := graph{Name: name}
+			for i := 0; i < 60; i++ {
+				g.Values = append(g.Values, model.SamplePair{Value: model.SampleValue(factor * (50 + 30*math.Sin(float64(i)/8)))})
+			}
+			r.Graphs = append(r.Graphs, g)
+		}
+		return r
+	}
+	reports := []Report{newReport("base", 1), newReport("new", 1.2)}
+
+	t.Run("png", func(t *testing.T) {
+		prefix := filepath.Join(t.TempDir(), "postgres_bounded_0_")
+		err := Compare(&strings.Builder{}, CompareOpts{GenGraph: true, GraphsPrefix: prefix}, reports...)
+		require.NoError(t, err)
+
+		for _, name := range []string{"cpu", "memory", "open-connections"} {
+			f, err := os.Open(prefix + name + ".png")
+			require.NoError(t, err)
+			cfg, err := png.DecodeConfig(f)
+			f.Close()
+			require.NoError(t, err)
+			require.Equal(t, graphWidth, cfg.Width)
+			require.Equal(t, graphHeight, cfg.Height)
+		}
+
+		// The combined image lays out the graphs in two columns.
+		f, err := os.Open(prefix + combinedGraphsName + ".png")
+		require.NoError(t, err)
+		defer f.Close()
+		cfg, err := png.DecodeConfig(f)
+		require.NoError(t, err)
+		require.Equal(t, 2*graphWidth, cfg.Width)
+		require.Equal(t, 2*graphHeight, cfg.Height)
+	})
+
+	t.Run("svg", func(t *testing.T) {
+		prefix := filepath.Join(t.TempDir(), "graph_")
+		err := Compare(&strings.Builder{}, CompareOpts{GenGraph: true, GraphsPrefix: prefix, GraphsFormat: GraphFormatSVG}, reports...)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(prefix + "cpu.svg")
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(data), "<svg"))
+		require.Equal(t, 2, strings.Count(string(data), "<polyline"))
+
+		data, err = os.ReadFile(prefix + combinedGraphsName + ".svg")
+		require.NoError(t, err)
+		require.Equal(t, 3, strings.Count(string(data), "<g transform="))
+		require.Equal(t, 6, strings.Count(string(data), "<polyline"))
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		err := Compare(&strings.Builder{}, CompareOpts{GenGraph: true, GraphsPrefix: t.TempDir(), GraphsFormat: "gif"}, reports...)
+		require.Error(t, err)
+	})
+}
+
+func TestCanvasText(t *testing.T) {
+	c := newCanvas(20, 10)
+	black := parseColor("#000000")
+	c.text(0, 0, "1", 1, black)
+
+	// The glyph of "1" has its vertical stroke on the third column.
+	for y := 0; y < glyphHeight; y++ {
+		require.Equal(t, black, c.RGBAAt(2, y))
+	}
+	require.Equal(t, uint8(0xff), c.RGBAAt(4, 3).R)
+	require.Equal(t, 2*glyphAdvance, textWidth("ab", 1))
+	require.Equal(t, 2*glyphAdvance*2, textWidth("ab", 2))
+}
@@ -8,14 +8,11 @@ import (
 	"fmt"
 	"io"
 	"math"
-	"os"
-	"os/exec"
 	"sort"
 	"strings"
 	"text/template"
 	"time"
 
-	"github.com/mattermost/mattermost/server/public/shared/mlog"
 	"github.com/prometheus/common/model"
 )
 
@@ -49,49 +46,71 @@ const (
 	sortByP99
 )
 
-func printSummary(c comp, target io.Writer, cols int) {
-	printTimes := func(data map[model.LabelValue]avgp99, metric string, showImproved bool) {
-		var keys []model.LabelValue
-		var sortBy sortByType
+// summaryEntry is a single row of the summary of a comparison.
+type summaryEntry struct {
+	label  model.LabelValue
+	metric string
+	d      diff
+}
+
+// summaryEntries returns the measurements of the given data that worsened,
+// or improved if showImproved is set, sorted by their delta percentage.
+// Negligible and not statistically significant deltas are skipped.
+func summaryEntries(data map[model.LabelValue]avgp99, metric string, showImproved bool) []summaryEntry {
+	var keys []model.LabelValue
+	var sortBy sortByType
+	if metric == "avg" {
+		sortBy = sortByAvg
+	} else if metric == "p99" {
+		sortBy = sortByP99
+	}
+	if showImproved {
+		keys = sortKeys(data, sortBy, false)
+	} else {
+		keys = sortKeys(data, sortBy, true)
+	}
+
+	var entries []summaryEntry
+	for _, label := range keys {
+		measurement := data[label]
+		var d []diff
 		if metric == "avg" {
-			sortBy = sortByAvg
+			d = measurement[0]
 		} else if metric == "p99" {
-			sortBy = sortByP99
+			d = measurement[1]
+		}
+		// We only handle comparisons between 2 reports.
+		if len(d) != 1 {
+			break
+		}
+		// Skip delta percentages smaller than 1.
+		if math.Abs(d[0].deltaPercent) < 1 {
+			break
+		}
+		// Only show requested data.
+		if showImproved && d[0].delta > 0 || !showImproved && d[0].delta < 0 {
+			break
 		}
-		if showImproved {
-			keys = sortKeys(data, sortBy, false)
-		} else {
-			keys = sortKeys(data, sortBy, true)
+		// Skip deltas smaller than 2ms.
+		if math.Abs(float64(d[0].delta.Milliseconds())) < 2 {
+			continue
 		}
+		// Skip deltas which are not statistically significant.
+		if d[0].tested && !d[0].significant {
+			continue
+		}
+		entries = append(entries, summaryEntry{label: label, metric: metric, d: d[0]})
+	}
+	return entries
+}
 
-		for _, label := range keys {
-			measurement := data[label]
-			var d []diff
-			if metric == "avg" {
-				d = measurement[0]
-			} else if metric == "p99" {
-				d = measurement[1]
-			}
-			// We only handle comparisons between 2 reports.
-			if len(d) != 1 {
-				break
-			}
-			// Skip delta percentages smaller than 1.
-			if math.Abs(d[0].deltaPercent) < 1 {
-				break
-			}
-			// Only show requested data.
-			if showImproved && d[0].delta > 0 || !showImproved && d[0].delta < 0 {
-				break
-			}
-			// Skip deltas smaller than 2ms.
-			if math.Abs(float64(d[0].delta.Milliseconds())) < 2 {
-				continue
-			}
-			fmt.Fprintf(target, "| %s ", label)
-			fmt.Fprintf(target, "| %s ", metric)
-			fmt.Fprintf(target, "| %s ", d[0].base)
-			fmt.Fprintf(target, "| %s | %s | %.2f", d[0].actual, d[0].delta, d[0].deltaPercent)
+func printSummary(c comp, target io.Writer, cols int) {
+	printTimes := func(data map[model.LabelValue]avgp99, metric string, showImproved bool) {
+		for _, e := range summaryEntries(data, metric, showImproved) {
+			fmt.Fprintf(target, "| %s ", e.label)
+			fmt.Fprintf(target, "| %s ", e.metric)
+			fmt.Fprintf(target, "| %s ", e.d.base)
+			fmt.Fprintf(target, "| %s | %s | %.2f", e.d.actual, e.d.delta, e.d.deltaPercent)
 			fmt.Fprintln(target)
 		}
 	}
@@ -127,6 +146,22 @@ func printSummary(c comp, target io.Writer, cols int) {
 
 // displayMarkdown prints a given comparison in markdown to the given target.
 func displayMarkdown(c comp, target io.Writer, base Report, cols int) {
+	// When comparing more than two reports, columns are numbered so we
+	// print which report each of them refers to.
+	if cols > 1 && len(c.labels) == cols {
+		fmt.Fprintln(target, "### Reports:")
+		fmt.Fprintln(target, "| Column | Report |")
+		fmt.Fprintln(target, "| --- | --- |")
+		fmt.Fprintf(target, "| Base | %s |\n", base.Label)
+		for i, label := range c.labels {
+			fmt.Fprintf(target, "| %d | %s |\n", i+1, label)
+		}
+	}
+
+	for _, w := range c.warnings {
+		fmt.Fprintf(target, "> **Warning:** %s\n\n", w)
+	}
+
 	printSummary(c, target, cols)
 
 	fmt.Fprintln(target, "### Store times:")
@@ -142,14 +177,14 @@ func displayMarkdown(c comp, target io.Writer, base Report, cols int) {
 		fmt.Fprint(target, " |  Avg")
 		fmt.Fprintf(target, "| %s", getDuration(float64(base.AvgStoreTimes[label])))
 		for i := 0; i < len(avg); i++ {
-			fmt.Fprintf(target, "| %s | %s | %.3f", avg[i].actual, avg[i].delta, avg[i].deltaPercent)
+			fmt.Fprintf(target, "| %s | %s | %s", avg[i].actual, avg[i].delta, formatDeltaPercent(avg[i]))
 		}
 		fmt.Fprintln(target)
 
 		fmt.Fprint(target, "| |  P99")
 		fmt.Fprintf(target, "| %s", getDuration(float64(base.P99StoreTimes[label])))
 		for i := 0; i < len(p99); i++ {
-			fmt.Fprintf(target, "| %s | %s | %.3f", p99[i].actual, p99[i].delta, p99[i].deltaPercent)
+			fmt.Fprintf(target, "| %s | %s | %s", p99[i].actual, p99[i].delta, formatDeltaPercent(p99[i]))
 		}
 		fmt.Fprintln(target)
 	}
@@ -167,106 +202,139 @@ func displayMarkdown(c comp, target io.Writer, base Report, cols int) {
 		fmt.Fprint(target, " | Avg")
 		fmt.Fprintf(target, "| %s", getDuration(float64(base.AvgAPITimes[label])))
 		for i := 0; i < len(avg); i++ {
-			fmt.Fprintf(target, "| %s | %s | %.3f", avg[i].actual, avg[i].delta, avg[i].deltaPercent)
+			fmt.Fprintf(target, "| %s | %s | %s", avg[i].actual, avg[i].delta, formatDeltaPercent(avg[i]))
 		}
 		fmt.Fprintln(target)
 
 		fmt.Fprint(target, "| | P99")
 		fmt.Fprintf(target, "| %s", getDuration(float64(base.P99APITimes[label])))
 		for i := 0; i < len(p99); i++ {
-			fmt.Fprintf(target, "| %s | %s | %.3f", p99[i].actual, p99[i].delta, p99[i].deltaPercent)
+			fmt.Fprintf(target, "| %s | %s | %s", p99[i].actual, p99[i].delta, formatDeltaPercent(p99[i]))
 		}
 		fmt.Fprintln(target)
 	}
-}
 
-// printHeader prints the header row of a markdown table.
-func printHeader(target io.Writer, cols int) {
-	fmt.Fprint(target, "| | | Base | ")
-	header := ""
-	for i := 0; i < cols; i++ {
-		header += "Actual | Delta | Delta % |"
-	}
-	fmt.Fprintln(target, header)
+	for _, sc := range c.stats {
+		fmt.Fprintf(target, "### %s %s:\n", sc.name, sc.stat)
+		printHeader(target, cols)
 
-	fmt.Fprint(target, "| --- | --- | --- | ")
-	header = ""
-	for i := 0; i < cols; i++ {
-		header += "--- | --- | --- |"
+		labels := make([]model.LabelValue, 0, len(sc.diffs))
+		for label := range sc.diffs {
+			labels = append(labels, label)
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i] < labels[j] })
+
+		for _, label := range labels {
+			d := sc.diffs[label]
+			fmt.Fprintf(target, "| %s | %s", label, strings.ToUpper(sc.stat[:1])+sc.stat[1:])
+			fmt.Fprintf(target, "| %s", getDuration(float64(sc.base[label])))
+			for i := 0; i < len(d); i++ {
+				fmt.Fprintf(target, "| %s | %s | %s", d[i].actual, d[i].delta, formatDeltaPercent(d[i]))
+			}
+			fmt.Fprintln(target)
+		}
 	}
-	fmt.Fprintln(target, header)
 }
 
-// generateGraph creates an input file for GNUplot to create a plot from.
-func generateGraph(name, prefix, baseLabel string, base graph, others []labelValues) error {
-	f, err := os.CreateTemp("", "tmp.out")
-	if err != nil {
-		return err
+// formatDeltaPercent formats the delta percentage of the given diff along
+// with its p-value, if any. Statistically significant deltas are displayed in
+// bold.
+func formatDeltaPercent(d diff) string {
+	if d.untestable {
+		return fmt.Sprintf("%.3f (not testable)", d.deltaPercent)
 	}
-	defer os.Remove(f.Name())
-	// Write the header
-	fmt.Fprint(f, "# time, base")
-	for i := range others {
-		fmt.Fprintf(f, ", col%d", i)
+	if !d.tested {
+		return fmt.Sprintf("%.3f", d.deltaPercent)
 	}
-	fmt.Fprintln(f)
-	// Do a row-wise traversal of the data.
-	for i, pt := range base.Values {
-		// Write the line of values separated by comma.
-		fmt.Fprintf(f, "%d, %f", i, pt.Value)
-		for _, r := range others {
-			if i < len(r.values) {
-				fmt.Fprintf(f, ", %f", r.values[i].Value)
-			}
-		}
-		// Print a newline to go to the next timestamp.
-		fmt.Fprintln(f)
+	s := fmt.Sprintf("%.3f (p=%.3f)", d.deltaPercent, d.pValue)
+	if d.significant {
+		s = "**" + s + "**"
 	}
-	err = f.Close()
-	if err != nil {
-		return err
+	return s
+}
+
+// displayGraphStats prints the comparison of the graphs across multiple runs
+// in markdown to the given target.
+func displayGraphStats(target io.Writer, stats []graphStats) {
+	if len(stats) == 0 {
+		return
 	}
 
-	err = plot(name, prefix, f.Name(), others, baseLabel)
-	if err != nil {
-		return fmt.Errorf("error while plotting %s graph: %s", name, err)
+	fmt.Fprintln(target, "### Graphs:")
+	fmt.Fprintln(target, "| | Base | Base 95% CI | Actual | Actual 95% CI | Delta % | p-value |")
+	fmt.Fprintln(target, "| --- | --- | --- | --- | --- | --- | --- |")
+	for _, gs := range stats {
+		deltaP := fmt.Sprintf("%.3f", gs.deltaPercent)
+		if gs.significant {
+			deltaP = "**" + deltaP + "**"
+		}
+		pValue := fmt.Sprintf("%.3f", gs.pValue)
+		if gs.untestable {
+			pValue = "not testable"
+		}
+		fmt.Fprintf(target, "| %s | %.4f | %.4f - %.4f | %.4f | %.4f - %.4f | %s | %s |\n",
+			gs.name, gs.base[0], gs.base[1], gs.base[2], gs.actual[0], gs.actual[1], gs.actual[2], deltaP, pValue)
 	}
-	return nil
 }
 
-// plot creates a gnu plot file and then creates a png output file from it.
-func plot(metric, prefix, fileName string, others []labelValues, baseLabel string) error {
-	f, err := os.CreateTemp("", "tmp.plt")
-	if err != nil {
-		return err
+// displayCapacity prints the capacity curves summary of the given reports
+// in markdown to the given target. Nothing is printed if none of the reports
+// has a capacity curve.
+func displayCapacity(target io.Writer, reports ...Report) {
+	var found bool
+	for _, r := range reports {
+		if r.CapacityCurve != nil {
+			found = true
+			break
+		}
 	}
-	defer os.Remove(f.Name())
-
-	imageFile := prefix + strings.Replace(strings.ToLower(metric), " ", "-", -1)
-	fmt.Fprintln(f, "set grid")
-	fmt.Fprintln(f, "set terminal png")
-	fmt.Fprintf(f, "set output '%s.png'\n", imageFile)
-	fmt.Fprintf(f, "set title '%s'\n", metric)
-	fmt.Fprintln(f, "set xlabel 'time (normalized)'")
-
-	// Just some gnuplot jargon to specify plot characteristics and the columns to compare.
-	fmt.Fprintf(f, "plot '%s' u 1:2 w l lw 2 t '%s'", fileName, baseLabel)
-	for i := 0; i < len(others); i++ {
-		fmt.Fprintf(f, ", '%s' u 1:%d w l lw 2 t '%s'", fileName, i+3, others[i].label)
+	if !found {
+		return
 	}
 
-	err = f.Close()
-	if err != nil {
-		return err
+	fmt.Fprintln(target, "### Supported users:")
+	fmt.Fprintln(target, "| Report | Supported users | 95% CI |")
+	fmt.Fprintln(target, "| --- | --- | --- |")
+	for _, r := range reports {
+		if r.CapacityCurve == nil || r.CapacityCurve.SupportedUsers == nil {
+			continue
+		}
+		ci := r.CapacityCurve.SupportedUsers
+		fmt.Fprintf(target, "| %s | %.0f | %.1f - %.1f |\n", r.Label, ci.Mean, ci.Lower, ci.Upper)
 	}
 
-	cmd := exec.Command("gnuplot", f.Name())
-	_, err = cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("error while running gnuplot: %w", err)
+	fmt.Fprintln(target, "### Capacity knee points:")
+	fmt.Fprintln(target, "| Query | Report | Active users | Value |")
+	fmt.Fprintln(target, "| --- | --- | --- | --- |")
+	for _, r := range reports {
+		if r.CapacityCurve == nil {
+			continue
+		}
+		for _, k := range r.CapacityCurve.Knees {
+			fmt.Fprintf(target, "| %s | %s | %d | %.4f |\n", k.Query, r.Label, k.ActiveUsers, k.Value)
+		}
 	}
-	mlog.Info("Wrote " + imageFile + ".png")
-	return nil
+}
+
+// printHeader prints the header row of a markdown table.
+func printHeader(target io.Writer, cols int) {
+	fmt.Fprint(target, "| | | Base | ")
+	header := ""
+	for i := 0; i < cols; i++ {
+		if cols > 1 {
+			header += fmt.Sprintf("Actual %d | Delta %d | Delta %% %d |", i+1, i+1, i+1)
+			continue
+		}
+		header += "Actual | Delta | Delta % |"
+	}
+	fmt.Fprintln(target, header)
+
+	fmt.Fprint(target, "| --- | --- | --- | ")
+	header = ""
+	for i := 0; i < cols; i++ {
+		header += "--- | --- | --- |"
+	}
+	fmt.Fprintln(target, header)
 }
 
 func sortKeys(m map[model.LabelValue]avgp99, t sortByType, desc bool) []model.LabelValue {
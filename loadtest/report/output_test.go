@@ -4,10 +4,13 @@
 package report
 
 import (
+	"bytes"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/mattermost/mattermost-load-test-ng/coordinator/capacity"
+
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 )
@@ -429,3 +432,52 @@ func TestPrintSummary(t *testing.T) {
 		})
 	}
 }
+
+func TestDisplayCapacity(t *testing.T) {
+	var buf bytes.Buffer
+	displayCapacity(&buf, Report{Label: "base"}, Report{Label: "new"})
+	require.Empty(t, buf.String())
+
+	displayCapacity(&buf, Report{
+		Label: "base",
+		CapacityCurve: &capacity.Curve{
+			Knees: []capacity.KneePoint{
+				{Query: "p99", ActiveUsers: 400, Value: 0.12},
+			},
+			SupportedUsers: &capacity.ConfidenceInterval{
+				Level: 0.95,
+				Mean:  400,
+				Lower: 390.5,
+				Upper: 409.5,
+			},
+		},
+	}, Report{Label: "new"})
+	require.Contains(t, buf.String(), "| base | 400 | 390.5 - 409.5 |")
+	require.Contains(t, buf.String(), "| p99 | base | 400 | 0.1200 |")
+}
+
+func TestDisplayMarkdownMultipleReports(t *testing.T) {
+	base := Report{
+		Label:         "release",
+		AvgStoreTimes: map[model.LabelValue]model.SampleValue{"method1": 0.1},
+		P99StoreTimes: map[model.LabelValue]model.SampleValue{"method1": 0.2},
+	}
+	fixA := Report{
+		Label:         "fix-a",
+		AvgStoreTimes: map[model.LabelValue]model.SampleValue{"method1": 0.05},
+		P99StoreTimes: map[model.LabelValue]model.SampleValue{"method1": 0.1},
+	}
+	fixB := Report{
+		Label:         "fix-b",
+		AvgStoreTimes: map[model.LabelValue]model.SampleValue{"method1": 0.2},
+		P99StoreTimes: map[model.LabelValue]model.SampleValue{"method1": 0.4},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Compare(&buf, CompareOpts{}, base, fixA, fixB))
+	out := buf.String()
+
+	require.Contains(t, out, "### Reports:\n| Column | Report |\n| --- | --- |\n| Base | release |\n| 1 | fix-a |\n| 2 | fix-b |\n")
+	require.Contains(t, out, "| | | Base | Actual 1 | Delta 1 | Delta % 1 |Actual 2 | Delta 2 | Delta % 2 |\n")
+	require.Contains(t, out, "| method1 |  Avg| 100ms| 50ms | -50ms | -50.000| 200ms | 100ms | 100.000\n")
+}
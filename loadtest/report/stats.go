@@ -0,0 +1,146 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package report
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// The default significance level used when comparing multiple runs.
+	defaultAlpha = 0.05
+	// The maximum number of samples per side for which the exact
+	// distribution of the Mann-Whitney U statistic is computed.
+	maxExactSamples = 20
+)
+
+// mean returns the arithmetic mean of the given values.
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// mannWhitneyU performs a two-sided Mann-Whitney U test on the given
+// samples and returns its p-value. The exact distribution of the statistic
+// is used for small samples without ties; otherwise the normal
+// approximation with tie correction is used.
+func mannWhitneyU(xs, ys []float64) float64 {
+	n1, n2 := len(xs), len(ys)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type sample struct {
+		value float64
+		first bool
+	}
+	samples := make([]sample, 0, n1+n2)
+	for _, x := range xs {
+		samples = append(samples, sample{value: x, first: true})
+	}
+	for _, y := range ys {
+		samples = append(samples, sample{value: y})
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].value < samples[j].value
+	})
+
+	// Ranks are 1-based, tied values get the average of their ranks.
+	var r1, tieSum float64
+	var hasTies bool
+	for i := 0; i < len(samples); {
+		j := i + 1
+		for j < len(samples) && samples[j].value == samples[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if samples[k].first {
+				r1 += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			hasTies = true
+			tieSum += t*t*t - t
+		}
+		i = j
+	}
+
+	u1 := r1 - float64(n1*(n1+1))/2
+	u := math.Min(u1, float64(n1*n2)-u1)
+
+	if !hasTies && n1 <= maxExactSamples && n2 <= maxExactSamples {
+		return math.Min(1, 2*exactUCDF(n1, n2, int(u)))
+	}
+
+	n := float64(n1 + n2)
+	mu := float64(n1*n2) / 2
+	sigma := math.Sqrt(float64(n1*n2) / 12 * ((n + 1) - tieSum/(n*(n-1))))
+	if sigma == 0 {
+		return 1
+	}
+	// We apply a continuity correction since U is discrete.
+	z := (u - mu + 0.5) / sigma
+	return math.Min(1, math.Erfc(-z/math.Sqrt2))
+}
+
+// minPValue returns the smallest p-value the Mann-Whitney U test can yield for
+// samples of the given sizes, which is reached when they don't overlap.
+func minPValue(n1, n2 int) float64 {
+	xs := make([]float64, n1)
+	ys := make([]float64, n2)
+	for i := range xs {
+		xs[i] = float64(i)
+	}
+	for i := range ys {
+		ys[i] = float64(n1 + i)
+	}
+	return mannWhitneyU(xs, ys)
+}
+
+// exactUCDF returns the probability of the Mann-Whitney U statistic being
+// less than or equal to u for samples of size n1 and n2, under the null
+// hypothesis.
+func exactUCDF(n1, n2, u int) float64 {
+	// counts[i][j][k] holds the number of arrangements of i and j samples
+	// yielding a statistic of k, computed through the recurrence
+	// f(i, j, k) = f(i-1, j, k-j) + f(i, j-1, k).
+	maxU := n1 * n2
+	prev := make([][]float64, n2+1)
+	for j := range prev {
+		prev[j] = make([]float64, maxU+1)
+		prev[j][0] = 1
+	}
+	for i := 1; i <= n1; i++ {
+		cur := make([][]float64, n2+1)
+		cur[0] = make([]float64, maxU+1)
+		cur[0][0] = 1
+		for j := 1; j <= n2; j++ {
+			cur[j] = make([]float64, maxU+1)
+			for k := 0; k <= i*j; k++ {
+				if k >= j {
+					cur[j][k] += prev[j][k-j]
+				}
+				cur[j][k] += cur[j-1][k]
+			}
+		}
+		prev = cur
+	}
+
+	var total, cum float64
+	for k, c := range prev[n2] {
+		total += c
+		if k <= u {
+			cum += c
+		}
+	}
+	return cum / total
+}
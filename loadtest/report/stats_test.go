@@ -0,0 +1,49 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMannWhitneyU(t *testing.T) {
+	t.Run("exact", func(t *testing.T) {
+		// All base values are lower, which is the most extreme of the 70
+		// possible arrangements.
+		p := mannWhitneyU([]float64{1, 2, 3, 4}, []float64{5, 6, 7, 8})
+		require.InDelta(t, 2.0/70, p, 1e-9)
+
+		p = mannWhitneyU([]float64{5, 6, 7, 8}, []float64{1, 2, 3, 4})
+		require.InDelta(t, 2.0/70, p, 1e-9)
+
+		p = mannWhitneyU([]float64{1, 4, 5, 8}, []float64{2, 3, 6, 7})
+		require.Equal(t, 1.0, p)
+
+		// Three runs per side can never be significant at the 0.05 level.
+		p = mannWhitneyU([]float64{1, 2, 3}, []float64{4, 5, 6})
+		require.InDelta(t, 0.1, p, 1e-9)
+	})
+
+	t.Run("normal approximation", func(t *testing.T) {
+		var xs, ys []float64
+		for i := 0; i < 50; i++ {
+			xs = append(xs, float64(i%10))
+			ys = append(ys, float64(i%10)+5)
+		}
+		require.Less(t, mannWhitneyU(xs, ys), 0.001)
+		require.Greater(t, mannWhitneyU(xs, xs), 0.9)
+	})
+
+	t.Run("min p-value", func(t *testing.T) {
+		require.InDelta(t, 1.0/3, minPValue(2, 2), 1e-9)
+		require.InDelta(t, 0.1, minPValue(3, 3), 1e-9)
+		require.InDelta(t, 2.0/70, minPValue(4, 4), 1e-9)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		require.Equal(t, 1.0, mannWhitneyU(nil, []float64{1}))
+	})
+}
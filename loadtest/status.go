@@ -75,4 +75,17 @@ type Status struct {
 	NumUsersStopped int64     // Number of users that stopped running.
 	NumErrors       int64     // Number of errors that have occurred.
 	StartTime       time.Time // Time when the load test was started. This only logs the time when the load test was first started, and does not get reset if it was subsequently restarted.
+	// Status of each controller population, keyed by name. Only set when
+	// populations are configured.
+	Populations map[string]PopulationStatus `json:",omitempty"`
+}
+
+// PopulationStatus contains information about the users of a controller
+// population.
+type PopulationStatus struct {
+	NumUsers        int64 // Number of active users.
+	NumUsersAdded   int64 // Number of users added since the start of the test.
+	NumUsersRemoved int64 // Number of users removed since the start of the test.
+	NumUsersStopped int64 // Number of users that stopped running.
+	NumErrors       int64 // Number of errors that have occurred.
 }
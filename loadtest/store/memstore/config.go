@@ -15,6 +15,10 @@ type Config struct {
 	MaxStoredStatuses       int // The maximum number of statuses to be stored.
 	MaxStoredThreads        int // The maximum number of statuses to be stored.
 	MaxStoredReactions      int // The maximum number of reactions to be stored.
+	MaxStoredChannels       int // The maximum number of channels to be stored.
+	MaxStoredDrafts         int // The maximum number of drafts to be stored.
+	MaxStoredBookmarks      int // The maximum number of channel bookmarks to be stored.
+	MaxStoredScheduledPosts int // The maximum number of scheduled posts to be stored.
 }
 
 // IsValid checks whether a Config is valid or not.
@@ -40,6 +44,22 @@ func (c *Config) IsValid() error {
 		return errors.New("MaxStoredThreads should be > 0")
 	}
 
+	if c.MaxStoredChannels <= 0 {
+		return errors.New("MaxStoredChannels should be > 0")
+	}
+
+	if c.MaxStoredDrafts <= 0 {
+		return errors.New("MaxStoredDrafts should be > 0")
+	}
+
+	if c.MaxStoredBookmarks <= 0 {
+		return errors.New("MaxStoredBookmarks should be > 0")
+	}
+
+	if c.MaxStoredScheduledPosts <= 0 {
+		return errors.New("MaxStoredScheduledPosts should be > 0")
+	}
+
 	return nil
 }
 
@@ -51,4 +71,8 @@ func (c *Config) SetDefaults() {
 	c.MaxStoredStatuses = 100
 	c.MaxStoredThreads = 100
 	c.MaxStoredReactions = 10
+	c.MaxStoredChannels = 2000
+	c.MaxStoredDrafts = 100
+	c.MaxStoredBookmarks = 100
+	c.MaxStoredScheduledPosts = 100
 }
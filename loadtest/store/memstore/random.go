@@ -26,6 +26,7 @@ var (
 	ErrMaxAttempts             = errors.New("memstore: maximum number of attempts tried")
 	ErrDraftNotFound           = errors.New("memstore: draft not found")
 	ErrScheduledPostStoreEmpty = errors.New("memstore: scheduled post store is empty")
+	ErrWebhookStoreEmpty       = errors.New("memstore: webhook store is empty")
 )
 
 func isSelectionType(st, t store.SelectionType) bool {
@@ -422,6 +423,20 @@ func pickRandomKeyFromMap[K comparable, V any](m map[K]V) (K, error) {
 	return keys[idx], nil
 }
 
+// RandomIncomingWebhook returns a random incoming webhook created by the user.
+func (s *MemStore) RandomIncomingWebhook() (model.IncomingWebhook, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	id, err := pickRandomKeyFromMap(s.incomingWebhooks)
+	if errors.Is(err, ErrEmptyMap) {
+		return model.IncomingWebhook{}, ErrWebhookStoreEmpty
+	} else if err != nil {
+		return model.IncomingWebhook{}, err
+	}
+	return *s.incomingWebhooks[id], nil
+}
+
 // RandomThread returns a random post.
 func (s *MemStore) RandomThread() (store.ThreadResponseWrapped, error) {
 	s.lock.RLock()
@@ -487,7 +502,9 @@ func (s *MemStore) GetRandomScheduledPost() (*model.ScheduledPost, error) {
 	innerIndex := 0
 	for _, post := range selectedInnerMap {
 		if innerIndex == randomInnerIndex {
-			selectedPost = post[rand.Intn(len(post))]
+			// A copy is returned since the stored post may be reused.
+			selectedPost = &model.ScheduledPost{}
+			copyScheduledPost(post[rand.Intn(len(post))], selectedPost)
 			break
 		}
 		innerIndex++
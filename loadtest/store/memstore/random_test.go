@@ -1152,3 +1152,23 @@ func TestRandomThread(t *testing.T) {
 		require.Equal(t, ErrThreadNotFound, err)
 	})
 }
+
+func TestRandomIncomingWebhook(t *testing.T) {
+	t.Run("basic", func(t *testing.T) {
+		s := newStore(t)
+		id1 := model.NewId()
+		id2 := model.NewId()
+		require.Error(t, s.SetIncomingWebhook(nil))
+		require.NoError(t, s.SetIncomingWebhook(&model.IncomingWebhook{Id: id1}))
+		require.NoError(t, s.SetIncomingWebhook(&model.IncomingWebhook{Id: id2}))
+		hook, err := s.RandomIncomingWebhook()
+		require.NoError(t, err)
+		require.Contains(t, []string{id1, id2}, hook.Id)
+		require.Equal(t, 2, s.Sizes()["incoming_webhooks"])
+	})
+	t.Run("empty", func(t *testing.T) {
+		s := newStore(t)
+		_, err := s.RandomIncomingWebhook()
+		require.Equal(t, ErrWebhookStoreEmpty, err)
+	})
+}
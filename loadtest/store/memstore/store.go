@@ -31,6 +31,7 @@ type MemStore struct {
 	postsQueue            *CQueue[model.Post]
 	teams                 map[string]*model.Team
 	channels              map[string]*model.Channel
+	channelsQueue         *CQueue[model.Channel]
 	channelStats          map[string]*model.ChannelStats
 	channelMembers        map[string]map[string]*model.ChannelMember
 	channelMembersQueue   *CQueue[model.ChannelMember]
@@ -52,12 +53,30 @@ type MemStore struct {
 	threadsQueue          *CQueue[store.ThreadResponseWrapped]
 	sidebarCategories     map[string]map[string]*model.SidebarCategoryWithChannels
 	drafts                map[string]map[string]*model.Draft
+	draftsQueue           *CQueue[storedDraft]
 	featureFlags          map[string]bool
 	report                *model.PerformanceReport
 	channelBookmarks      map[string]*model.ChannelBookmarkWithFileInfo
+	bookmarksQueue        *CQueue[model.ChannelBookmarkWithFileInfo]
 	scheduledPosts        map[string]map[string][]*model.ScheduledPost // map of team ID -> channel/thread ID -> list of scheduled posts
+	scheduledPostsQueue   *CQueue[storedScheduledPost]
 	customAttributeFields []*model.PropertyField
 	customAttributeValues map[string]map[string]json.RawMessage
+	incomingWebhooks      map[string]*model.IncomingWebhook
+}
+
+// storedDraft is a draft along with the keys it's stored under.
+type storedDraft struct {
+	teamId string
+	id     string
+	draft  model.Draft
+}
+
+// storedScheduledPost is a scheduled post along with the team it's stored
+// under.
+type storedScheduledPost struct {
+	teamId string
+	post   model.ScheduledPost
 }
 
 // New returns a new instance of MemStore with the given config.
@@ -101,6 +120,7 @@ func (s *MemStore) Clear() {
 	s.teams = map[string]*model.Team{}
 	clear(s.channels)
 	s.channels = map[string]*model.Channel{}
+	s.channelsQueue.Reset()
 	channelStats := map[string]*model.ChannelStats{}
 	if s.currentChannel != nil && s.channelStats[s.currentChannel.Id] != nil {
 		channelStats[s.currentChannel.Id] = s.channelStats[s.currentChannel.Id]
@@ -134,12 +154,53 @@ func (s *MemStore) Clear() {
 	s.report = &model.PerformanceReport{}
 	clear(s.drafts)
 	s.drafts = map[string]map[string]*model.Draft{}
+	s.draftsQueue.Reset()
 	clear(s.channelBookmarks)
 	s.channelBookmarks = map[string]*model.ChannelBookmarkWithFileInfo{}
+	s.bookmarksQueue.Reset()
 	clear(s.scheduledPosts)
 	s.scheduledPosts = map[string]map[string][]*model.ScheduledPost{}
+	s.scheduledPostsQueue.Reset()
 	clear(s.customAttributeValues)
 	s.customAttributeValues = map[string]map[string]json.RawMessage{}
+	clear(s.incomingWebhooks)
+	s.incomingWebhooks = map[string]*model.IncomingWebhook{}
+}
+
+// Sizes returns the number of items held by the store, keyed by their type.
+func (s *MemStore) Sizes() map[string]int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	sizes := map[string]int{
+		"posts":             len(s.posts),
+		"users":             len(s.users),
+		"channels":          len(s.channels),
+		"channel_members":   0,
+		"statuses":          len(s.statuses),
+		"threads":           len(s.threads),
+		"reactions":         0,
+		"drafts":            0,
+		"channel_bookmarks": len(s.channelBookmarks),
+		"scheduled_posts":   0,
+		"incoming_webhooks": len(s.incomingWebhooks),
+	}
+	for _, members := range s.channelMembers {
+		sizes["channel_members"] += len(members)
+	}
+	for _, reactions := range s.reactions {
+		sizes["reactions"] += len(reactions)
+	}
+	for _, drafts := range s.drafts {
+		sizes["drafts"] += len(drafts)
+	}
+	for _, posts := range s.scheduledPosts {
+		for _, list := range posts {
+			sizes["scheduled_posts"] += len(list)
+		}
+	}
+
+	return sizes
 }
 
 func (s *MemStore) setupQueues(config *Config) error {
@@ -174,6 +235,26 @@ func (s *MemStore) setupQueues(config *Config) error {
 		return fmt.Errorf("memstore: reactions queue creation failed %w", err)
 	}
 
+	s.channelsQueue, err = NewCQueue[model.Channel](config.MaxStoredChannels)
+	if err != nil {
+		return fmt.Errorf("memstore: channels queue creation failed %w", err)
+	}
+
+	s.draftsQueue, err = NewCQueue[storedDraft](config.MaxStoredDrafts)
+	if err != nil {
+		return fmt.Errorf("memstore: drafts queue creation failed %w", err)
+	}
+
+	s.bookmarksQueue, err = NewCQueue[model.ChannelBookmarkWithFileInfo](config.MaxStoredBookmarks)
+	if err != nil {
+		return fmt.Errorf("memstore: bookmarks queue creation failed %w", err)
+	}
+
+	s.scheduledPostsQueue, err = NewCQueue[storedScheduledPost](config.MaxStoredScheduledPosts)
+	if err != nil {
+		return fmt.Errorf("memstore: scheduled posts queue creation failed %w", err)
+	}
+
 	return nil
 }
 
@@ -482,7 +563,23 @@ func (s *MemStore) SetChannel(channel *model.Channel) error {
 	if channel == nil {
 		return errors.New("memstore: channel should not be nil")
 	}
-	s.channels[channel.Id] = channel
+
+	// The channel is updated in place if already stored.
+	if c, ok := s.channels[channel.Id]; ok {
+		*c = *channel
+		return nil
+	}
+
+	// We get an element from the queue and check if we have it in the map and
+	// if it points to the same memory location. If so, we delete it since it means the queue is full.
+	// This is done to keep the data pointed by the map consistent with the data stored in the queue.
+	c := s.channelsQueue.Get()
+	if cc, ok := s.channels[c.Id]; ok && cc == c {
+		delete(s.channels, c.Id)
+	}
+	*c = *channel
+	s.channels[channel.Id] = c
+
 	return nil
 }
 
@@ -1278,11 +1375,7 @@ func (s *MemStore) SetDraft(teamId, id string, draft *model.Draft) error {
 		return errors.New("memstore: draft should not be nil")
 	}
 
-	if s.drafts[teamId] == nil {
-		s.drafts[teamId] = map[string]*model.Draft{}
-	}
-
-	s.drafts[teamId][id] = draft
+	s.setDraft(teamId, id, draft)
 	return nil
 }
 
@@ -1299,12 +1392,36 @@ func (s *MemStore) SetDrafts(teamId string, drafts []*model.Draft) error {
 		if rootID == "" {
 			rootID = d.ChannelId
 		}
-		s.drafts[teamId][rootID] = d
+		s.setDraft(teamId, rootID, d)
 	}
 
 	return nil
 }
 
+func (s *MemStore) setDraft(teamId, id string, draft *model.Draft) {
+	// The draft is updated in place if already stored.
+	if d, ok := s.drafts[teamId][id]; ok {
+		copyDraft(draft, d)
+		return
+	}
+
+	// We get an element from the queue and check if we have it in the map and
+	// if it points to the same memory location. If so, we delete it since it means the queue is full.
+	// This is done to keep the data pointed by the map consistent with the data stored in the queue.
+	d := s.draftsQueue.Get()
+	if dd, ok := s.drafts[d.teamId][d.id]; ok && dd == &d.draft {
+		delete(s.drafts[d.teamId], d.id)
+	}
+	d.teamId = teamId
+	d.id = id
+	copyDraft(draft, &d.draft)
+
+	if s.drafts[teamId] == nil {
+		s.drafts[teamId] = map[string]*model.Draft{}
+	}
+	s.drafts[teamId][id] = &d.draft
+}
+
 // ChannelBookmarks returns all bookmarks for the specified channel.
 func (s *MemStore) ChannelBookmarks(channelId string) []*model.ChannelBookmarkWithFileInfo {
 	s.lock.RLock()
@@ -1313,7 +1430,8 @@ func (s *MemStore) ChannelBookmarks(channelId string) []*model.ChannelBookmarkWi
 	var bookmarks []*model.ChannelBookmarkWithFileInfo
 	for _, b := range s.channelBookmarks {
 		if b.ChannelId == channelId {
-			bookmarks = append(bookmarks, b)
+			bookmarkCopy := *b
+			bookmarks = append(bookmarks, &bookmarkCopy)
 		}
 	}
 	return bookmarks
@@ -1328,7 +1446,7 @@ func (s *MemStore) SetChannelBookmarks(bookmarks []*model.ChannelBookmarkWithFil
 		if bookmark == nil {
 			return errors.New("memstore: bookmark should not be nil")
 		}
-		s.channelBookmarks[bookmark.Id] = bookmark
+		s.addChannelBookmark(bookmark)
 	}
 
 	return nil
@@ -1343,10 +1461,30 @@ func (s *MemStore) AddChannelBookmark(bookmark *model.ChannelBookmarkWithFileInf
 		return errors.New("memstore: bookmark should not be nil")
 	}
 
-	s.channelBookmarks[bookmark.Id] = bookmark
+	s.addChannelBookmark(bookmark)
 	return nil
 }
 
+func (s *MemStore) addChannelBookmark(bookmark *model.ChannelBookmarkWithFileInfo) {
+	// The bookmark is updated in place if already stored.
+	if b, ok := s.channelBookmarks[bookmark.Id]; ok {
+		*b = *bookmark
+		return
+	}
+
+	// We get an element from the queue and check if we have it in the map and
+	// if it points to the same memory location. If so, we delete it since it means the queue is full.
+	// This is done to keep the data pointed by the map consistent with the data stored in the queue.
+	b := s.bookmarksQueue.Get()
+	if b.ChannelBookmark != nil {
+		if bb, ok := s.channelBookmarks[b.Id]; ok && bb == b {
+			delete(s.channelBookmarks, b.Id)
+		}
+	}
+	*b = *bookmark
+	s.channelBookmarks[bookmark.Id] = b
+}
+
 // UpdateChannelBookmark updates a given bookmark.
 func (s *MemStore) UpdateChannelBookmark(bookmark *model.ChannelBookmarkWithFileInfo) error {
 	s.lock.Lock()
@@ -1356,11 +1494,12 @@ func (s *MemStore) UpdateChannelBookmark(bookmark *model.ChannelBookmarkWithFile
 		return errors.New("memstore: bookmark should not be nil")
 	}
 
-	if s.channelBookmarks[bookmark.Id] == nil {
+	b := s.channelBookmarks[bookmark.Id]
+	if b == nil {
 		return errors.New("memstore: bookmark not found")
 	}
 
-	s.channelBookmarks[bookmark.Id] = bookmark
+	*b = *bookmark
 
 	return nil
 }
@@ -1390,21 +1529,43 @@ func (s *MemStore) SetScheduledPost(teamId string, scheduledPost *model.Schedule
 		return errors.New("memstore: scheduled post should not be nil")
 	}
 
-	if s.scheduledPosts == nil {
-		s.scheduledPosts = map[string]map[string][]*model.ScheduledPost{}
+	s.addScheduledPost(teamId, scheduledPost)
+	return nil
+}
+
+func (s *MemStore) addScheduledPost(teamId string, scheduledPost *model.ScheduledPost) {
+	// We get an element from the queue and check if we still store it. If so,
+	// we remove it since it means the queue is full. Empty lists are removed
+	// as well, so that they can't be picked at random.
+	sp := s.scheduledPostsQueue.Get()
+	spKey := scheduledPostKey(&sp.post)
+	for i, p := range s.scheduledPosts[sp.teamId][spKey] {
+		if p == &sp.post {
+			s.scheduledPosts[sp.teamId][spKey] = append(s.scheduledPosts[sp.teamId][spKey][:i], s.scheduledPosts[sp.teamId][spKey][i+1:]...)
+			if len(s.scheduledPosts[sp.teamId][spKey]) == 0 {
+				delete(s.scheduledPosts[sp.teamId], spKey)
+			}
+			break
+		}
 	}
+	sp.teamId = teamId
+	copyScheduledPost(scheduledPost, &sp.post)
 
 	if s.scheduledPosts[teamId] == nil {
 		s.scheduledPosts[teamId] = map[string][]*model.ScheduledPost{}
 	}
 
-	channelOrThreadId := scheduledPost.ChannelId
+	channelOrThreadId := scheduledPostKey(scheduledPost)
+	s.scheduledPosts[teamId][channelOrThreadId] = append(s.scheduledPosts[teamId][channelOrThreadId], &sp.post)
+}
+
+// scheduledPostKey returns the id of the channel or thread the given scheduled
+// post is stored under.
+func scheduledPostKey(scheduledPost *model.ScheduledPost) string {
 	if scheduledPost.RootId != "" {
-		channelOrThreadId = scheduledPost.RootId
+		return scheduledPost.RootId
 	}
-
-	s.scheduledPosts[teamId][channelOrThreadId] = append(s.scheduledPosts[teamId][channelOrThreadId], scheduledPost)
-	return nil
+	return scheduledPost.ChannelId
 }
 
 func (s *MemStore) DeleteScheduledPost(scheduledPost *model.ScheduledPost) {
@@ -1412,10 +1573,7 @@ func (s *MemStore) DeleteScheduledPost(scheduledPost *model.ScheduledPost) {
 	defer s.lock.Unlock()
 
 	for teamId := range s.scheduledPosts {
-		channelOrThreadId := scheduledPost.ChannelId
-		if scheduledPost.RootId != "" {
-			channelOrThreadId = scheduledPost.RootId
-		}
+		channelOrThreadId := scheduledPostKey(scheduledPost)
 
 		// find index of scheduledPost in s.scheduledPosts[teamId][channelOrThreadId] and if found, delete it
 		for i, sp := range s.scheduledPosts[teamId][channelOrThreadId] {
@@ -1431,21 +1589,15 @@ func (s *MemStore) UpdateScheduledPost(teamId string, scheduledPost *model.Sched
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	channelOrThreadId := scheduledPost.ChannelId
-	if scheduledPost.RootId != "" {
-		channelOrThreadId = scheduledPost.RootId
-	}
-
 	if _, ok := s.scheduledPosts[teamId]; !ok {
-		s.scheduledPosts[teamId] = map[string][]*model.ScheduledPost{
-			channelOrThreadId: {scheduledPost},
-		}
+		s.addScheduledPost(teamId, scheduledPost)
 		return
 	}
 
-	for i := range s.scheduledPosts[teamId][channelOrThreadId] {
-		if s.scheduledPosts[teamId][channelOrThreadId][i].Id == scheduledPost.Id {
-			s.scheduledPosts[teamId][channelOrThreadId][i] = scheduledPost
+	channelOrThreadId := scheduledPostKey(scheduledPost)
+	for _, sp := range s.scheduledPosts[teamId][channelOrThreadId] {
+		if sp.Id == scheduledPost.Id {
+			copyScheduledPost(scheduledPost, sp)
 			break
 		}
 	}
@@ -1480,3 +1632,15 @@ func (s *MemStore) GetCPAValues(userID string) map[string]json.RawMessage {
 
 	return s.customAttributeValues[userID]
 }
+
+// SetIncomingWebhook stores the given incoming webhook.
+func (s *MemStore) SetIncomingWebhook(hook *model.IncomingWebhook) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if hook == nil {
+		return errors.New("memstore: incoming webhook should not be nil")
+	}
+	s.incomingWebhooks[hook.Id] = hook
+	return nil
+}
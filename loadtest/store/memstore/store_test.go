@@ -301,6 +301,125 @@ func TestStoreConsistency(t *testing.T) {
 		require.Len(t, s.posts, 0)
 		require.Len(t, s.postsQueue.data, config.MaxStoredPosts)
 	})
+
+	t.Run("Channels", func(t *testing.T) {
+		config.MaxStoredChannels = 3
+		s, err := New(config)
+		require.NoError(t, err)
+
+		for i := 0; i < config.MaxStoredChannels; i++ {
+			require.NoError(t, s.SetChannel(&model.Channel{Id: fmt.Sprintf("%d", i+1)}))
+		}
+		// Updating a channel doesn't take any room.
+		require.NoError(t, s.SetChannel(&model.Channel{Id: "1", DisplayName: "updated"}))
+		c, err := s.Channel("1")
+		require.NoError(t, err)
+		require.Equal(t, "updated", c.DisplayName)
+		require.Len(t, s.channels, config.MaxStoredChannels)
+
+		require.NoError(t, s.SetChannel(&model.Channel{Id: "4"}))
+		require.Len(t, s.channelsQueue.data, config.MaxStoredChannels)
+		require.Len(t, s.channels, config.MaxStoredChannels)
+		c, err = s.Channel("1")
+		require.NoError(t, err)
+		require.Nil(t, c, "the oldest channel should be evicted")
+		c, err = s.Channel("4")
+		require.NoError(t, err)
+		require.NotNil(t, c)
+	})
+
+	t.Run("Drafts", func(t *testing.T) {
+		config.MaxStoredDrafts = 3
+		s, err := New(config)
+		require.NoError(t, err)
+		s.SetUser(&model.User{Id: "user"})
+
+		for i := 0; i < config.MaxStoredDrafts; i++ {
+			id := fmt.Sprintf("%d", i+1)
+			require.NoError(t, s.SetDraft("team", id, &model.Draft{UserId: "user", ChannelId: id}))
+		}
+		// Updating a draft doesn't take any room.
+		require.NoError(t, s.SetDraft("team", "1", &model.Draft{UserId: "user", ChannelId: "1", Message: "updated"}))
+		require.Equal(t, "updated", s.drafts["team"]["1"].Message)
+		require.NoError(t, s.SetDrafts("otherteam", []*model.Draft{{UserId: "user", ChannelId: "4"}}))
+
+		require.Len(t, s.draftsQueue.data, config.MaxStoredDrafts)
+		require.Len(t, s.drafts["team"], config.MaxStoredDrafts-1)
+		require.NotContains(t, s.drafts["team"], "1")
+		require.Len(t, s.drafts["otherteam"], 1)
+		require.Equal(t, 3, s.Sizes()["drafts"])
+	})
+
+	t.Run("Bookmarks", func(t *testing.T) {
+		config.MaxStoredBookmarks = 3
+		s, err := New(config)
+		require.NoError(t, err)
+
+		newBookmark := func(id string) *model.ChannelBookmarkWithFileInfo {
+			return &model.ChannelBookmarkWithFileInfo{
+				ChannelBookmark: &model.ChannelBookmark{Id: id, ChannelId: "channel"},
+			}
+		}
+		for i := 0; i < config.MaxStoredBookmarks; i++ {
+			require.NoError(t, s.AddChannelBookmark(newBookmark(fmt.Sprintf("%d", i+1))))
+		}
+		// Updating a bookmark doesn't take any room.
+		require.NoError(t, s.UpdateChannelBookmark(newBookmark("1")))
+		require.NoError(t, s.SetChannelBookmarks([]*model.ChannelBookmarkWithFileInfo{newBookmark("1")}))
+		require.Len(t, s.ChannelBookmarks("channel"), config.MaxStoredBookmarks)
+
+		require.NoError(t, s.AddChannelBookmark(newBookmark("4")))
+		require.Len(t, s.bookmarksQueue.data, config.MaxStoredBookmarks)
+		require.Len(t, s.ChannelBookmarks("channel"), config.MaxStoredBookmarks)
+		require.NotContains(t, s.channelBookmarks, "1")
+	})
+
+	t.Run("ScheduledPosts", func(t *testing.T) {
+		config.MaxStoredScheduledPosts = 3
+		s, err := New(config)
+		require.NoError(t, err)
+
+		for i := 0; i < config.MaxStoredScheduledPosts; i++ {
+			sp := &model.ScheduledPost{Id: fmt.Sprintf("%d", i+1), Draft: model.Draft{ChannelId: fmt.Sprintf("channel%d", i+1)}}
+			require.NoError(t, s.SetScheduledPost("team", sp))
+		}
+		require.NoError(t, s.SetScheduledPost("team", &model.ScheduledPost{Id: "4", Draft: model.Draft{ChannelId: "channel2"}}))
+
+		require.Len(t, s.scheduledPostsQueue.data, config.MaxStoredScheduledPosts)
+		require.NotContains(t, s.scheduledPosts["team"], "channel1", "empty lists should be removed")
+		require.Len(t, s.scheduledPosts["team"]["channel2"], 2)
+		require.Equal(t, 3, s.Sizes()["scheduled_posts"])
+
+		// Changes to the returned post don't affect the stored one.
+		sp, err := s.GetRandomScheduledPost()
+		require.NoError(t, err)
+		sp.Message = "updated"
+		for _, posts := range s.scheduledPosts["team"] {
+			for _, p := range posts {
+				require.Empty(t, p.Message)
+			}
+		}
+	})
+}
+
+func TestSizes(t *testing.T) {
+	s := newStore(t)
+	require.NoError(t, s.SetPost(&model.Post{Id: model.NewId()}))
+	require.NoError(t, s.SetChannel(&model.Channel{Id: model.NewId()}))
+	require.NoError(t, s.SetChannel(&model.Channel{Id: model.NewId()}))
+	require.NoError(t, s.SetReaction(&model.Reaction{UserId: "user", PostId: "post", EmojiName: "smile"}))
+
+	sizes := s.Sizes()
+	require.Equal(t, 1, sizes["posts"])
+	require.Equal(t, 2, sizes["channels"])
+	require.Equal(t, 1, sizes["reactions"])
+	require.Equal(t, 0, sizes["users"])
+	require.Len(t, sizes, 11)
+
+	s.Clear()
+	for typ, size := range s.Sizes() {
+		require.Zero(t, size, typ)
+	}
 }
 
 func TestChannel(t *testing.T) {
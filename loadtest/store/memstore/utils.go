@@ -67,3 +67,30 @@ func SetRandomSeed() int64 {
 	rand.Seed(seed)
 	return seed
 }
+
+// copyDraft copies the given draft into dst, sharing its props, file ids and
+// metadata.
+func copyDraft(src, dst *model.Draft) {
+	dst.CreateAt = src.CreateAt
+	dst.UpdateAt = src.UpdateAt
+	dst.DeleteAt = src.DeleteAt
+	dst.UserId = src.UserId
+	dst.ChannelId = src.ChannelId
+	dst.RootId = src.RootId
+	dst.Message = src.Message
+	dst.Type = src.Type
+	dst.SetProps(src.GetProps())
+	dst.FileIds = src.FileIds
+	dst.Metadata = src.Metadata
+	dst.Priority = src.Priority
+}
+
+// copyScheduledPost copies the given scheduled post into dst, sharing the
+// props, file ids and metadata of its draft.
+func copyScheduledPost(src, dst *model.ScheduledPost) {
+	copyDraft(&src.Draft, &dst.Draft)
+	dst.Id = src.Id
+	dst.ScheduledAt = src.ScheduledAt
+	dst.ProcessedAt = src.ProcessedAt
+	dst.ErrorCode = src.ErrorCode
+}
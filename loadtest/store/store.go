@@ -179,6 +179,10 @@ type UserStore interface {
 	DeleteChannelBookmark(bookmarkId string) error
 
 	GetRandomScheduledPost() (*model.ScheduledPost, error)
+
+	// RandomIncomingWebhook returns a random incoming webhook created by the
+	// user.
+	RandomIncomingWebhook() (model.IncomingWebhook, error)
 	DeleteScheduledPost(scheduledPost *model.ScheduledPost)
 	UpdateScheduledPost(teamId string, scheduledPost *model.ScheduledPost)
 
@@ -224,6 +228,10 @@ type MutableUserStore interface {
 	// scheduled posts
 	SetScheduledPost(teamId string, scheduledPost *model.ScheduledPost) error
 
+	// webhooks
+	// SetIncomingWebhook stores the given incoming webhook.
+	SetIncomingWebhook(hook *model.IncomingWebhook) error
+
 	// posts
 	// SetPost stores the given post.
 	SetPost(post *model.Post) error
@@ -0,0 +1,95 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package loadtest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// TLSConfig returns the TLS config used to connect to the instance, or nil if
+// the defaults should be used.
+func (c *TransportConfiguration) TLSConfig() (*tls.Config, error) {
+	if c.CACertFile == "" && c.ClientCertFile == "" && !c.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CACertFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(c.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.New("failed to parse CA certificates: no valid PEM certificate found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// NewTransport returns a new HTTP transport with the given TLS config, as
+// returned by TLSConfig, allowing up to maxConns connections per host. Zero
+// means no limit. Connections are made from the given local IP address,
+// unless it is empty.
+func (c *TransportConfiguration) NewTransport(tlsConfig *tls.Config, sourceAddr string, maxConns int) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   time.Duration(c.DialTimeoutMs) * time.Millisecond,
+		KeepAlive: 30 * time.Second,
+	}
+	if sourceAddr != "" {
+		dialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(sourceAddr)}
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     c.EnableHTTP2,
+		MaxConnsPerHost:       maxConns,
+		MaxIdleConns:          maxConns,
+		MaxIdleConnsPerHost:   maxConns,
+		IdleConnTimeout:       time.Duration(c.IdleConnTimeoutMs) * time.Millisecond,
+		TLSHandshakeTimeout:   time.Duration(c.TLSHandshakeTimeoutMs) * time.Millisecond,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if !c.EnableHTTP2 {
+		// A non-nil, empty map disables HTTP/2 altogether.
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return transport
+}
+
+// RequestTimeout returns the maximum amount of time for a request to
+// complete.
+func (c *TransportConfiguration) RequestTimeout() time.Duration {
+	return time.Duration(c.RequestTimeoutMs) * time.Millisecond
+}
+
+// MaxRateLimitBackoff returns the maximum amount of time users back off for
+// when rate limited.
+func (c *TransportConfiguration) MaxRateLimitBackoff() time.Duration {
+	return time.Duration(c.MaxRateLimitBackoffMs) * time.Millisecond
+}
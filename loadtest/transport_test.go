@@ -0,0 +1,128 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package loadtest
+
+import (
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattermost/mattermost-load-test-ng/defaults"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportConfiguration(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, caData, 0600))
+
+	newConfig := func(t *testing.T) TransportConfiguration {
+		t.Helper()
+		var cfg TransportConfiguration
+		require.NoError(t, defaults.Set(&cfg))
+		return cfg
+	}
+
+	get := func(cfg TransportConfiguration) error {
+		tlsConfig, err := cfg.TLSConfig()
+		require.NoError(t, err)
+		client := &http.Client{
+			Transport: cfg.NewTransport(tlsConfig, "", 1),
+			Timeout:   cfg.RequestTimeout(),
+		}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg := newConfig(t)
+		require.NoError(t, defaults.Validate(&cfg))
+
+		tlsConfig, err := cfg.TLSConfig()
+		require.NoError(t, err)
+		require.Nil(t, tlsConfig)
+
+		transport := cfg.NewTransport(tlsConfig, "", 10)
+		require.Equal(t, 10, transport.MaxConnsPerHost)
+		require.NotNil(t, transport.TLSNextProto)
+		require.Empty(t, transport.TLSNextProto)
+
+		// The certificate of the test server is not trusted.
+		require.Error(t, get(cfg))
+	})
+
+	t.Run("custom CA", func(t *testing.T) {
+		cfg := newConfig(t)
+		cfg.CACertFile = caFile
+		require.NoError(t, get(cfg))
+	})
+
+	t.Run("invalid CA", func(t *testing.T) {
+		cfg := newConfig(t)
+		cfg.CACertFile = filepath.Join(t.TempDir(), "invalid.pem")
+		require.NoError(t, os.WriteFile(cfg.CACertFile, []byte("invalid"), 0600))
+		_, err := cfg.TLSConfig()
+		require.Error(t, err)
+	})
+
+	t.Run("insecure skip verify", func(t *testing.T) {
+		cfg := newConfig(t)
+		cfg.InsecureSkipVerify = true
+		require.NoError(t, get(cfg))
+	})
+
+	t.Run("HTTP/2", func(t *testing.T) {
+		cfg := newConfig(t)
+		cfg.EnableHTTP2 = true
+		transport := cfg.NewTransport(nil, "", 0)
+		require.True(t, transport.ForceAttemptHTTP2)
+		require.Nil(t, transport.TLSNextProto)
+	})
+
+	t.Run("source address", func(t *testing.T) {
+		remoteAddrs := make(chan string, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remoteAddrs <- r.RemoteAddr
+		}))
+		defer server.Close()
+
+		cfg := newConfig(t)
+		client := &http.Client{Transport: cfg.NewTransport(nil, "127.0.0.1", 0)}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		host, _, err := net.SplitHostPort(<-remoteAddrs)
+		require.NoError(t, err)
+		require.Equal(t, "127.0.0.1", host)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		cfg := newConfig(t)
+		cfg.ConnectionPool = "invalid"
+		require.Error(t, defaults.Validate(&cfg))
+
+		cfg = newConfig(t)
+		cfg.ClientCertFile = caFile
+		require.EqualError(t, defaults.Validate(&cfg), "ClientCertFile and ClientKeyFile should be set together")
+
+		cfg = newConfig(t)
+		cfg.CACertFile = filepath.Join(t.TempDir(), "missing.pem")
+		require.Error(t, defaults.Validate(&cfg))
+	})
+}
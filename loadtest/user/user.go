@@ -333,6 +333,18 @@ type User interface {
 	// AckToPost acknowledges a post.
 	AckToPost(userID, postID string) error
 
+	// ExecuteCommand executes the given slash command in the given channel.
+	ExecuteCommand(channelID, command string) error
+
+	// Webhooks
+	// CreateIncomingWebhook creates and stores a new incoming webhook.
+	CreateIncomingWebhook(hook *model.IncomingWebhook) (string, error)
+	// PostToIncomingWebhook posts the given request to the incoming webhook
+	// with the given id, as an integration would.
+	PostToIncomingWebhook(hookID string, request *model.IncomingWebhookRequest) error
+	// CreateOutgoingWebhook creates a new outgoing webhook.
+	CreateOutgoingWebhook(hook *model.OutgoingWebhook) (string, error)
+
 	// GraphQL
 	GetInitialDataGQL() error
 	GetChannelsAndChannelMembersGQL(teamID string, includeDeleted bool, channelsCursor, channelMembersCursor string) (string, string, error)
@@ -53,6 +53,15 @@ func (ue *UserEntity) SignUp(email, username, password string) error {
 			return fmt.Errorf("error while getting user by email: %w", err)
 		}
 
+	case AuthenticationTypeToken:
+		// Users authenticating with a token can't be created, so we only
+		// fetch the existing one.
+		ue.client.SetToken(ue.config.Token)
+		newUser, _, err = ue.client.GetMe(context.Background(), "")
+		if err != nil {
+			return fmt.Errorf("error while getting user with token: %w", err)
+		}
+
 	default:
 		user := model.User{
 			Email:    email,
@@ -199,6 +208,12 @@ func (ue *UserEntity) Login() error {
 		if err != nil {
 			return fmt.Errorf("error while getting user by email through %s: %w", ue.config.AuthenticationType, err)
 		}
+	case AuthenticationTypeToken:
+		ue.client.SetToken(ue.config.Token)
+		loggedUser, _, err = ue.client.GetMe(context.Background(), "")
+		if err != nil {
+			return fmt.Errorf("error while logging in with token: %w", err)
+		}
 	default:
 		loggedUser, _, err = ue.client.Login(context.Background(), user.Email, user.Password)
 		if err != nil {
@@ -1551,6 +1566,60 @@ func (ue *UserEntity) AckToPost(userID, postID string) error {
 	return err
 }
 
+// ExecuteCommand executes the given slash command in the given channel.
+func (ue *UserEntity) ExecuteCommand(channelID, command string) error {
+	_, _, err := ue.client.ExecuteCommand(context.Background(), channelID, command)
+	return err
+}
+
+// CreateIncomingWebhook creates and stores a new incoming webhook.
+func (ue *UserEntity) CreateIncomingWebhook(hook *model.IncomingWebhook) (string, error) {
+	hook, _, err := ue.client.CreateIncomingWebhook(context.Background(), hook)
+	if err != nil {
+		return "", err
+	}
+
+	return hook.Id, ue.store.SetIncomingWebhook(hook)
+}
+
+// PostToIncomingWebhook posts the given request to the incoming webhook with
+// the given id, as an integration would. The request is sent through the same
+// HTTP client as the other requests of the user, though unauthenticated.
+func (ue *UserEntity) PostToIncomingWebhook(hookID string, request *model.IncomingWebhookRequest) error {
+	buf, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, ue.client.URL+"/hooks/"+hookID, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ue.client.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("incoming webhook returned status %d: %w", resp.StatusCode, model.AppErrorFromJSON(resp.Body))
+	}
+
+	return nil
+}
+
+// CreateOutgoingWebhook creates a new outgoing webhook.
+func (ue *UserEntity) CreateOutgoingWebhook(hook *model.OutgoingWebhook) (string, error) {
+	hook, _, err := ue.client.CreateOutgoingWebhook(context.Background(), hook)
+	if err != nil {
+		return "", err
+	}
+
+	return hook.Id, nil
+}
+
 // GetInitialDataGQL is a method to get the initial use data via GraphQL.
 func (ue *UserEntity) GetInitialDataGQL() error {
 	var q struct {
@@ -25,6 +25,25 @@ type config struct {
 		WebSocketURL  string `default:"ws://localhost:8065" validate:"url"`
 		AdminEmail    string `default:"sysadmin@sample.mattermost.com" validate:"email"`
 		AdminPassword string `default:"Sys@dmin-sample1" validate:"notempty"`
+		Transport     struct {
+			DialTimeoutMs         int
+			TLSHandshakeTimeoutMs int
+			RequestTimeoutMs      int
+			IdleConnTimeoutMs     int
+			EnableHTTP2           bool
+			CACertFile            string
+			ClientCertFile        string
+			ClientKeyFile         string
+			InsecureSkipVerify    bool
+			ConnectionPool        string
+			HonorRateLimits       bool
+			MaxRateLimitBackoffMs int
+		}
+		SourceAddresses []string
+		Targets         []struct {
+			ServerURL    string
+			WebSocketURL string
+		}
 	}
 	UserControllerConfiguration struct {
 		Type              userControllerType  `default:"simulative" validate:"oneof:{simple,simulative,noop,cluster,generative}"`
@@ -46,12 +65,36 @@ type config struct {
 		PercentGroupChannels        float64 `default:"0.1" validate:"range:[0,1]"`
 	}
 	UsersConfiguration struct {
-		UsersFilePath          string
+		UsersFilePath   string
+		UsersFileFormat string
+		PasswordPolicy  struct {
+			FixedPassword    string
+			Length           int
+			RequireUppercase bool
+			RequireNumber    bool
+			RequireSymbol    bool
+		}
 		InitialActiveUsers     int     `default:"0" validate:"range:[0,$MaxActiveUsers]"`
 		MaxActiveUsers         int     `default:"2000" validate:"range:(0,]"`
 		MaxActiveBrowserUsers  int     `default:"0" validate:"range:[0,]"`
 		AvgSessionsPerUser     int     `default:"1" validate:"range:[1,]"`
 		PercentOfUsersAreAdmin float64 `default:"0.0005" validate:"range:[0,1]"`
+		StoreLimits            struct {
+			MaxStoredPosts          int
+			MaxStoredUsers          int
+			MaxStoredChannelMembers int
+			MaxStoredStatuses       int
+			MaxStoredThreads        int
+			MaxStoredReactions      int
+			MaxStoredChannels       int
+			MaxStoredDrafts         int
+			MaxStoredBookmarks      int
+			MaxStoredScheduledPosts int
+		}
+	}
+	WebhooksConfiguration struct {
+		ReceiverListenAddress string
+		ReceiverURL           string
 	}
 	LogSettings logger.Settings
 }
@@ -94,6 +137,7 @@ func (th *TestHelper) CreateUser() *UserEntity {
 		"testuser",
 		"testuser@example.com",
 		"testpassword",
+		"",
 	})
 	require.NotNil(th.tb, u)
 	return u
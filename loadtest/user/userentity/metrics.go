@@ -6,9 +6,29 @@ package userentity
 import (
 	"strconv"
 
+	"github.com/mattermost/mattermost-load-test-ng/performance"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+func (ue *UserEntity) trackStore() {
+	if ue.metrics == nil || ue.metrics.StoreItems == nil {
+		return
+	}
+	if s, ok := ue.store.(performance.StoreSizer); ok {
+		ue.metrics.StoreItems.Track(s)
+	}
+}
+
+func (ue *UserEntity) untrackStore() {
+	if ue.metrics == nil || ue.metrics.StoreItems == nil {
+		return
+	}
+	if s, ok := ue.store.(performance.StoreSizer); ok {
+		ue.metrics.StoreItems.Untrack(s)
+	}
+}
+
 func (ue *UserEntity) incWebSocketConnections() {
 	if ue.metrics != nil {
 		ue.metrics.WebSocketConnections.Inc()
@@ -31,12 +51,27 @@ func (ue *UserEntity) incHTTPErrors(path, method string, status int) {
 	}
 }
 
+func (ue *UserEntity) incHTTPThrottled(path, method string) {
+	if ue.metrics != nil {
+		ue.metrics.HTTPThrottled.With(prometheus.Labels{
+			"path":   path,
+			"method": method,
+		}).Inc()
+	}
+}
+
 func (ue *UserEntity) observeHTTPRequestTimes(elapsed float64) {
 	if ue.metrics != nil {
 		ue.metrics.HTTPRequestTimes.Observe(elapsed)
 	}
 }
 
+func (ue *UserEntity) observeWebSocketEventDeliveryTimes(elapsed float64) {
+	if ue.metrics != nil {
+		ue.metrics.WebSocketEventDeliveryTimes.Observe(elapsed)
+	}
+}
+
 func (ue *UserEntity) incHTTPTimeouts(path, method string) {
 	if ue.metrics != nil {
 		ue.metrics.HTTPTimeouts.With(prometheus.Labels{
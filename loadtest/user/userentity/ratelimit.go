@@ -0,0 +1,100 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package userentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when a request is not sent because the user is
+// backing off after being rate limited by the server.
+var ErrRateLimited = errors.New("userentity: rate limited by the server")
+
+// defaultRateLimitBackoff is the amount of time to back off for when the
+// server rate limits a request without telling for how long.
+const defaultRateLimitBackoff = time.Second
+
+// rateLimitBackoff holds the state of a user backing off after being rate
+// limited by the server.
+type rateLimitBackoff struct {
+	mut   sync.Mutex
+	until time.Time
+	max   time.Duration
+}
+
+// wait blocks until the backoff period, if any, is over. If the context would
+// expire before that, it returns ErrRateLimited straight away rather than
+// waiting in vain.
+func (b *rateLimitBackoff) wait(ctx context.Context) error {
+	b.mut.Lock()
+	until := b.until
+	b.mut.Unlock()
+
+	d := time.Until(until)
+	if d <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(until) {
+		return fmt.Errorf("%w: backing off for %s", ErrRateLimited, d.Round(time.Millisecond))
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// update extends the backoff period according to the given response.
+func (b *rateLimitBackoff) update(resp *http.Response) {
+	now := time.Now()
+	d, ok := rateLimitDelay(resp.Header, now)
+	if !ok {
+		if resp.StatusCode != http.StatusTooManyRequests {
+			return
+		}
+		d = defaultRateLimitBackoff
+	}
+	if b.max > 0 && d > b.max {
+		d = b.max
+	}
+
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	if until := now.Add(d); until.After(b.until) {
+		b.until = until
+	}
+}
+
+// rateLimitDelay returns the amount of time to wait for before sending more
+// requests, as instructed by the given response headers. The Retry-After
+// header is honored first. The X-Ratelimit-* headers are used otherwise, once
+// there are no remaining requests left.
+func rateLimitDelay(header http.Header, now time.Time) (time.Duration, bool) {
+	if v := header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(t.Sub(now), 0), true
+		}
+	}
+
+	if header.Get("X-Ratelimit-Remaining") == "0" {
+		if secs, err := strconv.Atoi(header.Get("X-Ratelimit-Reset")); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+	}
+
+	return 0, false
+}
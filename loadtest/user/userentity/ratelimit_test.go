@@ -0,0 +1,142 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package userentity
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-load-test-ng/loadtest/store/memstore"
+	"github.com/mattermost/mattermost-load-test-ng/performance"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitDelay(t *testing.T) {
+	now := time.Now()
+
+	for _, tc := range []struct {
+		name   string
+		header http.Header
+		delay  time.Duration
+		ok     bool
+	}{
+		{
+			name:   "no headers",
+			header: http.Header{},
+		},
+		{
+			name:   "retry after seconds",
+			header: http.Header{"Retry-After": {"3"}},
+			delay:  3 * time.Second,
+			ok:     true,
+		},
+		{
+			name:   "retry after date",
+			header: http.Header{"Retry-After": {now.Add(10 * time.Second).UTC().Format(http.TimeFormat)}},
+			delay:  10 * time.Second,
+			ok:     true,
+		},
+		{
+			name:   "remaining requests",
+			header: http.Header{"X-Ratelimit-Remaining": {"5"}, "X-Ratelimit-Reset": {"2"}},
+		},
+		{
+			name:   "no remaining requests",
+			header: http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"2"}},
+			delay:  2 * time.Second,
+			ok:     true,
+		},
+		{
+			name:   "retry after first",
+			header: http.Header{"Retry-After": {"1"}, "X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"2"}},
+			delay:  time.Second,
+			ok:     true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			delay, ok := rateLimitDelay(tc.header, now)
+			require.Equal(t, tc.ok, ok)
+			require.InDelta(t, tc.delay, delay, float64(time.Second))
+		})
+	}
+}
+
+func TestHonorRateLimits(t *testing.T) {
+	var numRequests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if numRequests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "10")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	newUser := func(t *testing.T, honor bool) (*UserEntity, *performance.UserEntityMetrics) {
+		t.Helper()
+		numRequests.Store(0)
+		store, err := memstore.New(nil)
+		require.NoError(t, err)
+		metrics := performance.NewMetrics().UserEntityMetrics()
+		ue := New(Setup{
+			Store:               store,
+			Metrics:             metrics,
+			HonorRateLimits:     honor,
+			MaxRateLimitBackoff: 200 * time.Millisecond,
+		}, Config{ServerURL: server.URL})
+		return ue, metrics
+	}
+
+	get := func(ue *UserEntity) error {
+		resp, err := ue.client.HTTPClient.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		ue, metrics := newUser(t, false)
+		require.NoError(t, get(ue))
+		start := time.Now()
+		require.NoError(t, get(ue))
+		require.Less(t, time.Since(start), 200*time.Millisecond)
+
+		require.Equal(t, float64(1), testutil.ToFloat64(metrics.HTTPThrottled))
+		// Rate limited requests are still counted as errors.
+		require.Equal(t, float64(1), testutil.ToFloat64(metrics.HTTPErrors.WithLabelValues("", http.MethodGet, "429")))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		ue, metrics := newUser(t, true)
+		require.NoError(t, get(ue))
+
+		// The backoff is capped by MaxRateLimitBackoff.
+		start := time.Now()
+		require.NoError(t, get(ue))
+		require.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+		require.Less(t, time.Since(start), 5*time.Second)
+		require.Equal(t, float64(1), testutil.ToFloat64(metrics.HTTPThrottled))
+	})
+
+	t.Run("deadline before the end of the backoff", func(t *testing.T) {
+		ue, _ := newUser(t, true)
+		require.NoError(t, get(ue))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		_, err = ue.client.HTTPClient.Do(req)
+		require.ErrorIs(t, err, ErrRateLimited)
+		require.Equal(t, int64(1), numRequests.Load())
+	})
+}
@@ -4,6 +4,7 @@
 package userentity
 
 import (
+	"crypto/tls"
 	"errors"
 	"net/http"
 	"os"
@@ -30,6 +31,9 @@ type UserEntity struct {
 	connected   bool
 	config      Config
 	metrics     *performance.UserEntityMetrics
+	tlsConfig   *tls.Config
+	sourceAddr  string
+	rateLimit   *rateLimitBackoff
 	wsConnID    string
 	wsServerSeq int64
 }
@@ -38,6 +42,9 @@ const (
 	AuthenticationTypeMattermost = "mattermost"
 	AuthenticationTypeOpenID     = "openid"
 	AuthenticationTypeSAML       = "saml"
+	// Users authenticating with a personal access token, skipping the login
+	// with a password altogether. They must already exist.
+	AuthenticationTypeToken = "token"
 )
 
 var stripIDsRE = regexp.MustCompile(`\b\w{26}\b`)
@@ -56,6 +63,9 @@ type Config struct {
 	Email string
 	// The password to be used by the entity.
 	Password string
+	// The personal access token to be used by the entity, when authenticating
+	// with a token.
+	Token string
 }
 
 // Setup contains data used to create a new instance of UserEntity.
@@ -68,6 +78,18 @@ type Setup struct {
 	Metrics *performance.UserEntityMetrics
 	// The HTTP client timeout to use.
 	ClientTimeout time.Duration
+	// An optional TLS config used by WebSocket connections. The one of
+	// HTTP connections is set through the transport.
+	TLSConfig *tls.Config
+	// An optional local IP address WebSocket connections are made from. The
+	// one of HTTP connections is set through the transport.
+	SourceAddress string
+	// Whether to back off when rate limited by the server, as instructed by
+	// the Retry-After and X-Ratelimit-* response headers.
+	HonorRateLimits bool
+	// The maximum amount of time to back off for when rate limited. Zero
+	// means no limit.
+	MaxRateLimitBackoff time.Duration
 }
 
 type userTypingMsg struct {
@@ -101,8 +123,15 @@ type ueTransport struct {
 }
 
 // RoundTrip implements the RoundTripper interface for ueTransport.
-// This is used to collect metrics regarding the timing of HTTP calls.
+// This is used to collect metrics regarding the timing of HTTP calls, and to
+// back off when rate limited by the server.
 func (t *ueTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.ue.rateLimit != nil {
+		if err := t.ue.rateLimit.wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
 	startTime := time.Now()
 	resp, err := t.transport.RoundTrip(req)
 	t.ue.observeHTTPRequestTimes(time.Since(startTime).Seconds())
@@ -112,6 +141,14 @@ func (t *ueTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	if resp != nil && resp.StatusCode >= 400 {
 		t.ue.incHTTPErrors(stripIDs(req.URL.Path), req.Method, resp.StatusCode)
 	}
+	// Rate limited requests are also counted on their own, on top of the
+	// errors.
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		t.ue.incHTTPThrottled(stripIDs(req.URL.Path), req.Method)
+	}
+	if resp != nil && t.ue.rateLimit != nil {
+		t.ue.rateLimit.update(resp)
+	}
 	return resp, err
 }
 
@@ -130,12 +167,17 @@ func New(setup Setup, config Config) *UserEntity {
 	ue.config = config
 	ue.store = setup.Store
 	ue.metrics = setup.Metrics
+	ue.tlsConfig = setup.TLSConfig
+	ue.sourceAddr = setup.SourceAddress
 	ue.client = model.NewAPIv4Client(config.ServerURL)
 
 	if setup.Transport == nil {
 		setup.Transport = http.DefaultTransport
 	}
-	if setup.Metrics != nil {
+	if setup.HonorRateLimits {
+		ue.rateLimit = &rateLimitBackoff{max: setup.MaxRateLimitBackoff}
+	}
+	if setup.Metrics != nil || setup.HonorRateLimits {
 		setup.Transport = &ueTransport{
 			transport: setup.Transport,
 			ue:        &ue,
@@ -187,6 +229,7 @@ func (ue *UserEntity) Connect() (<-chan error, error) {
 	ue.dataChan = make(chan any, 10)
 	go ue.listen(ue.wsErrorChan)
 	ue.connected = true
+	ue.trackStore()
 	return ue.wsErrorChan, nil
 }
 
@@ -222,6 +265,7 @@ func (ue *UserEntity) Disconnect() error {
 	close(ue.dataChan)
 	close(ue.wsErrorChan)
 	ue.connected = false
+	ue.untrackStore()
 	return nil
 }
 
@@ -4,6 +4,7 @@
 package userentity
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -96,3 +97,37 @@ func TestIsSysAdmin(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, ok)
 }
+
+func TestPostToIncomingWebhook(t *testing.T) {
+	var cfg config
+	err := defaults.ReadFrom("", "../../../config/config.sample.json", &cfg)
+	require.Nil(t, err)
+
+	hookID := model.NewId()
+	var received model.IncomingWebhookRequest
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hooks/"+hookID, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Empty(t, r.Header.Get(model.HeaderAuth))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	})
+	mux.HandleFunc("/hooks/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"id": "web.incoming_webhook.invalid.app_error", "status_code": 400}`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cfg.ConnectionConfiguration.ServerURL = ts.URL
+	th := HelperSetup(t).SetConfig(cfg).Init()
+
+	err = th.User.PostToIncomingWebhook(hookID, &model.IncomingWebhookRequest{Text: "hello"})
+	require.NoError(t, err)
+	require.Equal(t, "hello", received.Text)
+
+	err = th.User.PostToIncomingWebhook(model.NewId(), &model.IncomingWebhookRequest{Text: "hello"})
+	require.ErrorContains(t, err, "status 400")
+	var appErr *model.AppError
+	require.ErrorAs(t, err, &appErr)
+	require.Equal(t, "web.incoming_webhook.invalid.app_error", appErr.Id)
+}
@@ -80,6 +80,13 @@ func (ue *UserEntity) handlePostEvent(ev *model.WebSocketEvent) error {
 		return err
 	}
 
+	if ev.EventType() == model.WebsocketEventPosted && post.CreateAt > 0 {
+		// This relies on the agent's clock being reasonably in sync with the server's.
+		if elapsed := time.Since(time.UnixMilli(post.CreateAt)); elapsed >= 0 {
+			ue.observeWebSocketEventDeliveryTimes(elapsed.Seconds())
+		}
+	}
+
 	switch ev.EventType() {
 	case model.WebsocketEventPosted, model.WebsocketEventPostEdited:
 		currentChannel, err := ue.store.CurrentChannel()
@@ -146,6 +153,8 @@ start:
 			AuthToken:      ue.client.AuthToken,
 			ConnID:         ue.wsConnID,
 			ServerSequence: ue.wsServerSeq,
+			TLSConfig:      ue.tlsConfig,
+			SourceAddress:  ue.sourceAddr,
 		})
 		if err != nil {
 			errChan <- fmt.Errorf("userentity: websocketClient creation error: %w", err)
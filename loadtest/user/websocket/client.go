@@ -7,7 +7,9 @@ package websocket
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"sync"
 
@@ -36,6 +38,10 @@ type ClientParams struct {
 	AuthToken      string
 	ConnID         string
 	ServerSequence int64
+	// An optional TLS config to use instead of the default one.
+	TLSConfig *tls.Config
+	// An optional local IP address to connect from.
+	SourceAddress string
 }
 
 // NewClient4 constructs a new WebSocket client.
@@ -45,7 +51,14 @@ func NewClient4(param *ClientParams) (*Client, error) {
 	}
 
 	url := param.WsURL + model.APIURLSuffix + "/websocket" + fmt.Sprintf("?connection_id=%s&sequence_number=%d", param.ConnID, param.ServerSequence)
-	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = param.TLSConfig
+	if param.SourceAddress != "" {
+		dialer.NetDialContext = (&net.Dialer{
+			LocalAddr: &net.TCPAddr{IP: net.ParseIP(param.SourceAddress)},
+		}).DialContext
+	}
+	conn, _, err := dialer.Dial(url, header)
 	if err != nil {
 		return nil, err
 	}
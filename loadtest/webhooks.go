@@ -0,0 +1,143 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/mattermost-load-test-ng/performance"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/shared/mlog"
+)
+
+// WebhookReceiver is an HTTP server receiving the outgoing webhooks delivered
+// by the server to the callback URLs registered by the users. It keeps count
+// of the deliveries and measures the time elapsed since the creation of the
+// posts triggering them.
+type WebhookReceiver struct {
+	listenAddr string
+	metrics    *performance.WebhookMetrics
+	deliveries int64
+
+	mut      sync.Mutex
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewWebhookReceiver creates a new WebhookReceiver listening on the given
+// address once started. Deliveries are recorded into the given metrics, if
+// any.
+func NewWebhookReceiver(listenAddr string, metrics *performance.WebhookMetrics) (*WebhookReceiver, error) {
+	if listenAddr == "" {
+		return nil, errors.New("listen address should not be empty")
+	}
+
+	return &WebhookReceiver{
+		listenAddr: listenAddr,
+		metrics:    metrics,
+	}, nil
+}
+
+// Start starts listening for outgoing webhooks.
+func (r *WebhookReceiver) Start() error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.server != nil {
+		return errors.New("webhook receiver already started")
+	}
+
+	listener, err := net.Listen("tcp", r.listenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", r.listenAddr, err)
+	}
+
+	r.listener = listener
+	r.server = &http.Server{
+		Handler:     r,
+		ReadTimeout: 10 * time.Second,
+	}
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			mlog.Error("webhook receiver failed", mlog.Err(err))
+		}
+	}(r.server)
+
+	return nil
+}
+
+// Stop stops listening for outgoing webhooks, waiting for the deliveries in
+// progress to be handled.
+func (r *WebhookReceiver) Stop() error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.server == nil {
+		return errors.New("webhook receiver not started")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := r.server.Shutdown(ctx)
+	r.server = nil
+	r.listener = nil
+	if err != nil {
+		return fmt.Errorf("failed to stop webhook receiver: %w", err)
+	}
+
+	return nil
+}
+
+// Addr returns the address the receiver is listening on, or nil if it's not
+// started.
+func (r *WebhookReceiver) Addr() net.Addr {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.listener == nil {
+		return nil
+	}
+	return r.listener.Addr()
+}
+
+// Deliveries returns the number of outgoing webhooks received so far.
+func (r *WebhookReceiver) Deliveries() int64 {
+	return atomic.LoadInt64(&r.deliveries)
+}
+
+// ServeHTTP implements the http.Handler interface. Outgoing webhooks are
+// acknowledged with an empty response, so that the server doesn't post
+// anything back.
+func (r *WebhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payload model.OutgoingWebhookPayload
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	atomic.AddInt64(&r.deliveries, 1)
+	if r.metrics != nil {
+		r.metrics.Deliveries.Inc()
+		if payload.Timestamp > 0 {
+			elapsed := time.Since(time.UnixMilli(payload.Timestamp)).Seconds()
+			r.metrics.DeliveryTimes.Observe(elapsed)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
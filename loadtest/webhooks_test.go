@@ -0,0 +1,77 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package loadtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-load-test-ng/performance"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookReceiver(t *testing.T) {
+	_, err := NewWebhookReceiver("", nil)
+	require.Error(t, err)
+
+	metrics := performance.NewMetrics().WebhookMetrics()
+	r, err := NewWebhookReceiver("127.0.0.1:0", metrics)
+	require.NoError(t, err)
+	require.Nil(t, r.Addr())
+	require.Error(t, r.Stop())
+
+	require.NoError(t, r.Start())
+	require.Error(t, r.Start())
+	url := "http://" + r.Addr().String()
+
+	payload, err := json.Marshal(model.OutgoingWebhookPayload{
+		PostId:    model.NewId(),
+		Text:      "trigger",
+		Timestamp: time.Now().Add(-time.Second).UnixMilli(),
+	})
+	require.NoError(t, err)
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(payload))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Post(url, "application/json", bytes.NewReader([]byte("invalid")))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	require.Equal(t, int64(1), r.Deliveries())
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.Deliveries))
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.DeliveryTimes))
+
+	require.NoError(t, r.Stop())
+	require.Nil(t, r.Addr())
+
+	// The receiver can be started again, as load-tests can.
+	require.NoError(t, r.Start())
+	require.NoError(t, r.Stop())
+}
+
+func TestWebhooksConfigurationIsValid(t *testing.T) {
+	cfg := WebhooksConfiguration{}
+	require.NoError(t, cfg.IsValid())
+
+	cfg.ReceiverListenAddress = ":4010"
+	require.EqualError(t, cfg.IsValid(), "ReceiverURL should be set when ReceiverListenAddress is set")
+
+	cfg.ReceiverURL = "http://localhost:4010"
+	require.NoError(t, cfg.IsValid())
+}
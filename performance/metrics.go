@@ -5,6 +5,7 @@ package performance
 
 import (
 	"net/http"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -14,18 +15,101 @@ const (
 	metricsNamespace     = "loadtest"
 	metricsSubSystemHTTP = "http"
 	metricsSubSystemWS   = "websocket"
+	metricsSubSystemAct  = "action"
+	metricsSubSystemSt   = "store"
+	metricsSubSystemWH   = "webhook"
 )
 
 type UserEntityMetrics struct {
-	HTTPRequestTimes     prometheus.Histogram
-	HTTPErrors           *prometheus.CounterVec
-	HTTPTimeouts         *prometheus.CounterVec
-	WebSocketConnections prometheus.Gauge
+	HTTPRequestTimes            prometheus.Histogram
+	HTTPErrors                  *prometheus.CounterVec
+	HTTPTimeouts                *prometheus.CounterVec
+	HTTPThrottled               *prometheus.CounterVec
+	WebSocketConnections        prometheus.Gauge
+	WebSocketEventDeliveryTimes prometheus.Histogram
+	StoreItems                  *StoreMetrics
+}
+
+// StoreSizer is implemented by the stores of the users, reporting the number
+// of items they hold, keyed by type.
+type StoreSizer interface {
+	Sizes() map[string]int
+}
+
+// StoreMetrics is a collector reporting the total number of items held by the
+// stores of the connected users, labeled by type.
+type StoreMetrics struct {
+	desc   *prometheus.Desc
+	lock   sync.Mutex
+	stores map[StoreSizer]struct{}
+}
+
+func newStoreMetrics() *StoreMetrics {
+	return &StoreMetrics{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, metricsSubSystemSt, "items"),
+			"The total number of items held by the stores of the connected users.",
+			[]string{"type"},
+			nil,
+		),
+		stores: map[StoreSizer]struct{}{},
+	}
+}
+
+// Track adds the given store to the ones reported.
+func (m *StoreMetrics) Track(s StoreSizer) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.stores[s] = struct{}{}
+}
+
+// Untrack removes the given store from the ones reported.
+func (m *StoreMetrics) Untrack(s StoreSizer) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.stores, s)
+}
+
+// Describe implements the prometheus.Collector interface.
+func (m *StoreMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.desc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (m *StoreMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.lock.Lock()
+	totals := map[string]int{}
+	for s := range m.stores {
+		for typ, size := range s.Sizes() {
+			totals[typ] += size
+		}
+	}
+	m.lock.Unlock()
+
+	for typ, total := range totals {
+		ch <- prometheus.MustNewConstMetric(m.desc, prometheus.GaugeValue, float64(total), typ)
+	}
+}
+
+// ActionMetrics holds the metrics of the actions run by the controllers,
+// labeled by action and persona.
+type ActionMetrics struct {
+	ActionTimes  *prometheus.HistogramVec
+	ActionErrors *prometheus.CounterVec
+}
+
+// WebhookMetrics holds the metrics of the outgoing webhooks delivered by the
+// server to the agent.
+type WebhookMetrics struct {
+	Deliveries    prometheus.Counter
+	DeliveryTimes prometheus.Histogram
 }
 
 type Metrics struct {
-	registry  *prometheus.Registry
-	ueMetrics UserEntityMetrics
+	registry   *prometheus.Registry
+	ueMetrics  UserEntityMetrics
+	actMetrics ActionMetrics
+	whMetrics  WebhookMetrics
 }
 
 func NewMetrics() *Metrics {
@@ -58,6 +142,15 @@ func NewMetrics() *Metrics {
 		[]string{"path", "method"})
 	m.registry.MustRegister(m.ueMetrics.HTTPTimeouts)
 
+	m.ueMetrics.HTTPThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubSystemHTTP,
+		Name:      "throttled_total",
+		Help:      "The total number of HTTP requests rate limited by the server.",
+	},
+		[]string{"path", "method"})
+	m.registry.MustRegister(m.ueMetrics.HTTPThrottled)
+
 	m.ueMetrics.WebSocketConnections = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Subsystem: metricsSubSystemWS,
@@ -66,6 +159,53 @@ func NewMetrics() *Metrics {
 	})
 	m.registry.MustRegister(m.ueMetrics.WebSocketConnections)
 
+	m.ueMetrics.WebSocketEventDeliveryTimes = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubSystemWS,
+		Name:      "event_delivery_time",
+		Help:      "The time elapsed between the creation of a post and the reception of the related WebSocket event.",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	})
+	m.registry.MustRegister(m.ueMetrics.WebSocketEventDeliveryTimes)
+
+	m.ueMetrics.StoreItems = newStoreMetrics()
+	m.registry.MustRegister(m.ueMetrics.StoreItems)
+
+	m.actMetrics.ActionTimes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubSystemAct,
+		Name:      "run_time",
+		Help:      "The time taken to run user actions.",
+	},
+		[]string{"action", "persona"})
+	m.registry.MustRegister(m.actMetrics.ActionTimes)
+
+	m.actMetrics.ActionErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubSystemAct,
+		Name:      "errors_total",
+		Help:      "The total number of user actions that failed.",
+	},
+		[]string{"action", "persona"})
+	m.registry.MustRegister(m.actMetrics.ActionErrors)
+
+	m.whMetrics.Deliveries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubSystemWH,
+		Name:      "deliveries_total",
+		Help:      "The total number of outgoing webhooks received from the server.",
+	})
+	m.registry.MustRegister(m.whMetrics.Deliveries)
+
+	m.whMetrics.DeliveryTimes = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubSystemWH,
+		Name:      "delivery_time",
+		Help:      "The time elapsed between the creation of a post and the reception of the related outgoing webhook.",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	})
+	m.registry.MustRegister(m.whMetrics.DeliveryTimes)
+
 	return &m
 }
 
@@ -76,3 +216,11 @@ func (m *Metrics) Handler() http.Handler {
 func (m *Metrics) UserEntityMetrics() *UserEntityMetrics {
 	return &m.ueMetrics
 }
+
+func (m *Metrics) ActionMetrics() *ActionMetrics {
+	return &m.actMetrics
+}
+
+func (m *Metrics) WebhookMetrics() *WebhookMetrics {
+	return &m.whMetrics
+}